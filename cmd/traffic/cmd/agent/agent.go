@@ -123,6 +123,7 @@ func Main(ctx context.Context, args ...string) error {
 	info := &rpc.AgentInfo{
 		Name:      config.AgentConfig().AgentName,
 		PodIp:     config.PodIP(),
+		PodName:   config.PodName(),
 		Product:   "telepresence",
 		Version:   version.Version,
 		Namespace: config.AgentConfig().Namespace,
@@ -183,6 +184,20 @@ func Main(ctx context.Context, args ...string) error {
 			if err != nil {
 				return err
 			}
+			cnMountPoint := filepath.Join(agentconfig.ExportsMountPoint, filepath.Base(cn.MountPoint))
+			if len(cn.Intercepts) == 0 {
+				// Nothing to intercept, e.g. a container of a Job. The manager makes the environment
+				// and mounts of such containers available to clients using the agent info.
+				if info.Containers == nil {
+					info.Containers = make(map[string]*rpc.AgentInfo_ContainerInfo)
+				}
+				info.Containers[cn.Name] = &rpc.AgentInfo_ContainerInfo{
+					Environment: env,
+					MountPoint:  cnMountPoint,
+				}
+				info.SftpPort = int32(state.SftpPort())
+				continue
+			}
 
 			// Group the containers intercepts by agent port
 			icStates := make(map[agentconfig.PortAndProto][]*agentconfig.Intercept, len(cn.Intercepts))
@@ -201,7 +216,6 @@ func Main(ctx context.Context, args ...string) error {
 				g.Go(fmt.Sprintf("forward-%s:%d", cn.Name, cp), func(ctx context.Context) error {
 					return fwd.Serve(tunnel.WithPool(ctx, tunnel.NewPool()), nil)
 				})
-				cnMountPoint := filepath.Join(agentconfig.ExportsMountPoint, filepath.Base(cn.MountPoint))
 				state.AddInterceptState(NewInterceptState(state, fwd, ics, cnMountPoint, env))
 			}
 		}
@@ -2,6 +2,7 @@ package agent_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -22,7 +23,10 @@ import (
 
 const serviceName = "test-echo"
 const namespace = "teltest"
-const podIP = "192.168.50.34"
+const (
+	podIP   = "192.168.50.34"
+	podName = "test-echo-6c8bd4b4f-x2q5n"
+)
 
 var testConfig = agentconfig.Sidecar{
 	Create:       false,
@@ -68,6 +72,7 @@ func testContext(t *testing.T, env dos.MapEnv) context.Context {
 	require.NoError(t, afero.WriteFile(fs, filepath.Join(agentconfig.ConfigMountPoint, agentconfig.ConfigFile), y, 0600))
 
 	env[agentconfig.EnvPrefixAgent+"POD_IP"] = podIP
+	env[agentconfig.EnvPrefixAgent+"NAME"] = podName
 
 	ctx := dlog.NewTestContext(t, false)
 	ctx = dos.WithFS(ctx, aferofs.Wrap(fs))
@@ -80,6 +85,26 @@ func Test_LoadConfig(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, &testConfig, config.AgentConfig())
 	require.Equal(t, podIP, config.PodIP())
+	require.Equal(t, podName, config.PodName())
+}
+
+func Test_LoadConfigFromEnv(t *testing.T) {
+	ac := testConfig
+	ac.AgentName = "test-echo-ephemeral"
+	ac.Ephemeral = true
+	js, err := json.Marshal(&ac)
+	require.NoError(t, err)
+
+	// The config in the environment takes precedence over the config file
+	ctx := testContext(t, dos.MapEnv{agentconfig.EnvAgentConfig: string(js)})
+	require.NoError(t, dos.RemoveAll(ctx, agentconfig.ExportsMountPoint))
+	config, err := agent.LoadConfig(ctx)
+	require.NoError(t, err)
+	require.Equal(t, &ac, config.AgentConfig())
+
+	// An ephemeral agent creates its exports directory
+	_, err = dos.Stat(ctx, agentconfig.ExportsMountPoint)
+	require.NoError(t, err)
 }
 
 func Test_AppEnvironment(t *testing.T) {
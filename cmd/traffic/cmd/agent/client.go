@@ -13,7 +13,9 @@ import (
 	"github.com/blang/semver"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/datawire/dlib/dcontext"
@@ -25,6 +27,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 	"github.com/telepresenceio/telepresence/v2/pkg/log"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+	"github.com/telepresenceio/telepresence/v2/pkg/watchstate"
 )
 
 func GetAmbassadorCloudConnectionInfo(ctx context.Context, address string) (*rpc.AmbassadorCloudConnection, error) {
@@ -158,13 +161,8 @@ func TalkToManager(ctx context.Context, address string, info *rpc.AgentInfo, sta
 
 	snapshots := make(chan *rpc.InterceptInfoSnapshot)
 
-	// Call WatchIntercepts
-	stream, err := manager.WatchIntercepts(ctx, session)
-	if err != nil {
-		return err
-	}
 	wg.Go("interceptWait", func(ctx context.Context) error {
-		return interceptWaitLoop(ctx, cancel, snapshots, stream)
+		return interceptWaitLoop(ctx, cancel, snapshots, manager, session)
 	})
 	wg.Go("handleIntercept", func(ctx context.Context) error {
 		return handleInterceptLoop(ctx, snapshots, state, manager, session)
@@ -216,17 +214,60 @@ func handleInterceptLoop(ctx context.Context, snapshots <-chan *rpc.InterceptInf
 	}
 }
 
-func interceptWaitLoop(ctx context.Context, cancel context.CancelFunc, snapshots chan<- *rpc.InterceptInfoSnapshot, stream rpc.Manager_WatchInterceptsClient) error {
+// interceptWaitLoop watches the intercepts of this agent and sends complete snapshots to the given
+// channel. An interrupted watch is resumed from the revision of the last snapshot that was received,
+// so that the traffic-manager only sends what changed since then. The loop gives up when a resumed
+// watch fails before it delivers anything.
+func interceptWaitLoop(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	snapshots chan<- *rpc.InterceptInfoSnapshot,
+	manager rpc.ManagerClient,
+	session *rpc.SessionInfo,
+) error {
 	defer cancel() // Drop the gRPC connection if we leave this function
+	var current map[string]*rpc.InterceptInfo
+	var revision uint64
+	sinceSupported := true
 	for {
-		snapshot, err := stream.Recv()
+		var stream interface {
+			Recv() (*rpc.InterceptInfoSnapshot, error)
+		}
+		var err error
+		if sinceSupported {
+			stream, err = manager.WatchInterceptsSince(ctx, &rpc.WatchSinceRequest{Session: session, SinceRevision: revision})
+		} else {
+			stream, err = manager.WatchIntercepts(ctx, session)
+		}
 		if err != nil {
-			if ctx.Err() == nil && !errors.Is(err, io.EOF) {
-				return fmt.Errorf("stream Recv: %w", err)
+			if ctx.Err() != nil {
+				return nil
 			}
+			return fmt.Errorf("watch intercepts: %w", err)
+		}
+		received := false
+		for {
+			var snapshot *rpc.InterceptInfoSnapshot
+			if snapshot, err = stream.Recv(); err != nil {
+				break
+			}
+			received = true
+			current = watchstate.ApplyIntercepts(current, snapshot)
+			revision = snapshot.Revision
+			snapshots <- &rpc.InterceptInfoSnapshot{Intercepts: watchstate.SortedIntercepts(current), Revision: revision}
+		}
+		switch {
+		case ctx.Err() != nil || errors.Is(err, io.EOF):
 			return nil
+		case sinceSupported && !received && status.Code(err) == codes.Unimplemented:
+			// Older traffic-manager. Fall back to complete snapshots.
+			dlog.Debug(ctx, "WatchInterceptsSince is not implemented by the traffic-manager")
+			sinceSupported = false
+		case !received:
+			return fmt.Errorf("stream Recv: %w", err)
+		default:
+			dlog.Warnf(ctx, "intercept stream interrupted, resuming from revision %d: %v", revision, err)
 		}
-		snapshots <- snapshot
 	}
 }
 
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -19,11 +20,13 @@ type Config interface {
 	AgentConfig() *agentconfig.Sidecar
 	HasMounts(ctx context.Context) bool
 	PodIP() string
+	PodName() string
 }
 
 type config struct {
 	agentconfig.Sidecar
-	podIP string
+	podIP   string
+	podName string
 }
 
 // Keys that aren't useful when running on the local machine
@@ -34,17 +37,22 @@ var skipKeys = map[string]bool{
 }
 
 func LoadConfig(ctx context.Context) (Config, error) {
-	cf, err := dos.Open(ctx, filepath.Join(agentconfig.ConfigMountPoint, agentconfig.ConfigFile))
-	if err != nil {
-		return nil, fmt.Errorf("unable to open agent ConfigMap: %w", err)
-	}
-	defer cf.Close()
-
 	c := config{}
-	if err = yaml.NewDecoder(cf).Decode(&c.Sidecar); err != nil {
-		return nil, fmt.Errorf("unable to decode agent ConfigMap: %w", err)
+	if js := dos.Getenv(ctx, agentconfig.EnvAgentConfig); js != "" {
+		if err := json.Unmarshal([]byte(js), &c.Sidecar); err != nil {
+			return nil, fmt.Errorf("unable to decode agent config from %s: %w", agentconfig.EnvAgentConfig, err)
+		}
+	} else if err := loadConfigFile(ctx, &c.Sidecar); err != nil {
+		return nil, err
+	}
+	if c.Ephemeral {
+		// An ephemeral container has no exports volume
+		if err := dos.MkdirAll(ctx, agentconfig.ExportsMountPoint, 0700); err != nil {
+			return nil, err
+		}
 	}
 	c.podIP = dos.Getenv(ctx, "_TEL_AGENT_POD_IP")
+	c.podName = dos.Getenv(ctx, "_TEL_AGENT_NAME")
 	for _, cn := range c.Containers {
 		if err := addAppMounts(ctx, cn); err != nil {
 			return nil, err
@@ -60,6 +68,19 @@ func LoadConfig(ctx context.Context) (Config, error) {
 	return &c, nil
 }
 
+func loadConfigFile(ctx context.Context, ac *agentconfig.Sidecar) error {
+	cf, err := dos.Open(ctx, filepath.Join(agentconfig.ConfigMountPoint, agentconfig.ConfigFile))
+	if err != nil {
+		return fmt.Errorf("unable to open agent ConfigMap: %w", err)
+	}
+	defer cf.Close()
+
+	if err = yaml.NewDecoder(cf).Decode(ac); err != nil {
+		return fmt.Errorf("unable to decode agent ConfigMap: %w", err)
+	}
+	return nil
+}
+
 func (c *config) AgentConfig() *agentconfig.Sidecar {
 	return &c.Sidecar
 }
@@ -77,6 +98,10 @@ func (c *config) PodIP() string {
 	return c.podIP
 }
 
+func (c *config) PodName() string {
+	return c.podName
+}
+
 func OtelResources(ctx context.Context, c Config) []attribute.KeyValue {
 	return []attribute.KeyValue{
 		attribute.Bool("tel2.has-mounts", c.HasMounts(ctx)),
@@ -86,6 +111,7 @@ func OtelResources(ctx context.Context, c Config) []attribute.KeyValue {
 		attribute.Bool("tel2.manual", c.AgentConfig().Manual),
 		attribute.String("k8s.namespace", c.AgentConfig().Namespace),
 		attribute.String("k8s.pod-ip", c.PodIP()),
+		attribute.String("k8s.pod-name", c.PodName()),
 	}
 }
 
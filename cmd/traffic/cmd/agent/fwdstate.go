@@ -57,6 +57,10 @@ func (fs *fwdState) InterceptInfo(ctx context.Context, callerID, path string, co
 func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.InterceptInfo) []*manager.ReviewInterceptRequest {
 	var myChoice, activeIntercept *manager.InterceptInfo
 
+	// Intercepts that target another pod are served by the agent in that pod. This
+	// agent must neither review them nor intercept the traffic that they concern.
+	cepts = fs.interceptsForPod(ctx, cepts)
+
 	// Find the chosen intercept if it still exists
 	if fs.chosenIntercept != nil {
 		for _, cept := range cepts {
@@ -144,3 +148,21 @@ func (fs *fwdState) HandleIntercepts(ctx context.Context, cepts []*manager.Inter
 	}
 	return reviews
 }
+
+// interceptsForPod returns the intercepts that either target all pods or the pod of this agent. The
+// chosen intercept is forgotten when it no longer targets this pod.
+func (fs *fwdState) interceptsForPod(ctx context.Context, cepts []*manager.InterceptInfo) []*manager.InterceptInfo {
+	podName := fs.PodName()
+	mine := make([]*manager.InterceptInfo, 0, len(cepts))
+	for _, cept := range cepts {
+		if cept.TargetPod == "" || cept.TargetPod == podName {
+			mine = append(mine, cept)
+			continue
+		}
+		dlog.Debugf(ctx, "Ignoring intercept %q because it targets pod %s", cept.Id, cept.TargetPod)
+		if fs.chosenIntercept != nil && fs.chosenIntercept.Id == cept.Id {
+			fs.chosenIntercept = nil
+		}
+	}
+	return mine
+}
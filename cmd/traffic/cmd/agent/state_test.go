@@ -150,3 +150,44 @@ func TestState_HandleIntercepts(t *testing.T) {
 	a.Len(reviews, 0)
 	a.Equal("", f.InterceptId())
 }
+
+func TestState_HandleInterceptsForOtherPod(t *testing.T) {
+	ctx := testContext(t, nil)
+	a := assert.New(t)
+	f, s := makeFS(t, ctx)
+
+	cept := &rpc.InterceptInfo{
+		Spec: &rpc.InterceptSpec{
+			Name:                  "cept1Name",
+			Client:                "user@host1",
+			Agent:                 "agentName",
+			Mechanism:             "tcp",
+			Namespace:             namespace,
+			ServiceName:           serviceName,
+			ServicePortIdentifier: "http",
+			TargetPort:            8080,
+			Replicas:              1,
+		},
+		Id:          "intercept-01",
+		Disposition: rpc.InterceptDispositionType_WAITING,
+		TargetPod:   "test-echo-6c8bd4b4f-other",
+	}
+
+	// An intercept that targets another pod is neither reviewed nor served
+
+	reviews := s.HandleIntercepts(ctx, []*rpc.InterceptInfo{cept})
+	a.Len(reviews, 0)
+
+	cept.Disposition = rpc.InterceptDispositionType_ACTIVE
+	reviews = s.HandleIntercepts(ctx, []*rpc.InterceptInfo{cept})
+	a.Len(reviews, 0)
+	a.Equal("", f.InterceptId())
+
+	// An intercept that targets this pod is reviewed
+
+	cept.Disposition = rpc.InterceptDispositionType_WAITING
+	cept.TargetPod = podName
+	reviews = s.HandleIntercepts(ctx, []*rpc.InterceptInfo{cept})
+	a.Len(reviews, 1)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, reviews[0].Disposition)
+}
@@ -122,7 +122,7 @@ func (c *config) configureIptables(ctx context.Context, iptables *iptables.IPTab
 		err = iptables.Insert(nat, "OUTPUT", 1,
 			"-o", loopback,
 			"-p", strings.ToLower(string(proto)),
-			"!", "-d", "127.0.0.1/32",
+			"!", "-d", localhost(iptables.Proto()),
 			"-m", "owner", "--uid-owner", agentUID,
 			"-j", chain)
 		if err != nil {
@@ -143,6 +143,72 @@ func (c *config) configureIptables(ctx context.Context, iptables *iptables.IPTab
 	return nil
 }
 
+// localhost returns the CIDR of the loopback address of the given protocol.
+func localhost(p iptables.Protocol) string {
+	if p == iptables.ProtocolIPv6 {
+		return "::1/128"
+	}
+	return "127.0.0.1/32"
+}
+
+// addressFamilies returns the iptables protocols of the address families that are used by the given addresses of
+// the pod's network interfaces. Loopback and link-local addresses are ignored.
+func addressFamilies(addrs []net.Addr) []iptables.Protocol {
+	var v4, v6 bool
+	for _, addr := range addrs {
+		ipn, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipn.IP
+		if ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		if ip.To4() != nil {
+			v4 = true
+		} else {
+			v6 = true
+		}
+	}
+	var ps []iptables.Protocol
+	if v4 {
+		ps = append(ps, iptables.ProtocolIPv4)
+	}
+	if v6 {
+		ps = append(ps, iptables.ProtocolIPv6)
+	}
+	return ps
+}
+
+func findAddressFamilies(ctx context.Context) ([]iptables.Protocol, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network interface addresses: %w", err)
+	}
+	ps := addressFamilies(addrs)
+	if len(ps) == 0 {
+		// Not likely to happen, but IPv4 was the only family that was configured before
+		// families were detected.
+		dlog.Warn(ctx, "unable to detect the address families of the pod, assuming IPv4")
+		ps = []iptables.Protocol{iptables.ProtocolIPv4}
+	}
+	return ps, nil
+}
+
+func protocolName(p iptables.Protocol) string {
+	if p == iptables.ProtocolIPv6 {
+		return "ip6tables"
+	}
+	return "iptables"
+}
+
+func familyName(p iptables.Protocol) string {
+	if p == iptables.ProtocolIPv6 {
+		return "IPv6"
+	}
+	return "IPv4"
+}
+
 func findLoopback(ctx context.Context) (string, error) {
 	ifaces, err := net.Interfaces()
 	if err != nil {
@@ -179,14 +245,49 @@ func Main(ctx context.Context, args ...string) error {
 		dlog.Error(ctx, err)
 		return err
 	}
-	it, err := iptables.New()
+	ps, err := findAddressFamilies(ctx)
 	if err != nil {
-		err = fmt.Errorf("unable to create iptables instance: %w", err)
 		dlog.Error(ctx, err)
 		return err
 	}
-	if err = cfg.configureIptables(ctx, it, lo); err != nil {
-		dlog.Error(ctx, err)
+	for _, p := range ps {
+		if err = cfg.configureNetfilter(ctx, p, lo); err != nil {
+			dlog.Error(ctx, err)
+			return err
+		}
 	}
-	return err
+	return nil
+}
+
+// configureNetfilter configures the redirect of the given protocol using the backend of the config. The
+// NetfilterAuto backend uses iptables when it is usable, which requires both the binary and kernel support
+// for its nat table, and falls back to nftables when it isn't.
+func (c *config) configureNetfilter(ctx context.Context, p iptables.Protocol, lo string) error {
+	nb := c.NetfilterBackend
+	var it *iptables.IPTables
+	if nb != agentconfig.NetfilterNFTables {
+		var err error
+		if it, err = iptables.NewWithProtocol(p); err == nil && nb == agentconfig.NetfilterAuto {
+			_, err = it.ListChains(nat)
+		}
+		if err != nil {
+			if nb == agentconfig.NetfilterIPTables {
+				return fmt.Errorf("unable to create %s instance: %w", protocolName(p), err)
+			}
+			dlog.Infof(ctx, "%s is not usable, using nftables instead: %v", protocolName(p), err)
+			nb = agentconfig.NetfilterNFTables
+		}
+	}
+	if nb == agentconfig.NetfilterNFTables {
+		dlog.Infof(ctx, "Configuring nftables for %s", familyName(p))
+		if err := c.configureNftables(ctx, p, lo); err != nil {
+			return fmt.Errorf("unable to configure nftables for %s: %w", familyName(p), err)
+		}
+		return nil
+	}
+	dlog.Infof(ctx, "Configuring %s", protocolName(p))
+	if err := c.configureIptables(ctx, it, lo); err != nil {
+		return fmt.Errorf("unable to configure %s: %w", protocolName(p), err)
+	}
+	return nil
 }
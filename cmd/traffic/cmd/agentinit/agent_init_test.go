@@ -0,0 +1,50 @@
+//go:build !windows
+// +build !windows
+
+package agentinit
+
+import (
+	"net"
+	"testing"
+
+	"github.com/coreos/go-iptables/iptables"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressFamilies(t *testing.T) {
+	ipNet := func(cidr string) net.Addr {
+		ip, ipn, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ipn.IP = ip
+		return ipn
+	}
+	lo4 := ipNet("127.0.0.1/8")
+	lo6 := ipNet("::1/128")
+	ll6 := ipNet("fe80::a8c1:abff:fe4d:1/64")
+	pod4 := ipNet("10.244.0.12/24")
+	pod6 := ipNet("fd00:10:244::c/64")
+
+	tests := []struct {
+		name  string
+		addrs []net.Addr
+		want  []iptables.Protocol
+	}{
+		{"IPv4", []net.Addr{lo4, lo6, pod4, ll6}, []iptables.Protocol{iptables.ProtocolIPv4}},
+		{"IPv6", []net.Addr{lo4, lo6, pod6, ll6}, []iptables.Protocol{iptables.ProtocolIPv6}},
+		{"dual-stack", []net.Addr{lo4, lo6, pod6, pod4, ll6}, []iptables.Protocol{iptables.ProtocolIPv4, iptables.ProtocolIPv6}},
+		{"loopback only", []net.Addr{lo4, lo6}, nil},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, addressFamilies(tt.addrs))
+		})
+	}
+}
+
+func TestLocalhost(t *testing.T) {
+	assert.Equal(t, "127.0.0.1/32", localhost(iptables.ProtocolIPv4))
+	assert.Equal(t, "::1/128", localhost(iptables.ProtocolIPv6))
+}
@@ -0,0 +1,219 @@
+package agentinit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/coreos/go-iptables/iptables"
+	"github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+	"golang.org/x/sys/unix"
+	core "k8s.io/api/core/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// nftTable is the name of the table that holds all chains and rules created by the nftables backend. Unlike
+// iptables, nftables allows any number of tables, so nothing is added to tables owned by others.
+const nftTable = "telepresence"
+
+func (c *config) configureNftables(ctx context.Context, p iptables.Protocol, loopback string) error {
+	return c.applyNftables(ctx, &nftables.Conn{}, p, loopback)
+}
+
+// applyNftables implements the same redirect semantics as configureIptables using nftables. See
+// configureIptables for the rationale behind each rule.
+//
+// Base chains are ordered by their priority rather than by their position in a shared chain, so the
+// prerouting chain is given a priority just after the one used by the nat table of iptables, which is where
+// a service mesh adds its rules, and the output chain is given a priority just before it.
+func (c *config) applyNftables(ctx context.Context, conn *nftables.Conn, p iptables.Protocol, loopback string) error {
+	family := nftables.TableFamilyIPv4
+	if p == iptables.ProtocolIPv6 {
+		family = nftables.TableFamilyIPv6
+	}
+	table := &nftables.Table{Name: nftTable, Family: family}
+
+	// Adding the table before deleting it ensures that the delete succeeds, so the batch replaces any table
+	// that remains from a previous run of this container.
+	conn.AddTable(table)
+	conn.DelTable(table)
+	conn.AddTable(table)
+
+	prerouting := conn.AddChain(&nftables.Chain{
+		Name:     "prerouting",
+		Table:    table,
+		Type:     nftables.ChainTypeNAT,
+		Hooknum:  nftables.ChainHookPrerouting,
+		Priority: nftables.ChainPriorityNATDest + 1,
+	})
+	output := conn.AddChain(&nftables.Chain{
+		Name:     "output",
+		Table:    table,
+		Type:     nftables.ChainTypeNAT,
+		Hooknum:  nftables.ChainHookOutput,
+		Priority: nftables.ChainPriorityNATDest - 1,
+	})
+
+	agentUID := uint32(os.Getuid())
+	for _, proto := range []core.Protocol{core.ProtocolTCP, core.ProtocolUDP} {
+		var ics []*agentconfig.Intercept
+		for _, cn := range c.Containers {
+			for _, ic := range agentconfig.PortUniqueIntercepts(cn) {
+				if proto == ic.Protocol {
+					ics = append(ics, ic)
+				}
+			}
+		}
+		if len(ics) == 0 {
+			// no rules for the given proto
+			continue
+		}
+
+		chain := conn.AddChain(&nftables.Chain{
+			Name:  inboundChain + "_" + string(proto),
+			Table: table,
+		})
+		for _, ic := range ics {
+			conn.AddRule(&nftables.Rule{
+				Table: table,
+				Chain: chain,
+				Exprs: concatExprs(
+					matchL4Proto(proto),
+					matchDestinationPort(ic.ContainerPort),
+					redirectToPort(ic.AgentPort),
+				),
+			})
+		}
+
+		conn.AddRule(&nftables.Rule{
+			Table: table,
+			Chain: prerouting,
+			Exprs: concatExprs(matchL4Proto(proto), jumpTo(chain)),
+		})
+
+		conn.AddRule(&nftables.Rule{
+			Table: table,
+			Chain: output,
+			Exprs: concatExprs(
+				matchOutputInterface(loopback),
+				matchL4Proto(proto),
+				matchSocketUID(expr.CmpOpNeq, agentUID),
+				jumpTo(chain),
+			),
+		})
+
+		conn.AddRule(&nftables.Rule{
+			Table: table,
+			Chain: output,
+			Exprs: concatExprs(
+				matchOutputInterface(loopback),
+				matchL4Proto(proto),
+				matchNotLocalhost(p),
+				matchSocketUID(expr.CmpOpEq, agentUID),
+				jumpTo(chain),
+			),
+		})
+	}
+
+	// A return from a base chain doesn't prevent the base chains of other tables from seeing the packet, but
+	// the kernel skips the remaining nat chains of a hook once a connection has a NAT binding. Translating the
+	// destination of the agent's own connections to itself creates such a binding, so just like its iptables
+	// counterpart, this rule prevents a service mesh from redirecting the traffic of the agent.
+	conn.AddRule(&nftables.Rule{
+		Table: table,
+		Chain: output,
+		Exprs: concatExprs(
+			matchSocketUID(expr.CmpOpEq, agentUID),
+			dnatToDestination(p),
+		),
+	})
+
+	if err := conn.Flush(); err != nil {
+		return fmt.Errorf("failed to add nftables table %s: %w", nftTable, err)
+	}
+	dlog.Debugf(ctx, "added nftables table %s", nftTable)
+	return nil
+}
+
+func concatExprs(ess ...[]expr.Any) []expr.Any {
+	var all []expr.Any
+	for _, es := range ess {
+		all = append(all, es...)
+	}
+	return all
+}
+
+func matchL4Proto(proto core.Protocol) []expr.Any {
+	pn := byte(unix.IPPROTO_TCP)
+	if proto == core.ProtocolUDP {
+		pn = unix.IPPROTO_UDP
+	}
+	return []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyL4PROTO, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: []byte{pn}},
+	}
+}
+
+func matchDestinationPort(port uint16) []expr.Any {
+	return []expr.Any{
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 2, Len: 2},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: binaryutil.BigEndian.PutUint16(port)},
+	}
+}
+
+func matchOutputInterface(name string) []expr.Any {
+	// Interface names are compared as zero padded arrays of IFNAMSIZ bytes
+	ifName := make([]byte, unix.IFNAMSIZ)
+	copy(ifName, name)
+	return []expr.Any{
+		&expr.Meta{Key: expr.MetaKeyOIFNAME, Register: 1},
+		&expr.Cmp{Op: expr.CmpOpEq, Register: 1, Data: ifName},
+	}
+}
+
+func matchSocketUID(op expr.CmpOp, uid uint32) []expr.Any {
+	return []expr.Any{
+		&expr.Meta{Key: expr.MetaKeySKUID, Register: 1},
+		&expr.Cmp{Op: op, Register: 1, Data: binaryutil.NativeEndian.PutUint32(uid)},
+	}
+}
+
+func matchNotLocalhost(p iptables.Protocol) []expr.Any {
+	// Offset of the destination address in the IPv4 and IPv6 headers
+	offset, ip := uint32(16), net.IPv4(127, 0, 0, 1).To4()
+	if p == iptables.ProtocolIPv6 {
+		offset, ip = 24, net.IPv6loopback
+	}
+	return []expr.Any{
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: offset, Len: uint32(len(ip))},
+		&expr.Cmp{Op: expr.CmpOpNeq, Register: 1, Data: ip},
+	}
+}
+
+func redirectToPort(port uint16) []expr.Any {
+	return []expr.Any{
+		&expr.Immediate{Register: 1, Data: binaryutil.BigEndian.PutUint16(port)},
+		&expr.Redir{RegisterProtoMin: 1},
+	}
+}
+
+// dnatToDestination translates the destination address to itself. The port is left as is.
+func dnatToDestination(p iptables.Protocol) []expr.Any {
+	offset, size, family := uint32(16), uint32(4), uint32(unix.NFPROTO_IPV4)
+	if p == iptables.ProtocolIPv6 {
+		offset, size, family = 24, 16, unix.NFPROTO_IPV6
+	}
+	return []expr.Any{
+		&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseNetworkHeader, Offset: offset, Len: size},
+		&expr.NAT{Type: expr.NATTypeDestNAT, Family: family, RegAddrMin: 1},
+	}
+}
+
+func jumpTo(chain *nftables.Chain) []expr.Any {
+	return []expr.Any{&expr.Verdict{Kind: expr.VerdictJump, Chain: chain.Name}}
+}
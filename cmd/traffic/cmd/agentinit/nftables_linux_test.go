@@ -0,0 +1,232 @@
+package agentinit
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/coreos/go-iptables/iptables"
+	"github.com/google/nftables"
+	"github.com/google/nftables/binaryutil"
+	"github.com/google/nftables/expr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+	core "k8s.io/api/core/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// newNetNS creates a new network namespace and returns a file descriptor that references it. The test is
+// skipped when the namespace can't be created, which is the case unless the test runs with CAP_SYS_ADMIN.
+func newNetNS(t *testing.T) int {
+	t.Helper()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	threadNS := fmt.Sprintf("/proc/%d/task/%d/ns/net", os.Getpid(), unix.Gettid())
+	orig, err := unix.Open(threadNS, unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	require.NoError(t, err)
+	defer unix.Close(orig)
+
+	if err = unix.Unshare(unix.CLONE_NEWNET); err != nil {
+		t.Skipf("unable to create a network namespace: %v", err)
+	}
+	ns, err := unix.Open(threadNS, unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err == nil {
+		t.Cleanup(func() { _ = unix.Close(ns) })
+	}
+	// The thread must be restored even if the open failed
+	require.NoError(t, unix.Setns(orig, unix.CLONE_NEWNET))
+	require.NoError(t, err)
+	return ns
+}
+
+func TestApplyNftables(t *testing.T) {
+	cfg := &config{Sidecar: agentconfig.Sidecar{
+		Containers: []*agentconfig.Container{{
+			Name: "app",
+			Intercepts: []*agentconfig.Intercept{
+				{ContainerPort: 8080, AgentPort: 9900, Protocol: core.ProtocolTCP},
+				{ContainerPort: 8081, AgentPort: 9901, Protocol: core.ProtocolTCP},
+				{ContainerPort: 5353, AgentPort: 9902, Protocol: core.ProtocolUDP},
+			},
+		}},
+	}}
+
+	tests := []struct {
+		name   string
+		proto  iptables.Protocol
+		family nftables.TableFamily
+	}{
+		{"IPv4", iptables.ProtocolIPv4, nftables.TableFamilyIPv4},
+		{"IPv6", iptables.ProtocolIPv6, nftables.TableFamilyIPv6},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := dlog.NewTestContext(t, false)
+			conn := &nftables.Conn{NetNS: newNetNS(t)}
+
+			chainRules := func() map[string][]*nftables.Rule {
+				t.Helper()
+				chains, err := conn.ListChainsOfTableFamily(tt.family)
+				require.NoError(t, err)
+				crs := make(map[string][]*nftables.Rule)
+				for _, c := range chains {
+					if c.Table.Name != nftTable {
+						continue
+					}
+					rules, err := conn.GetRules(c.Table, c)
+					require.NoError(t, err)
+					crs[c.Name] = rules
+				}
+				return crs
+			}
+
+			err := cfg.applyNftables(ctx, conn, tt.proto, "lo")
+			if errors.Is(err, unix.EPROTONOSUPPORT) || errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.ENOENT) {
+				t.Skipf("nftables is not supported: %v", err)
+			}
+			require.NoError(t, err)
+			crs := chainRules()
+			require.Len(t, crs, 4)
+			assert.Len(t, crs["prerouting"], 2)
+			assert.Len(t, crs["output"], 5)
+			assert.Len(t, crs[inboundChain+"_TCP"], 2)
+			assert.Len(t, crs[inboundChain+"_UDP"], 1)
+
+			var ports [][]byte
+			for _, r := range crs[inboundChain+"_TCP"] {
+				var redir bool
+				for _, e := range r.Exprs {
+					switch e := e.(type) {
+					case *expr.Immediate:
+						ports = append(ports, e.Data)
+					case *expr.Redir:
+						redir = true
+					}
+				}
+				assert.True(t, redir)
+			}
+			assert.Equal(t, [][]byte{binaryutil.BigEndian.PutUint16(9900), binaryutil.BigEndian.PutUint16(9901)}, ports)
+
+			// Applying the config again replaces the table
+			require.NoError(t, cfg.applyNftables(ctx, conn, tt.proto, "lo"))
+			assert.Len(t, chainRules()["output"], 5)
+		})
+	}
+}
+
+// inNetNS runs the given function on a thread that has entered the given network namespace.
+func inNetNS(t *testing.T, ns int, f func()) {
+	t.Helper()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	orig, err := unix.Open(fmt.Sprintf("/proc/%d/task/%d/ns/net", os.Getpid(), unix.Gettid()), unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	require.NoError(t, err)
+	defer unix.Close(orig)
+	require.NoError(t, unix.Setns(ns, unix.CLONE_NEWNET))
+	defer func() {
+		// The thread must be restored even if f fails
+		require.NoError(t, unix.Setns(orig, unix.CLONE_NEWNET))
+	}()
+	f()
+}
+
+// serveName starts a TCP server that writes the given name to each connection, and returns its port.
+func serveName(t *testing.T, name string) uint16 {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = c.Write([]byte(name))
+			_ = c.Close()
+		}
+	}()
+	return uint16(l.Addr().(*net.TCPAddr).Port)
+}
+
+// dialName connects to the given port and returns what the server wrote.
+func dialName(t *testing.T, port uint16) string {
+	t.Helper()
+	c, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer c.Close()
+	buf := make([]byte, 16)
+	n, _ := c.Read(buf)
+	return string(buf[:n])
+}
+
+// TestApplyNftables_agentBypassesMesh verifies that the agent's own traffic isn't redirected by a service mesh
+// that adds its nat rules using a table of its own, e.g. using iptables-nft.
+func TestApplyNftables_agentBypassesMesh(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	ns := newNetNS(t)
+	conn := &nftables.Conn{NetNS: ns}
+
+	var appPort, meshPort uint16
+	inNetNS(t, ns, func() {
+		fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+		require.NoError(t, err)
+		defer unix.Close(fd)
+		ifr, err := unix.NewIfreq("lo")
+		require.NoError(t, err)
+		ifr.SetUint16(unix.IFF_UP)
+		require.NoError(t, unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr))
+		appPort = serveName(t, "app")
+		meshPort = serveName(t, "mesh")
+	})
+
+	// The mesh redirects all outbound TCP traffic to its proxy, except the traffic to the proxy itself.
+	mesh := conn.AddTable(&nftables.Table{Name: "mesh", Family: nftables.TableFamilyIPv4})
+	meshOutput := conn.AddChain(&nftables.Chain{
+		Name:     "output",
+		Table:    mesh,
+		Type:     nftables.ChainTypeNAT,
+		Hooknum:  nftables.ChainHookOutput,
+		Priority: nftables.ChainPriorityNATDest,
+	})
+	conn.AddRule(&nftables.Rule{
+		Table: mesh,
+		Chain: meshOutput,
+		Exprs: concatExprs(
+			matchL4Proto(core.ProtocolTCP),
+			[]expr.Any{
+				&expr.Payload{DestRegister: 1, Base: expr.PayloadBaseTransportHeader, Offset: 2, Len: 2},
+				&expr.Cmp{Op: expr.CmpOpNeq, Register: 1, Data: binaryutil.BigEndian.PutUint16(meshPort)},
+			},
+			redirectToPort(meshPort),
+		),
+	})
+	err := conn.Flush()
+	if errors.Is(err, unix.EPROTONOSUPPORT) || errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.ENOENT) {
+		t.Skipf("nftables is not supported: %v", err)
+	}
+	require.NoError(t, err)
+
+	// The test runs as the agent, so the mesh redirects it until the table of the agent is added.
+	inNetNS(t, ns, func() { assert.Equal(t, "mesh", dialName(t, appPort)) })
+
+	cfg := &config{Sidecar: agentconfig.Sidecar{
+		Containers: []*agentconfig.Container{{
+			Name: "app",
+			Intercepts: []*agentconfig.Intercept{
+				{ContainerPort: 8080, AgentPort: 9900, Protocol: core.ProtocolTCP},
+			},
+		}},
+	}}
+	require.NoError(t, cfg.applyNftables(ctx, conn, iptables.ProtocolIPv4, "lo"))
+	inNetNS(t, ns, func() { assert.Equal(t, "app", dialName(t, appPort)) })
+}
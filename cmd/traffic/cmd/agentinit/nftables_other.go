@@ -0,0 +1,15 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package agentinit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/coreos/go-iptables/iptables"
+)
+
+func (c *config) configureNftables(ctx context.Context, p iptables.Protocol, loopback string) error {
+	return errors.New("nftables is only supported on linux")
+}
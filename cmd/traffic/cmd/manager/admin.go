@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"context"
+	"crypto/subtle"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/install"
+)
+
+// checkAdmin verifies that the incoming request carries the admin token that the traffic-manager
+// was configured with. The admin API is disabled when no token has been configured.
+func checkAdmin(ctx context.Context) error {
+	token := managerutil.GetEnv(ctx).AdminToken
+	if token == "" {
+		return status.Error(codes.PermissionDenied, "the admin API of the traffic-manager is not enabled")
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, t := range md.Get(install.AdminTokenHeader) {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				return nil
+			}
+		}
+	}
+	return status.Error(codes.PermissionDenied, "request lacks a valid admin token")
+}
+
+// ListClientSessions returns all client sessions together with the intercepts that they own.
+func (m *Manager) ListClientSessions(ctx context.Context, _ *empty.Empty) (*rpc.ClientSessionSnapshot, error) {
+	if err := checkAdmin(ctx); err != nil {
+		return nil, err
+	}
+	dlog.Debug(ctx, "ListClientSessions called")
+
+	intercepts := m.state.GetAllIntercepts()
+	clusterID := m.clusterInfo.GetClusterID()
+	clients := m.state.GetAllClients()
+	sessions := make([]*rpc.ClientSessionInfo, 0, len(clients))
+	for sessionID, client := range clients {
+		lastMarked, ok := m.state.SessionLastMarked(sessionID)
+		if !ok {
+			// Session was removed while we were iterating
+			continue
+		}
+		// The maps returned by the state are deep copies, so it's OK to modify them.
+		client.ApiKey = ""
+		installID := client.InstallId
+		csi := &rpc.ClientSessionInfo{
+			Session: &rpc.SessionInfo{
+				SessionId: sessionID,
+				ClusterId: clusterID,
+				InstallId: &installID,
+			},
+			Client:     client,
+			LastMarked: timestamppb.New(lastMarked),
+		}
+		for _, ii := range intercepts {
+			if ii.ClientSession.SessionId == sessionID {
+				ii.ApiKey = ""
+				csi.Intercepts = append(csi.Intercepts, ii)
+			}
+		}
+		sort.Slice(csi.Intercepts, func(i, j int) bool {
+			return csi.Intercepts[i].Id < csi.Intercepts[j].Id
+		})
+		sessions = append(sessions, csi)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Client.Name < sessions[j].Client.Name
+	})
+	return &rpc.ClientSessionSnapshot{Sessions: sessions}, nil
+}
+
+// AdminRemoveIntercept removes an intercept regardless of what client session that owns it.
+func (m *Manager) AdminRemoveIntercept(ctx context.Context, req *rpc.AdminRemoveInterceptRequest) (*empty.Empty, error) {
+	if err := checkAdmin(ctx); err != nil {
+		return nil, err
+	}
+	interceptID := req.InterceptId
+	dlog.Infof(ctx, "AdminRemoveIntercept called: %s", interceptID)
+
+	if !m.state.RemoveIntercept(interceptID) {
+		return nil, status.Errorf(codes.NotFound, "Intercept with ID %q not found", interceptID)
+	}
+	return &empty.Empty{}, nil
+}
+
+// EvictSession terminates a client session and removes all intercepts that it owns.
+func (m *Manager) EvictSession(ctx context.Context, session *rpc.SessionInfo) (*empty.Empty, error) {
+	if err := checkAdmin(ctx); err != nil {
+		return nil, err
+	}
+	sessionID := session.GetSessionId()
+	dlog.Infof(ctx, "EvictSession called: %s", sessionID)
+
+	if m.state.GetClient(sessionID) == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	m.state.RemoveSession(ctx, sessionID)
+	return &empty.Empty{}, nil
+}
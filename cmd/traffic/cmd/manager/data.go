@@ -76,6 +76,8 @@ func validateIntercept(spec *rpc.InterceptSpec) string {
 		return "namespace must not be empty"
 	case spec.Mechanism == "":
 		return "mechanism must not be empty"
+	case spec.Replicas < 0 || spec.Replicas > 1:
+		return "replicas must be 0 (all replicas) or 1"
 	}
 
 	return ""
@@ -11,6 +11,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/datawire/dlib/dlog"
@@ -26,8 +27,9 @@ import (
 const supportedKubeAPIVersion = "1.17.0"
 
 type Info interface {
-	// Watch changes of an ClusterInfo and write them on the given stream
-	Watch(context.Context, rpc.Manager_WatchClusterInfoServer) error
+	// Watch changes of an ClusterInfo and write them on the given stream. The initial ClusterInfo
+	// is not written when its revision is equal to the given sinceRevision.
+	Watch(ctx context.Context, sinceRevision uint64, stream ClusterInfoStream) error
 
 	// GetClusterID returns the ClusterID
 	GetClusterID() string
@@ -41,6 +43,11 @@ type Info interface {
 	GetTrafficAgentPods(context.Context, string) ([]*corev1.Pod, error)
 }
 
+// ClusterInfoStream is the stream that Info.Watch writes to.
+type ClusterInfoStream interface {
+	Send(*rpc.ClusterInfo) error
+}
+
 type subnetRetriever interface {
 	changeNotifier(ctx context.Context, updateSubnets func(subnet.Set))
 	viable(ctx context.Context) bool
@@ -111,16 +118,16 @@ func NewInfo(ctx context.Context) Info {
 		},
 	}
 	for _, svc := range dnsServices {
-		if ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", svc.Name+"."+svc.Namespace); err == nil && len(ips) > 0 {
+		if ips, err := net.DefaultResolver.LookupIP(ctx, "ip", svc.Name+"."+svc.Namespace); err == nil && len(ips) > 0 {
 			dlog.Infof(ctx, "Using DNS IP from %s.%s", svc.Name, svc.Namespace)
-			oi.KubeDnsIp = ips[0]
+			oi.KubeDnsIp = preferIPv4(ips)
 			break
 		}
 	}
 
 	if oi.KubeDnsIp == nil && env.DNSServiceIP != "" {
 		dlog.Infof(ctx, "Unable to determine DNS IP, using user supplied IP %s", env.DNSServiceIP)
-		oi.KubeDnsIp = net.ParseIP(env.DNSServiceIP)
+		oi.KubeDnsIp = iputil.Parse(env.DNSServiceIP)
 		if oi.KubeDnsIp == nil {
 			dlog.Warn(ctx, "The user supplied IP is not a valid IP address")
 		}
@@ -143,36 +150,15 @@ func NewInfo(ctx context.Context) Info {
 	// check the error message for the correct range as suggested tin the second answer here:
 	//   https://stackoverflow.com/questions/44190607/how-do-you-find-the-cluster-service-cidr-of-a-kubernetes-cluster
 	// This requires an additional permission to create a service, which the traffic-manager
-	// should have.
-	svc := corev1.Service{
-		TypeMeta: metav1.TypeMeta{
-			Kind: "Service",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: env.ManagerNamespace,
-			Name:      "t2-tst-dummy",
-		},
-		Spec: corev1.ServiceSpec{
-			Ports:     []corev1.ServicePort{{Port: 443}},
-			ClusterIP: "1.1.1.1",
-		},
-	}
-	if _, err = client.Services(env.ManagerNamespace).Create(ctx, &svc, metav1.CreateOptions{}); err != nil {
-		svcCIDRrx := regexp.MustCompile(`range of valid IPs is (.*)$`)
-		if match := svcCIDRrx.FindStringSubmatch(err.Error()); match != nil {
-			var cidr *net.IPNet
-			if _, cidr, err = net.ParseCIDR(match[1]); err != nil {
-				dlog.Errorf(ctx, "unable to parse service CIDR %q", match[1])
-			} else {
-				dlog.Infof(ctx, "Extracting service subnet %v from create service error message", cidr)
-				oi.ServiceSubnet = iputil.IPNetToRPC(cidr)
-			}
-		} else {
-			dlog.Errorf(ctx, "unable to extract service subnet from error message %q", err.Error())
+	// should have. One attempt is made for each IP family, so that both subnets are found
+	// on a dual-stack cluster.
+	for _, clusterIP := range []string{"1.1.1.1", "100::1"} {
+		if cidr := serviceSubnetFromCreateError(ctx, client, env.ManagerNamespace, clusterIP); cidr != nil {
+			oi.ServiceSubnets = append(oi.ServiceSubnets, iputil.IPNetToRPC(cidr))
 		}
 	}
 
-	if oi.ServiceSubnet == nil && oi.KubeDnsIp != nil {
+	if len(oi.ServiceSubnets) == 0 && oi.KubeDnsIp != nil {
 		// Using a "kubectl cluster-info dump" or scanning all services generates a lot of unwanted traffic
 		// and would quite possibly also require elevated permissions, so instead, we derive the service subnet
 		// from the kubeDNS IP. This is cheating but a cluster may only have one service subnet and the mask is
@@ -181,7 +167,12 @@ func NewInfo(ctx context.Context) Info {
 		bits := len(oi.KubeDnsIp) * 8
 		ones := bits / 2
 		mask := net.CIDRMask(ones, bits) // will yield a 16 bit mask on IPv4 and 64 bit mask on IPv6.
-		oi.ServiceSubnet = &rpc.IPNet{Ip: net.IP(oi.KubeDnsIp).Mask(mask), Mask: int32(ones)}
+		oi.ServiceSubnets = []*rpc.IPNet{{Ip: net.IP(oi.KubeDnsIp).Mask(mask), Mask: int32(ones)}}
+	}
+	if len(oi.ServiceSubnets) > 0 {
+		// Clients that don't know about ServiceSubnets use the ServiceSubnet, which is the IPv4 subnet
+		// if there is one.
+		oi.ServiceSubnet = oi.ServiceSubnets[0]
 	}
 
 	podCIDRStrategy := env.PodCIDRStrategy
@@ -233,6 +224,55 @@ func NewInfo(ctx context.Context) Info {
 	return &oi
 }
 
+// serviceSubnetFromCreateError attempts to create a service with the given ClusterIP, and extracts the
+// service subnet from the error message that the API server responds with when the IP is out of range.
+// The ClusterIP determines the IP family of the subnet. Nil is returned when no subnet could be extracted,
+// which is expected for the IPv6 family on an IPv4 single-stack cluster and vice versa.
+func serviceSubnetFromCreateError(ctx context.Context, client typedcorev1.CoreV1Interface, namespace, clusterIP string) *net.IPNet {
+	svc := corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "t2-tst-dummy",
+		},
+		Spec: corev1.ServiceSpec{
+			Ports:     []corev1.ServicePort{{Port: 443}},
+			ClusterIP: clusterIP,
+		},
+	}
+	_, err := client.Services(namespace).Create(ctx, &svc, metav1.CreateOptions{})
+	if err == nil {
+		// Not expected to happen, but if it does, we shouldn't leave the service behind.
+		_ = client.Services(namespace).Delete(ctx, svc.Name, metav1.DeleteOptions{})
+		return nil
+	}
+	svcCIDRrx := regexp.MustCompile(`range of valid IPs is (.*)$`)
+	match := svcCIDRrx.FindStringSubmatch(err.Error())
+	if match == nil {
+		dlog.Debugf(ctx, "unable to extract service subnet from error message %q", err.Error())
+		return nil
+	}
+	_, cidr, err := net.ParseCIDR(match[1])
+	if err != nil {
+		dlog.Errorf(ctx, "unable to parse service CIDR %q", match[1])
+		return nil
+	}
+	dlog.Infof(ctx, "Extracting service subnet %v from create service error message", cidr)
+	return cidr
+}
+
+// preferIPv4 returns the first IPv4 address of the given slice, or the first address if it has no IPv4 address.
+func preferIPv4(ips []net.IP) net.IP {
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			return ip4
+		}
+	}
+	return ips[0]
+}
+
 func (oi *info) watchNodeSubnets(ctx context.Context) bool {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -324,8 +364,8 @@ func (oi *info) setSubnetsFromEnv(ctx context.Context) bool {
 
 // Watch will start by sending an initial snapshot of the ClusterInfo on the given stream
 // and then enter a loop where it waits for updates and sends new snapshots.
-func (oi *info) Watch(ctx context.Context, oiStream rpc.Manager_WatchClusterInfoServer) error {
-	return oi.ciSubs.subscriberLoop(ctx, oiStream)
+func (oi *info) Watch(ctx context.Context, sinceRevision uint64, oiStream ClusterInfoStream) error {
+	return oi.ciSubs.subscriberLoop(ctx, sinceRevision, oiStream)
 }
 
 func (oi *info) GetClusterID() string {
@@ -334,13 +374,15 @@ func (oi *info) GetClusterID() string {
 
 func (oi *info) clusterInfo() *rpc.ClusterInfo {
 	ci := &rpc.ClusterInfo{
-		KubeDnsIp:     oi.KubeDnsIp,
-		ServiceSubnet: oi.ServiceSubnet,
-		PodSubnets:    make([]*rpc.IPNet, len(oi.PodSubnets)),
-		ClusterDomain: oi.ClusterDomain,
-		ManagerPodIp:  oi.ManagerPodIp,
-		DnsConfig:     oi.GetDnsConfig(),
+		KubeDnsIp:      oi.KubeDnsIp,
+		ServiceSubnet:  oi.ServiceSubnet,
+		ServiceSubnets: make([]*rpc.IPNet, len(oi.ServiceSubnets)),
+		PodSubnets:     make([]*rpc.IPNet, len(oi.PodSubnets)),
+		ClusterDomain:  oi.ClusterDomain,
+		ManagerPodIp:   oi.ManagerPodIp,
+		DnsConfig:      oi.GetDnsConfig(),
 	}
+	copy(ci.ServiceSubnets, oi.ServiceSubnets)
 	copy(ci.PodSubnets, oi.PodSubnets)
 	return ci
 }
@@ -2,19 +2,24 @@ package cluster
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/license"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
 )
 
@@ -113,3 +118,57 @@ func TestNewInfo_GetClusterID(t *testing.T) {
 		require.Equal(t, info.GetClusterID(), license.ClusterIDZero)
 	})
 }
+
+func TestNewInfo_ServiceSubnets(t *testing.T) {
+	env := managerutil.Env{
+		ManagerNamespace: "test",
+		PodCIDRStrategy:  "environment",
+		PodCIDRs:         "10.244.0.0/16 fd00:10:244::/56",
+	}
+
+	// rangeReactor makes the creation of a service fail with the same error message that the API server
+	// uses when the ClusterIP is outside the service CIDR of its IP family. A family that isn't included
+	// in ranges yields a different error.
+	rangeReactor := func(ranges map[bool]string) k8stesting.ReactionFunc {
+		return func(action k8stesting.Action) (bool, runtime.Object, error) {
+			svc := action.(k8stesting.CreateAction).GetObject().(*v1.Service)
+			ip := net.ParseIP(svc.Spec.ClusterIP)
+			if r, ok := ranges[ip.To4() != nil]; ok {
+				return true, nil, fmt.Errorf(`Service "t2-tst-dummy" is invalid: spec.clusterIPs: Invalid value: []string{%q}: failed to `+
+					`allocate IP %s: provided IP is not in the valid range. The range of valid IPs is %s`, ip, ip, r)
+			}
+			return true, nil, fmt.Errorf(`Service "t2-tst-dummy" is invalid: spec.clusterIPs[0]: Invalid value: %q: `+
+				`not a valid IP family for this cluster`, ip)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		ranges map[bool]string
+		want   []string
+	}{
+		{"IPv4", map[bool]string{true: "10.96.0.0/12"}, []string{"10.96.0.0/12"}},
+		{"IPv6", map[bool]string{false: "fd00:10:96::/112"}, []string{"fd00:10:96::/112"}},
+		{"dual-stack", map[bool]string{true: "10.96.0.0/12", false: "fd00:10:96::/112"}, []string{"10.96.0.0/12", "fd00:10:96::/112"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cs := fake.NewSimpleClientset()
+			cs.PrependReactor("create", "services", rangeReactor(tt.ranges))
+
+			ctx := k8sapi.WithK8sInterface(context.Background(), cs)
+			ctx = managerutil.WithEnv(ctx, &env)
+			ci := NewInfo(ctx).(*info).clusterInfo()
+
+			got := make([]string, len(ci.ServiceSubnets))
+			for i, sn := range ci.ServiceSubnets {
+				got[i] = iputil.IPNetFromRPC(sn).String()
+			}
+			assert.Equal(t, tt.want, got)
+			require.NotNil(t, ci.ServiceSubnet)
+			assert.Equal(t, tt.want[0], iputil.IPNetFromRPC(ci.ServiceSubnet).String())
+			require.Len(t, ci.PodSubnets, 2)
+		})
+	}
+}
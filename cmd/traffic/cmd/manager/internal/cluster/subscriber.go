@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
 )
@@ -18,7 +19,9 @@ type clusterInfoSubscribers struct {
 
 func newClusterInfoSubscribers() *clusterInfoSubscribers {
 	return &clusterInfoSubscribers{
-		current:     &rpc.ClusterInfo{},
+		// Revisions start at the current time, so that a revision that was obtained from a
+		// previous incarnation of the traffic-manager is never mistaken for a current one.
+		current:     &rpc.ClusterInfo{Revision: uint64(time.Now().UnixNano())},
 		subscribers: make(map[int]chan *rpc.ClusterInfo),
 	}
 }
@@ -29,6 +32,7 @@ func (ss *clusterInfoSubscribers) notify(ctx context.Context, ci *rpc.ClusterInf
 	if clusterInfoEqual(ss.current, ci) {
 		return
 	}
+	ci.Revision = ss.current.Revision + 1
 	ss.current = ci
 	for _, ch := range ss.subscribers {
 		select {
@@ -40,7 +44,7 @@ func (ss *clusterInfoSubscribers) notify(ctx context.Context, ci *rpc.ClusterInf
 	}
 }
 
-func (ss *clusterInfoSubscribers) subscribe() (int, <-chan *rpc.ClusterInfo) {
+func (ss *clusterInfoSubscribers) subscribe(sinceRevision uint64) (int, <-chan *rpc.ClusterInfo) {
 	ch := make(chan *rpc.ClusterInfo, 3)
 	ss.Lock()
 	id := ss.idGen
@@ -48,7 +52,7 @@ func (ss *clusterInfoSubscribers) subscribe() (int, <-chan *rpc.ClusterInfo) {
 	ss.subscribers[id] = ch
 	curr := ss.current
 	ss.Unlock()
-	if curr.KubeDnsIp != nil {
+	if curr.KubeDnsIp != nil && curr.Revision != sinceRevision {
 		// Post initial state
 		ch <- curr
 	}
@@ -67,10 +71,8 @@ func (ss *clusterInfoSubscribers) unsubscribe(id int) {
 	}
 }
 
-func (ss *clusterInfoSubscribers) subscriberLoop(ctx context.Context, rec interface {
-	Send(request *rpc.ClusterInfo) error
-}) error {
-	id, ch := ss.subscribe()
+func (ss *clusterInfoSubscribers) subscriberLoop(ctx context.Context, sinceRevision uint64, rec ClusterInfoStream) error {
+	id, ch := ss.subscribe(sinceRevision)
 	defer ss.unsubscribe(id)
 	for {
 		select {
@@ -91,15 +93,20 @@ func (ss *clusterInfoSubscribers) subscriberLoop(ctx context.Context, rec interf
 }
 
 func clusterInfoEqual(a, b *rpc.ClusterInfo) bool {
-	if len(a.PodSubnets) != len(b.PodSubnets) ||
-		a.ServiceSubnet != b.ServiceSubnet ||
-		a.ClusterDomain != b.ClusterDomain ||
-		!net.IP(a.KubeDnsIp).Equal(b.KubeDnsIp) {
+	return a.ServiceSubnet == b.ServiceSubnet &&
+		a.ClusterDomain == b.ClusterDomain &&
+		net.IP(a.KubeDnsIp).Equal(b.KubeDnsIp) &&
+		subnetsEqual(a.ServiceSubnets, b.ServiceSubnets) &&
+		subnetsEqual(a.PodSubnets, b.PodSubnets)
+}
+
+func subnetsEqual(a, b []*rpc.IPNet) bool {
+	if len(a) != len(b) {
 		return false
 	}
-	for i, aps := range a.PodSubnets {
-		bps := b.PodSubnets[i]
-		if !net.IP(aps.Ip).Equal(bps.Ip) || aps.Mask != bps.Mask {
+	for i, as := range a {
+		bs := b[i]
+		if !net.IP(as.Ip).Equal(bs.Ip) || as.Mask != bs.Mask {
 			return false
 		}
 	}
@@ -0,0 +1,244 @@
+// Package lifecycle delivers events about the lifecycle of intercepts and client sessions to
+// webhook endpoints configured for the traffic-manager.
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/datawire/dlib/dtime"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+type EventType string
+
+const (
+	InterceptCreated    = EventType("intercept-created")
+	InterceptActive     = EventType("intercept-active")
+	InterceptAgentError = EventType("intercept-agent-error")
+	InterceptRemoved    = EventType("intercept-removed")
+	ClientArrived       = EventType("client-arrived")
+	ClientExpired       = EventType("client-expired")
+)
+
+const (
+	queueSize      = 256
+	maxAttempts    = 5
+	initialBackoff = 500 * time.Millisecond
+	maxBackoff     = 10 * time.Second
+	postTimeout    = 10 * time.Second
+)
+
+// Client describes the client that an Event concerns. It never includes the client's API key.
+type Client struct {
+	SessionID string `json:"sessionId"`
+	Name      string `json:"name"`
+	InstallID string `json:"installId"`
+	Product   string `json:"product"`
+	Version   string `json:"version"`
+}
+
+// Intercept describes the intercept that an Event concerns.
+type Intercept struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Workload    string `json:"workload"`
+	Namespace   string `json:"namespace"`
+	Disposition string `json:"disposition"`
+	Message     string `json:"message,omitempty"`
+}
+
+// Event is the JSON payload that is posted to the webhook endpoints.
+type Event struct {
+	Type      EventType  `json:"type"`
+	Time      time.Time  `json:"time"`
+	Client    *Client    `json:"client,omitempty"`
+	Intercept *Intercept `json:"intercept,omitempty"`
+}
+
+// Notifier posts events to a set of webhook endpoints. Each endpoint has its own queue, and
+// the Run method delivers the events of each queue asynchronously and in order, so that an
+// unavailable endpoint doesn't delay the delivery to the others. A nil Notifier silently
+// discards all events.
+type Notifier struct {
+	endpoints []*endpoint
+	client    *http.Client
+}
+
+// endpoint is a webhook endpoint and the queue of events that are yet to be posted to it.
+type endpoint struct {
+	url    string
+	events chan *queuedEvent
+}
+
+// queuedEvent is a marshalled Event.
+type queuedEvent struct {
+	eventType EventType
+	data      []byte
+}
+
+type notifierKey struct{}
+
+// NewNotifier returns a Notifier that posts events to the given endpoints, or nil if there are none.
+func NewNotifier(endpoints []string) *Notifier {
+	if len(endpoints) == 0 {
+		return nil
+	}
+	eps := make([]*endpoint, len(endpoints))
+	for i, url := range endpoints {
+		eps[i] = &endpoint{url: url, events: make(chan *queuedEvent, queueSize)}
+	}
+	return &Notifier{
+		endpoints: eps,
+		client:    &http.Client{Timeout: postTimeout},
+	}
+}
+
+func WithNotifier(ctx context.Context, n *Notifier) context.Context {
+	return context.WithValue(ctx, notifierKey{}, n)
+}
+
+func GetNotifier(ctx context.Context) *Notifier {
+	n, _ := ctx.Value(notifierKey{}).(*Notifier)
+	return n
+}
+
+// ClientEvent queues an event concerning the client with the given session ID.
+func (n *Notifier) ClientEvent(ctx context.Context, et EventType, sessionID string, ci *rpc.ClientInfo) {
+	if n == nil || ci == nil {
+		return
+	}
+	n.notify(ctx, &Event{Type: et, Client: newClient(sessionID, ci)})
+}
+
+// InterceptEvent queues an event concerning the given intercept and the client that owns it.
+func (n *Notifier) InterceptEvent(ctx context.Context, et EventType, ii *rpc.InterceptInfo, ci *rpc.ClientInfo) {
+	if n == nil || ii == nil {
+		return
+	}
+	spec := ii.Spec
+	ev := &Event{
+		Type: et,
+		Intercept: &Intercept{
+			ID:          ii.Id,
+			Name:        spec.Name,
+			Workload:    spec.Agent,
+			Namespace:   spec.Namespace,
+			Disposition: ii.Disposition.String(),
+			Message:     ii.Message,
+		},
+	}
+	if ci != nil {
+		ev.Client = newClient(ii.ClientSession.GetSessionId(), ci)
+	} else {
+		// The client session is already gone.
+		ev.Client = &Client{SessionID: ii.ClientSession.GetSessionId(), Name: spec.Client}
+	}
+	n.notify(ctx, ev)
+}
+
+func newClient(sessionID string, ci *rpc.ClientInfo) *Client {
+	return &Client{
+		SessionID: sessionID,
+		Name:      ci.Name,
+		InstallID: ci.InstallId,
+		Product:   ci.Product,
+		Version:   ci.Version,
+	}
+}
+
+// notify queues the event for each endpoint without blocking. The event is dropped for an
+// endpoint whose queue is full, which means that the endpoint has been unavailable for a
+// long time.
+func (n *Notifier) notify(ctx context.Context, ev *Event) {
+	ev.Time = time.Now()
+	data, err := json.Marshal(ev)
+	if err != nil {
+		dlog.Errorf(ctx, "unable to marshal %s event: %v", ev.Type, err)
+		return
+	}
+	qe := &queuedEvent{eventType: ev.Type, data: data}
+	for _, ep := range n.endpoints {
+		select {
+		case ep.events <- qe:
+		default:
+			dlog.Errorf(ctx, "lifecycle webhook queue for %s is full, dropping %s event", ep.url, ev.Type)
+		}
+	}
+}
+
+// Run delivers queued events, using one goroutine per endpoint, until the context is cancelled.
+func (n *Notifier) Run(ctx context.Context) error {
+	urls := make([]string, len(n.endpoints))
+	for i, ep := range n.endpoints {
+		urls[i] = ep.url
+	}
+	dlog.Infof(ctx, "Posting lifecycle events to %v", urls)
+	wg := sync.WaitGroup{}
+	wg.Add(len(n.endpoints))
+	for _, ep := range n.endpoints {
+		go func(ep *endpoint) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case qe := <-ep.events:
+					n.deliver(ctx, ep.url, qe.eventType, qe.data)
+				}
+			}
+		}(ep)
+	}
+	wg.Wait()
+	return nil
+}
+
+// deliver posts the data to the given endpoint. Failed attempts are retried with an
+// exponential backoff, unless the endpoint rejects the event with a 4xx status.
+func (n *Notifier) deliver(ctx context.Context, endpoint string, et EventType, data []byte) {
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(ctx, endpoint, data)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		if !retry || attempt == maxAttempts {
+			dlog.Errorf(ctx, "failed to post %s event to %s: %v", et, endpoint, err)
+			return
+		}
+		dlog.Debugf(ctx, "failed to post %s event to %s, retrying in %s: %v", et, endpoint, backoff, err)
+		dtime.SleepWithContext(ctx, backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, endpoint string, data []byte) (bool, error) {
+	rq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	rq.Header.Set("Content-Type", "application/json")
+	rs, err := n.client.Do(rq)
+	if err != nil {
+		return true, err
+	}
+	_, _ = io.Copy(io.Discard, rs.Body)
+	_ = rs.Body.Close()
+	switch {
+	case rs.StatusCode < 300:
+		return false, nil
+	case rs.StatusCode == http.StatusTooManyRequests || rs.StatusCode >= 500:
+		return true, fmt.Errorf("status %s", rs.Status)
+	default:
+		return false, fmt.Errorf("status %s", rs.Status)
+	}
+}
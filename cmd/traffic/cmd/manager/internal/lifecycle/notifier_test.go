@@ -0,0 +1,147 @@
+package lifecycle_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/internal/lifecycle"
+)
+
+func TestNotifier(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+
+	var attempts int32
+	received := make(chan *lifecycle.Event, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			// First attempt fails and must be retried
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var ev lifecycle.Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- &ev
+	}))
+	defer srv.Close()
+
+	n := lifecycle.NewNotifier([]string{srv.URL})
+	require.NotNil(t, n)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = n.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	client := &rpc.ClientInfo{Name: "alice@example.com", InstallId: "abc", Product: "telepresence", Version: "v2.7.0", ApiKey: "secret"}
+	n.ClientEvent(ctx, lifecycle.ClientArrived, "session-1", client)
+	n.InterceptEvent(ctx, lifecycle.InterceptActive, &rpc.InterceptInfo{
+		Id:            "session-1:echo",
+		Spec:          &rpc.InterceptSpec{Name: "echo", Agent: "echo-server", Namespace: "staging"},
+		ClientSession: &rpc.SessionInfo{SessionId: "session-1"},
+		Disposition:   rpc.InterceptDispositionType_ACTIVE,
+	}, client)
+
+	next := func() *lifecycle.Event {
+		t.Helper()
+		select {
+		case ev := <-received:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for event")
+			return nil
+		}
+	}
+
+	ev := next()
+	assert.Equal(t, lifecycle.ClientArrived, ev.Type)
+	assert.Equal(t, "session-1", ev.Client.SessionID)
+	assert.Equal(t, "alice@example.com", ev.Client.Name)
+	assert.Nil(t, ev.Intercept)
+
+	ev = next()
+	assert.Equal(t, lifecycle.InterceptActive, ev.Type)
+	assert.Equal(t, "alice@example.com", ev.Client.Name)
+	assert.Equal(t, "echo-server", ev.Intercept.Workload)
+	assert.Equal(t, "staging", ev.Intercept.Namespace)
+	assert.Equal(t, "ACTIVE", ev.Intercept.Disposition)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestNotifier_unavailableEndpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+
+	// The first endpoint never responds
+	unblock := make(chan struct{})
+	stuck := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-unblock:
+		case <-r.Context().Done():
+		}
+	}))
+	defer stuck.Close()
+	defer close(unblock)
+
+	received := make(chan lifecycle.EventType, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev lifecycle.Event
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- ev.Type
+	}))
+	defer srv.Close()
+
+	n := lifecycle.NewNotifier([]string{stuck.URL, srv.URL})
+	require.NotNil(t, n)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = n.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	client := &rpc.ClientInfo{Name: "alice@example.com"}
+	n.ClientEvent(ctx, lifecycle.ClientArrived, "session-1", client)
+	n.ClientEvent(ctx, lifecycle.ClientExpired, "session-1", client)
+
+	// The events reach the second endpoint well before the first endpoint's post times out
+	for _, et := range []lifecycle.EventType{lifecycle.ClientArrived, lifecycle.ClientExpired} {
+		select {
+		case ret := <-received:
+			assert.Equal(t, et, ret)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timeout waiting for %s event", et)
+		}
+	}
+}
+
+func TestNilNotifier(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	n := lifecycle.NewNotifier(nil)
+	assert.Nil(t, n)
+
+	// A nil notifier discards events
+	n.ClientEvent(ctx, lifecycle.ClientExpired, "session-1", &rpc.ClientInfo{Name: "alice"})
+	assert.Nil(t, lifecycle.GetNotifier(ctx))
+}
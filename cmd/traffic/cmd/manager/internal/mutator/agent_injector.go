@@ -136,8 +136,10 @@ func (a *agentInjector) inject(ctx context.Context, req *admission.AdmissionRequ
 			return nil, nil
 		}
 		if config, err = agentmap.Generate(ctx, wl, env.GeneratorConfig(a.getAgentImage(ctx))); err != nil {
+			recordInjectionFailure(ctx, wl, err)
 			return nil, err
 		}
+		recordInjectionSuccess(ctx, wl)
 		if err = a.agentConfigs.Store(ctx, config, true); err != nil {
 			return nil, err
 		}
@@ -148,10 +150,21 @@ func (a *agentInjector) inject(ctx context.Context, req *admission.AdmissionRequ
 	// Create patch operations to add the traffic-agent sidecar
 	dlog.Infof(ctx, "Injecting %s into pod %s.%s", agentconfig.ContainerName, pod.Name, pod.Namespace)
 
+	patches := podPatches(ctx, pod, config)
+	if len(patches) > 0 {
+		dlog.Infof(ctx, "Injecting %d patches into pod %s.%s", len(patches), pod.Name, pod.Namespace)
+		dlog.Debugf(ctx, "Patches = %s", patches)
+	}
+	return patches, nil
+}
+
+// podPatches returns the patch operations that inject the traffic-agent described by the given config into the given pod
+func podPatches(ctx context.Context, pod *core.Pod, config *agentconfig.Sidecar) patchOps {
 	var patches patchOps
 	patches = addInitContainer(ctx, pod, config, patches)
 	patches = addAgentContainer(ctx, pod, config, patches)
 	patches = addAgentVolumes(pod, config, patches)
+	patches = addPullSecrets(pod, config, patches)
 	patches = hidePorts(pod, config, patches)
 	patches = addPodAnnotations(ctx, pod, patches)
 
@@ -160,25 +173,35 @@ func (a *agentInjector) inject(ctx context.Context, req *admission.AdmissionRequ
 		tpEnv[agentconfig.EnvAPIPort] = strconv.Itoa(int(config.APIPort))
 		patches = addTPEnv(pod, config, tpEnv, patches)
 	}
-
-	// Create patch operations to add the traffic-agent sidecar
-	if len(patches) > 0 {
-		dlog.Infof(ctx, "Injecting %d patches into pod %s.%s", len(patches), pod.Name, pod.Namespace)
-		dlog.Debugf(ctx, "Patches = %s", patches)
-	}
-	return patches, nil
+	return patches
 }
 
 func (a *agentInjector) getAgentImage(ctx context.Context) string {
 	a.Lock()
 	defer a.Unlock()
 	if a.agentImage == "" {
-		a.agentImage = managerutil.GetAgentImage(ctx)
-		dlog.Infof(ctx, "using agent image %s", a.agentImage)
+		a.agentImage = getAgentImage(ctx)
 	}
 	return a.agentImage
 }
 
+// cachedAgentImage is the agent image used when generating agent configs. It's determined once, because that
+// might require a request to Ambassador Cloud.
+var cachedAgentImage struct {
+	sync.Mutex
+	name string
+}
+
+func getAgentImage(ctx context.Context) string {
+	cachedAgentImage.Lock()
+	defer cachedAgentImage.Unlock()
+	if cachedAgentImage.name == "" {
+		cachedAgentImage.name = managerutil.GetAgentImage(ctx)
+		dlog.Infof(ctx, "using agent image %s", cachedAgentImage.name)
+	}
+	return cachedAgentImage.name
+}
+
 // uninstall ensures that no more webhook injections is made and that all the workloads of currently injected
 // pods are rolled out.
 func (a *agentInjector) uninstall(ctx context.Context) {
@@ -216,7 +239,7 @@ func addInitContainer(ctx context.Context, pod *core.Pod, config *agentconfig.Si
 	}
 
 	pis := pod.Spec.InitContainers
-	ic := agentconfig.InitContainer(config.AgentImage)
+	ic := agentconfig.InitContainer(config)
 	if len(pis) == 0 {
 		return append(patches, patchOperation{
 			Op:    "replace",
@@ -282,6 +305,39 @@ func addAgentVolumes(pod *core.Pod, ag *agentconfig.Sidecar, patches patchOps) p
 	return patches
 }
 
+// addPullSecrets creates patch operations that add the image pull secrets of the config that are
+// not already present in the pod
+func addPullSecrets(pod *core.Pod, config *agentconfig.Sidecar, patches patchOps) patchOps {
+	var missing []core.LocalObjectReference
+nextSecret:
+	for _, ps := range config.PullSecrets {
+		for _, ops := range pod.Spec.ImagePullSecrets {
+			if ops.Name == ps {
+				continue nextSecret
+			}
+		}
+		missing = append(missing, core.LocalObjectReference{Name: ps})
+	}
+	if len(missing) == 0 {
+		return patches
+	}
+	if pod.Spec.ImagePullSecrets == nil {
+		return append(patches, patchOperation{
+			Op:    "replace",
+			Path:  "/spec/imagePullSecrets",
+			Value: missing,
+		})
+	}
+	for _, ps := range missing {
+		patches = append(patches, patchOperation{
+			Op:    "add",
+			Path:  "/spec/imagePullSecrets/-",
+			Value: ps,
+		})
+	}
+	return patches
+}
+
 // compareProbes compares two Probes but will only consider their Handler.Exec.Command in the comparison
 func compareProbes(a, b *core.Probe) bool {
 	if a == nil || b == nil {
@@ -441,6 +497,10 @@ func hidePorts(pod *core.Pod, config *agentconfig.Sidecar, patches patchOps) pat
 				// Rely on iptables mapping instead of port renames
 				continue
 			}
+			if ic.TargetPortRewritten {
+				// The service references the agent's port using a generated name, so there's nothing to hide
+				continue
+			}
 			patches = hideContainerPorts(pod, app, ic.ContainerPortName, patches)
 		}
 	})
@@ -13,11 +13,15 @@ import (
 	"github.com/stretchr/testify/require"
 	admission "k8s.io/api/admission/v1"
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
 	"sigs.k8s.io/yaml"
 
@@ -34,6 +38,9 @@ const serviceAccountMountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
 func int32P(i int32) *int32 {
 	return &i
 }
+func int64P(i int64) *int64 {
+	return &i
+}
 func boolP(b bool) *bool {
 	return &b
 }
@@ -292,6 +299,127 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 		},
 	}
 
+	podDaemonSet := core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:        "named-port-ds-x7kq2",
+			Namespace:   "some-ns",
+			Annotations: map[string]string{install.InjectAnnotation: "enabled"},
+			Labels:      map[string]string{"service": "named-port"},
+			OwnerReferences: []meta.OwnerReference{
+				{
+					APIVersion: "apps/v1",
+					Kind:       "DaemonSet",
+					Name:       "named-port-ds",
+					Controller: boolP(true),
+				},
+			},
+		},
+		Spec: podNamedPort.Spec,
+	}
+
+	podRollout := core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:        "named-port-ro-x7kq2",
+			Namespace:   "some-ns",
+			Annotations: map[string]string{install.InjectAnnotation: "enabled"},
+			Labels:      map[string]string{"service": "named-port"},
+			OwnerReferences: []meta.OwnerReference{
+				{
+					APIVersion: "argoproj.io/v1alpha1",
+					Kind:       "Rollout",
+					Name:       "named-port-ro",
+					Controller: boolP(true),
+				},
+			},
+		},
+		Spec: podNamedPort.Spec,
+	}
+
+	jobPod := func(name string, annotations map[string]string) core.Pod {
+		return core.Pod{
+			ObjectMeta: meta.ObjectMeta{
+				Name:        name + "-x7kq2",
+				Namespace:   "some-ns",
+				Annotations: annotations,
+				Labels:      map[string]string{"job-name": name},
+				OwnerReferences: []meta.OwnerReference{
+					{
+						APIVersion: "batch/v1",
+						Kind:       "Job",
+						Name:       name,
+						Controller: boolP(true),
+					},
+				},
+			},
+			Spec: podNamedPort.Spec,
+		}
+	}
+	podJob := jobPod("some-job-tp-x2v4z", map[string]string{
+		install.InjectAnnotation:  "enabled",
+		agentmap.RunJobAnnotation: "some-job",
+	})
+	podPlainJob := jobPod("plain-job", map[string]string{install.InjectAnnotation: "enabled"})
+
+	job := func(pod *core.Pod) *batch.Job {
+		return &batch.Job{
+			TypeMeta: meta.TypeMeta{
+				Kind:       "Job",
+				APIVersion: "batch/v1",
+			},
+			ObjectMeta: meta.ObjectMeta{
+				Name:      pod.OwnerReferences[0].Name,
+				Namespace: "some-ns",
+			},
+			Spec: batch.JobSpec{
+				Template: core.PodTemplateSpec{
+					ObjectMeta: pod.ObjectMeta,
+					Spec:       pod.Spec,
+				},
+			},
+		}
+	}
+
+	rollout := func(pod *core.Pod, name string) *unstructured.Unstructured {
+		tm, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&core.PodTemplateSpec{
+			ObjectMeta: pod.ObjectMeta,
+			Spec:       pod.Spec,
+		})
+		require.NoError(t, err)
+		ro := &unstructured.Unstructured{Object: map[string]any{
+			"spec": map[string]any{
+				"replicas": int64(1),
+				"selector": map[string]any{"matchLabels": map[string]any{"service": "named-port"}},
+				"template": tm,
+			},
+		}}
+		ro.SetAPIVersion("argoproj.io/v1alpha1")
+		ro.SetKind("Rollout")
+		ro.SetName(name)
+		ro.SetNamespace("some-ns")
+		return ro
+	}
+
+	daemonSet := func(pod *core.Pod, name string) *apps.DaemonSet {
+		return &apps.DaemonSet{
+			TypeMeta: meta.TypeMeta{
+				Kind:       "DaemonSet",
+				APIVersion: "apps/v1",
+			},
+			ObjectMeta: meta.ObjectMeta{
+				Name:      name,
+				Namespace: "some-ns",
+				Labels:    pod.Labels,
+			},
+			Spec: apps.DaemonSetSpec{
+				Template: core.PodTemplateSpec{
+					ObjectMeta: pod.ObjectMeta,
+					Spec:       pod.Spec,
+				},
+				Selector: &meta.LabelSelector{MatchLabels: pod.Labels},
+			},
+		}
+	}
+
 	deployment := func(pod *core.Pod) *apps.Deployment {
 		name := wlName(pod.Name)
 		return &apps.Deployment{
@@ -432,7 +560,73 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 		deployment(&podNamedAndNumericPort),
 		deployment(&podMultiPort),
 		deployment(&podMultiSplitPort),
+		&podDaemonSet,
+		daemonSet(&podDaemonSet, "named-port-ds"),
+		&podRollout,
+		&podJob,
+		job(&podJob),
+		&podPlainJob,
+		job(&podPlainJob),
 	)
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{k8sapi.RolloutResource: "RolloutList"},
+		rollout(&podRollout, "named-port-ro"))
+	podAgentConfigured := podNamedPort
+	podAgentConfigured.Annotations = map[string]string{
+		install.InjectAnnotation:                `enabled`,
+		agentmap.AgentResourcesAnnotation:       `{"limits":{"cpu":"200m","memory":"128Mi"},"requests":{"cpu":"100m"}}`,
+		agentmap.AgentSecurityContextAnnotation: `{"runAsNonRoot":true,"runAsUser":1000}`,
+		agentmap.AgentPullSecretsAnnotation:     `my-secret, other-secret`,
+	}
+
+	podRedirectedNumericPort := podNumericPort
+	podRedirectedNumericPort.Annotations = map[string]string{
+		install.InjectAnnotation:          `enabled`,
+		agentmap.RedirectPolicyAnnotation: `ServiceTargetPort`,
+	}
+
+	podEphemeral := podNamedPort
+	podEphemeral.Annotations = map[string]string{
+		install.InjectAnnotation:     `enabled`,
+		agentmap.EphemeralAnnotation: `true`,
+	}
+
+	podBadEphemeral := podNamedPort
+	podBadEphemeral.Annotations = map[string]string{
+		install.InjectAnnotation:     `enabled`,
+		agentmap.EphemeralAnnotation: `sometimes`,
+	}
+
+	podNFTables := podNumericPort
+	podNFTables.Annotations = map[string]string{
+		install.InjectAnnotation:            `enabled`,
+		agentmap.NetfilterBackendAnnotation: `NFTables`,
+	}
+
+	podBadNetfilterBackend := podNumericPort
+	podBadNetfilterBackend.Annotations = map[string]string{
+		install.InjectAnnotation:            `enabled`,
+		agentmap.NetfilterBackendAnnotation: `nft`,
+	}
+
+	podBadRedirectPolicy := podNumericPort
+	podBadRedirectPolicy.Annotations = map[string]string{
+		install.InjectAnnotation:          `enabled`,
+		agentmap.RedirectPolicyAnnotation: `Iptables`,
+	}
+
+	podBadAgentResources := podNamedPort
+	podBadAgentResources.Annotations = map[string]string{
+		install.InjectAnnotation:          `enabled`,
+		agentmap.AgentResourcesAnnotation: `{"limits":{"cpu":"100m"},"requests":{"cpu":"200m"}}`,
+	}
+
+	podBadAgentSecurityContext := podNamedPort
+	podBadAgentSecurityContext.Annotations = map[string]string{
+		install.InjectAnnotation:                `enabled`,
+		agentmap.AgentSecurityContextAnnotation: `{"runAsUsr":1000}`,
+	}
+
 	tests := []struct {
 		name           string
 		request        *core.Pod
@@ -502,6 +696,157 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"Named port with agent resources, security context, and pull secrets",
+			&podAgentConfigured,
+			&agentconfig.Sidecar{
+				AgentName:    "named-port",
+				AgentImage:   "docker.io/datawire/tel2:2.6.0",
+				Namespace:    "some-ns",
+				WorkloadName: "named-port",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "http",
+								ServiceName:       "named-port",
+								ServiceUID:        namedPortUID,
+								ServicePortName:   "http",
+								ServicePort:       80,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9900,
+								ContainerPort:     8888,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+						Mounts:     []string{"/var/run/secrets/kubernetes.io/serviceaccount"},
+					},
+				},
+				Resources: &agentconfig.ResourceRequirements{
+					Limits:   map[core.ResourceName]string{core.ResourceCPU: "200m", core.ResourceMemory: "128Mi"},
+					Requests: map[core.ResourceName]string{core.ResourceCPU: "100m"},
+				},
+				SecurityContext: &core.SecurityContext{
+					RunAsNonRoot: &yes,
+					RunAsUser:    int64P(1000),
+				},
+				PullSecrets: []string{"my-secret", "other-secret"},
+			},
+			"",
+		},
+		{
+			"Error Precondition: Agent resource request greater than limit",
+			&podBadAgentResources,
+			nil,
+			"cpu request 200m is greater than its limit 100m",
+		},
+		{
+			"Error Precondition: Unknown field in agent security context",
+			&podBadAgentSecurityContext,
+			nil,
+			`unknown field "runAsUsr"`,
+		},
+		{
+			"DaemonSet with named port",
+			&podDaemonSet,
+			&agentconfig.Sidecar{
+				AgentName:    "named-port-ds",
+				AgentImage:   "docker.io/datawire/tel2:2.6.0",
+				Namespace:    "some-ns",
+				WorkloadName: "named-port-ds",
+				WorkloadKind: "DaemonSet",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "http",
+								ServiceName:       "named-port",
+								ServiceUID:        namedPortUID,
+								ServicePortName:   "http",
+								ServicePort:       80,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9900,
+								ContainerPort:     8888,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+						Mounts:     []string{"/var/run/secrets/kubernetes.io/serviceaccount"},
+					},
+				},
+			},
+			"",
+		},
+		{
+			"Rollout with named port",
+			&podRollout,
+			&agentconfig.Sidecar{
+				AgentName:    "named-port-ro",
+				AgentImage:   "docker.io/datawire/tel2:2.6.0",
+				Namespace:    "some-ns",
+				WorkloadName: "named-port-ro",
+				WorkloadKind: "Rollout",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "http",
+								ServiceName:       "named-port",
+								ServiceUID:        namedPortUID,
+								ServicePortName:   "http",
+								ServicePort:       80,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9900,
+								ContainerPort:     8888,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+						Mounts:     []string{"/var/run/secrets/kubernetes.io/serviceaccount"},
+					},
+				},
+			},
+			"",
+		},
+		{
+			"Job started by run-job",
+			&podJob,
+			&agentconfig.Sidecar{
+				AgentName:    "some-job-tp-x2v4z",
+				AgentImage:   "docker.io/datawire/tel2:2.6.0",
+				Namespace:    "some-ns",
+				WorkloadName: "some-job-tp-x2v4z",
+				WorkloadKind: "Job",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name:       "some-container",
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+						Mounts:     []string{"/var/run/secrets/kubernetes.io/serviceaccount"},
+					},
+				},
+			},
+			"",
+		},
+		{
+			"Error Precondition: Job not started by run-job",
+			&podPlainJob,
+			nil,
+			"can only be injected using telepresence run-job",
+		},
 		{
 			"Numeric port",
 			&podNumericPort,
@@ -536,6 +881,128 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"Numeric port redirected using nftables",
+			&podNFTables,
+			&agentconfig.Sidecar{
+				AgentName:    "numeric-port",
+				AgentImage:   "docker.io/datawire/tel2:2.6.0",
+				Namespace:    "some-ns",
+				WorkloadName: "numeric-port",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName: "",
+								ServiceName:       "numeric-port",
+								ServiceUID:        numericPortUID,
+								ServicePortName:   "http",
+								ServicePort:       80,
+								TargetPortNumeric: true,
+								Protocol:          core.ProtocolTCP,
+								AgentPort:         9900,
+								ContainerPort:     8899,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+					},
+				},
+				NetfilterBackend: agentconfig.NetfilterNFTables,
+			},
+			"",
+		},
+		{
+			"Numeric port redirected using the service target port",
+			&podRedirectedNumericPort,
+			&agentconfig.Sidecar{
+				AgentName:    "numeric-port",
+				AgentImage:   "docker.io/datawire/tel2:2.6.0",
+				Namespace:    "some-ns",
+				WorkloadName: "numeric-port",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ContainerPortName:   "tp-8899",
+								ServiceName:         "numeric-port",
+								ServiceUID:          numericPortUID,
+								ServicePortName:     "http",
+								ServicePort:         80,
+								TargetPortRewritten: true,
+								Protocol:            core.ProtocolTCP,
+								AgentPort:           9900,
+								ContainerPort:       8899,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+					},
+				},
+			},
+			"",
+		},
+		{
+			"Ephemeral traffic-agent",
+			&podEphemeral,
+			&agentconfig.Sidecar{
+				AgentName:    "named-port",
+				AgentImage:   "docker.io/datawire/tel2:2.6.0",
+				Namespace:    "some-ns",
+				WorkloadName: "named-port",
+				WorkloadKind: "Deployment",
+				ManagerHost:  "traffic-manager.default",
+				ManagerPort:  8081,
+				Containers: []*agentconfig.Container{
+					{
+						Name: "some-container",
+						Intercepts: []*agentconfig.Intercept{
+							{
+								ServiceName:         "named-port",
+								ServiceUID:          namedPortUID,
+								ServicePortName:     "http",
+								ServicePort:         80,
+								TargetPortRewritten: true,
+								Protocol:            core.ProtocolTCP,
+								AgentPort:           9900,
+								ContainerPort:       8888,
+							},
+						},
+						EnvPrefix:  "A_",
+						MountPoint: "/tel_app_mounts/some-container",
+						Mounts:     []string{"/var/run/secrets/kubernetes.io/serviceaccount"},
+					},
+				},
+				Ephemeral: true,
+			},
+			"",
+		},
+		{
+			"Error Precondition: Invalid ephemeral annotation",
+			&podBadEphemeral,
+			nil,
+			`invalid value for annotation telepresence.getambassador.io/inject-ephemeral`,
+		},
+		{
+			"Error Precondition: Invalid netfilter backend",
+			&podBadNetfilterBackend,
+			nil,
+			`invalid NetfilterBackend: "nft"`,
+		},
+		{
+			"Error Precondition: Invalid redirect policy",
+			&podBadRedirectPolicy,
+			nil,
+			`invalid RedirectPolicy: "Iptables"`,
+		},
 		{
 			"Unnamed Numeric port",
 			&podUnnamedNumericPort,
@@ -722,6 +1189,7 @@ func TestTrafficAgentConfigGenerator(t *testing.T) {
 	for _, test := range tests {
 		test := test // pin it
 		ctx := k8sapi.WithK8sInterface(ctx, clientset)
+		ctx = k8sapi.WithDynamicInterface(ctx, dynamicClient)
 		t.Run(test.name, func(t *testing.T) {
 			actualConfig, actualErr := generateForPod(t, ctx, test.request, env.GeneratorConfig("docker.io/datawire/tel2:2.6.0"))
 			requireContains(t, actualErr, strings.ReplaceAll(test.expectedError, "<PODNAME>", test.request.Name))
@@ -1693,6 +2161,17 @@ func generateForPod(t *testing.T, ctx context.Context, pod *core.Pod, gc *agentm
 	case *apps.StatefulSet:
 		wi.Spec.Template = tpl
 		wl = k8sapi.StatefulSet(wi)
+	case *apps.DaemonSet:
+		wi.Spec.Template = tpl
+		wl = k8sapi.DaemonSet(wi)
+	case *batch.Job:
+		wi.Spec.Template = tpl
+		wl = k8sapi.Job(wi)
+	case *unstructured.Unstructured:
+		tm, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&tpl)
+		require.NoError(t, err)
+		require.NoError(t, unstructured.SetNestedMap(wi.Object, tm, "spec", "template"))
+		wl = k8sapi.Rollout(wi)
 	default:
 		t.Fatalf("bad workload type %T", wi)
 	}
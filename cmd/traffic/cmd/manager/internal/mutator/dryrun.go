@@ -0,0 +1,71 @@
+package mutator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
+)
+
+// DryRun generates the agent config for the given workload and computes the patch that the agent injector
+// would apply to a pod created from the workload's pod template. The YAML encoded config and the JSON encoded
+// patch are returned. Nothing is stored, and nothing is changed in the cluster.
+func DryRun(ctx context.Context, wl k8sapi.Workload) (string, string, error) {
+	config, err := agentmap.Generate(ctx, wl, managerutil.GetEnv(ctx).GeneratorConfig(getAgentImage(ctx)))
+	if err != nil {
+		return "", "", err
+	}
+	bf := bytes.Buffer{}
+	if err = yaml.NewEncoder(&bf).Encode(config); err != nil {
+		return "", "", err
+	}
+
+	tpl := wl.GetPodTemplate()
+	pod := &core.Pod{
+		ObjectMeta: *tpl.ObjectMeta.DeepCopy(),
+		Spec:       *tpl.Spec.DeepCopy(),
+	}
+	pod.Namespace = wl.GetNamespace()
+	if pod.Name == "" {
+		pod.Name = wl.GetName()
+	}
+	return bf.String(), podPatches(ctx, pod, config).String(), nil
+}
+
+// DecodeWorkload decodes a YAML or JSON encoded workload. The given namespace is used when the workload
+// doesn't declare one.
+func DecodeWorkload(data []byte, namespace string) (k8sapi.Workload, error) {
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		if !runtime.IsNotRegisteredError(err) {
+			return nil, fmt.Errorf("unable to decode workload: %w", err)
+		}
+		// Might be a Rollout, which isn't known to the scheme
+		js, err := k8syaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode workload: %w", err)
+		}
+		u := &unstructured.Unstructured{}
+		if err = u.UnmarshalJSON(js); err != nil {
+			return nil, fmt.Errorf("unable to decode workload: %w", err)
+		}
+		obj = u
+	}
+	if namespace != "" {
+		if mo, err := meta.Accessor(obj); err == nil && mo.GetNamespace() == "" {
+			mo.SetNamespace(namespace)
+		}
+	}
+	return k8sapi.WrapWorkload(obj)
+}
@@ -0,0 +1,124 @@
+package mutator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
+)
+
+const echoDeployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: echo
+spec:
+  selector:
+    matchLabels:
+      app: echo
+  template:
+    metadata:
+      labels:
+        app: echo
+    spec:
+      containers:
+      - name: echo
+        image: jmalloc/echo-server
+        ports:
+        - name: http
+          containerPort: 8080
+`
+
+func TestDecodeWorkload(t *testing.T) {
+	wl, err := DecodeWorkload([]byte(echoDeployment), "some-ns")
+	require.NoError(t, err)
+	assert.Equal(t, "Deployment", wl.GetKind())
+	assert.Equal(t, "echo", wl.GetName())
+	assert.Equal(t, "some-ns", wl.GetNamespace())
+
+	wl, err = DecodeWorkload([]byte(`
+apiVersion: argoproj.io/v1alpha1
+kind: Rollout
+metadata:
+  name: echo
+  namespace: other-ns
+spec:
+  template:
+    spec:
+      containers:
+      - name: echo
+`), "some-ns")
+	require.NoError(t, err)
+	assert.Equal(t, "Rollout", wl.GetKind())
+	assert.Equal(t, "other-ns", wl.GetNamespace())
+
+	_, err = DecodeWorkload([]byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: echo
+`), "some-ns")
+	assert.Error(t, err)
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	ctx = managerutil.WithEnv(ctx, &managerutil.Env{
+		ManagerNamespace: "default",
+		AgentRegistry:    "docker.io/datawire",
+		AgentImage:       "tel2:2.6.0",
+		AgentPort:        9900,
+	})
+	ctx = k8sapi.WithK8sInterface(ctx, fake.NewSimpleClientset(&core.Service{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "echo",
+			Namespace: "some-ns",
+		},
+		Spec: core.ServiceSpec{
+			Ports: []core.ServicePort{{
+				Name:       "http",
+				Port:       80,
+				TargetPort: intstr.FromString("http"),
+			}},
+			Selector: map[string]string{"app": "echo"},
+		},
+	}))
+
+	wl, err := DecodeWorkload([]byte(echoDeployment), "some-ns")
+	require.NoError(t, err)
+	config, patch, err := DryRun(ctx, wl)
+	require.NoError(t, err)
+
+	var ac agentconfig.Sidecar
+	require.NoError(t, yaml.Unmarshal([]byte(config), &ac))
+	assert.Equal(t, "echo", ac.AgentName)
+	assert.Equal(t, "docker.io/datawire/tel2:2.6.0", ac.AgentImage)
+	require.Len(t, ac.Containers, 1)
+	require.Len(t, ac.Containers[0].Intercepts, 1)
+	assert.Equal(t, "http", ac.Containers[0].Intercepts[0].ContainerPortName)
+
+	var ops []map[string]any
+	require.NoError(t, json.Unmarshal([]byte(patch), &ops))
+	var paths []string
+	for _, op := range ops {
+		paths = append(paths, op["path"].(string))
+	}
+	assert.Contains(t, paths, "/spec/containers/-")
+	assert.Contains(t, paths, "/spec/containers/0/ports/0/name")
+	assert.Contains(t, paths, "/metadata/annotations")
+
+	// Nothing is stored
+	_, err = k8sapi.GetK8sInterface(ctx).CoreV1().ConfigMaps("some-ns").Get(ctx, agentconfig.ConfigMap, meta.GetOptions{})
+	assert.Error(t, err)
+}
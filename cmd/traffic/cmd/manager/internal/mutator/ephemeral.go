@@ -0,0 +1,105 @@
+package mutator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
+)
+
+// addEphemeralAgents adds the traffic-agent described by the given config as an ephemeral container to each
+// pod of the given workload that doesn't have a traffic-agent and hasn't terminated. Pods created later get a
+// regular traffic-agent from the agent injector. An ephemeral container can neither be updated nor removed, so
+// if a pod has a traffic-agent with another config, then the workload is rolled out instead, provided that
+// rollout is true. An error is returned if a pod that needs a traffic-agent didn't get one.
+func addEphemeralAgents(ctx context.Context, wl k8sapi.Workload, ac *agentconfig.Sidecar, rollout bool) error {
+	selector, err := wl.Selector()
+	if err != nil {
+		return fmt.Errorf("unable to get the selector of %s %s.%s: %w", wl.GetKind(), wl.GetName(), wl.GetNamespace(), err)
+	}
+	api := k8sapi.GetK8sInterface(ctx).CoreV1().Pods(wl.GetNamespace())
+	pods, err := api.List(ctx, meta.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fmt.Errorf("unable to list the pods of %s %s.%s: %w", wl.GetKind(), wl.GetName(), wl.GetNamespace(), err)
+	}
+	var needAgent []*core.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		switch pod.Status.Phase {
+		case core.PodSucceeded, core.PodFailed:
+			continue
+		}
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		env, ok := agentEnv(pod)
+		switch {
+		case !ok:
+			needAgent = append(needAgent, pod)
+		case !cmp.Equal(agentConfigFromEnv(env), ac, cmpopts.EquateEmpty()):
+			if rollout {
+				dlog.Infof(ctx, "Pod %s.%s has a %s with another config", pod.Name, pod.Namespace, agentconfig.ContainerName)
+				triggerRollout(ctx, wl)
+				return nil
+			}
+		}
+	}
+
+	failed := 0
+	for _, pod := range needAgent {
+		ec := agentconfig.EphemeralAgentContainer(pod, ac)
+		if ec == nil {
+			continue
+		}
+		pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, *ec)
+		dlog.Infof(ctx, "Adding ephemeral %s to pod %s.%s", agentconfig.ContainerName, pod.Name, pod.Namespace)
+		if _, err = api.UpdateEphemeralContainers(ctx, pod.Name, pod, meta.UpdateOptions{}); err != nil {
+			dlog.Errorf(ctx, "unable to add ephemeral %s to pod %s.%s: %v", agentconfig.ContainerName, pod.Name, pod.Namespace, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("unable to add ephemeral %s to %d of the pods of %s %s.%s",
+			agentconfig.ContainerName, failed, wl.GetKind(), wl.GetName(), wl.GetNamespace())
+	}
+	return nil
+}
+
+// agentEnv returns the environment of the regular or ephemeral traffic-agent of the given pod, and true,
+// or nil and false if the pod has no traffic-agent.
+func agentEnv(pod *core.Pod) ([]core.EnvVar, bool) {
+	for i := range pod.Spec.Containers {
+		if cn := &pod.Spec.Containers[i]; cn.Name == agentconfig.ContainerName {
+			return cn.Env, true
+		}
+	}
+	for i := range pod.Spec.EphemeralContainers {
+		if ec := &pod.Spec.EphemeralContainers[i]; ec.Name == agentconfig.ContainerName {
+			return ec.Env, true
+		}
+	}
+	return nil, false
+}
+
+// agentConfigFromEnv returns the config found in the given traffic-agent environment, or nil if no valid config
+// is found.
+func agentConfigFromEnv(env []core.EnvVar) *agentconfig.Sidecar {
+	for _, e := range env {
+		if e.Name == agentconfig.EnvAgentConfig {
+			var ac agentconfig.Sidecar
+			if json.Unmarshal([]byte(e.Value), &ac) == nil {
+				return &ac
+			}
+			break
+		}
+	}
+	return nil
+}
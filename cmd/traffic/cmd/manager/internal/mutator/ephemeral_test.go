@@ -0,0 +1,193 @@
+package mutator
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/install"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
+)
+
+func TestAddEphemeralAgents(t *testing.T) {
+	labels := map[string]string{"app": "echo"}
+	dep := &apps.Deployment{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "echo",
+			Namespace: "some-ns",
+		},
+		Spec: apps.DeploymentSpec{
+			Selector: &meta.LabelSelector{MatchLabels: labels},
+		},
+	}
+	ac := &agentconfig.Sidecar{
+		AgentImage:   "docker.io/datawire/tel2:2.7.0",
+		AgentName:    "echo",
+		Namespace:    "some-ns",
+		WorkloadName: "echo",
+		WorkloadKind: "Deployment",
+		Ephemeral:    true,
+		Containers: []*agentconfig.Container{{
+			Name:       "echo",
+			EnvPrefix:  "A_",
+			MountPoint: "/tel_app_mounts/echo",
+			Intercepts: []*agentconfig.Intercept{{
+				ServiceName:         "echo",
+				ServicePortName:     "http",
+				ServicePort:         80,
+				TargetPortRewritten: true,
+				Protocol:            core.ProtocolTCP,
+				ContainerPort:       8080,
+				AgentPort:           9900,
+			}},
+		}},
+	}
+	makePod := func(name string) *core.Pod {
+		return &core.Pod{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      name,
+				Namespace: "some-ns",
+				Labels:    labels,
+			},
+			Spec: core.PodSpec{
+				Containers: []core.Container{{
+					Name:  "echo",
+					Image: "echo:latest",
+					Ports: []core.ContainerPort{{ContainerPort: 8080}},
+				}},
+				Volumes: []core.Volume{{Name: "data"}},
+			},
+			Status: core.PodStatus{Phase: core.PodRunning},
+		}
+	}
+	plain := makePod("echo-plain")
+	pending := makePod("echo-pending")
+	pending.Status.Phase = core.PodPending
+	done := makePod("echo-done")
+	done.Status.Phase = core.PodSucceeded
+	injected := makePod("echo-injected")
+	injected.Spec.Containers = append(injected.Spec.Containers, *agentconfig.AgentContainer(injected, ac))
+
+	cs := fake.NewSimpleClientset(dep, plain, pending, done, injected)
+	ctx := dlog.NewTestContext(t, false)
+	ctx = k8sapi.WithK8sInterface(ctx, cs)
+
+	getPod := func(name string) *core.Pod {
+		t.Helper()
+		pod, err := cs.CoreV1().Pods("some-ns").Get(ctx, name, meta.GetOptions{})
+		require.NoError(t, err)
+		return pod
+	}
+	getWorkload := func() k8sapi.Workload {
+		t.Helper()
+		wl, err := k8sapi.GetWorkload(ctx, "echo", "some-ns", "Deployment")
+		require.NoError(t, err)
+		return wl
+	}
+
+	require.NoError(t, addEphemeralAgents(ctx, getWorkload(), ac, true))
+
+	// Only the pods without a traffic-agent that haven't terminated get an ephemeral one
+	ecs := getPod("echo-plain").Spec.EphemeralContainers
+	require.Len(t, ecs, 1)
+	ec := ecs[0]
+	assert.Equal(t, agentconfig.ContainerName, ec.Name)
+	assert.Empty(t, ec.Ports)
+	assert.Nil(t, ec.ReadinessProbe)
+	for _, m := range ec.VolumeMounts {
+		assert.NotEqual(t, agentconfig.ConfigVolumeName, m.Name)
+	}
+	env, ok := agentEnv(getPod("echo-plain"))
+	require.True(t, ok)
+	assert.Equal(t, ac, agentConfigFromEnv(env))
+	assert.Len(t, getPod("echo-pending").Spec.EphemeralContainers, 1)
+	assert.Empty(t, getPod("echo-done").Spec.EphemeralContainers)
+	assert.Empty(t, getPod("echo-injected").Spec.EphemeralContainers)
+	assert.NotContains(t, getWorkload().GetPodTemplate().Annotations, install.DomainPrefix+"restartedAt")
+
+	// A traffic-agent with another config cannot be updated, so the workload is rolled out
+	acn := *ac
+	acn.AgentImage = "docker.io/datawire/tel2:2.7.1"
+	require.NoError(t, addEphemeralAgents(ctx, getWorkload(), &acn, false))
+	assert.NotContains(t, getWorkload().GetPodTemplate().Annotations, install.DomainPrefix+"restartedAt")
+	require.NoError(t, addEphemeralAgents(ctx, getWorkload(), &acn, true))
+	assert.Contains(t, getWorkload().GetPodTemplate().Annotations, install.DomainPrefix+"restartedAt")
+	assert.Len(t, getPod("echo-plain").Spec.EphemeralContainers, 1)
+}
+
+func TestAddEphemeralAgentsFailure(t *testing.T) {
+	labels := map[string]string{"app": "echo"}
+	dep := &apps.Deployment{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "echo",
+			Namespace: "some-ns",
+		},
+		Spec: apps.DeploymentSpec{
+			Selector: &meta.LabelSelector{MatchLabels: labels},
+		},
+	}
+	pod := &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "echo-1",
+			Namespace: "some-ns",
+			Labels:    labels,
+		},
+		Spec: core.PodSpec{
+			Containers: []core.Container{{
+				Name:  "echo",
+				Image: "echo:latest",
+				Ports: []core.ContainerPort{{ContainerPort: 8080}},
+			}},
+		},
+		Status: core.PodStatus{Phase: core.PodRunning},
+	}
+	ac := &agentconfig.Sidecar{
+		AgentImage: "docker.io/datawire/tel2:2.7.0",
+		AgentName:  "echo",
+		Namespace:  "some-ns",
+		Ephemeral:  true,
+		Containers: []*agentconfig.Container{{
+			Name: "echo",
+			Intercepts: []*agentconfig.Intercept{{
+				ServiceName:         "echo",
+				ServicePort:         80,
+				TargetPortRewritten: true,
+				ContainerPort:       8080,
+				AgentPort:           9900,
+			}},
+		}},
+	}
+
+	cs := fake.NewSimpleClientset(dep, pod)
+	cs.PrependReactor("update", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "ephemeralcontainers" {
+			return true, nil, errors.New("ephemeral containers are disabled")
+		}
+		return false, nil, nil
+	})
+	ctx := dlog.NewTestContext(t, false)
+	ctx = k8sapi.WithK8sInterface(ctx, cs)
+	wl, err := k8sapi.GetWorkload(ctx, "echo", "some-ns", "Deployment")
+	require.NoError(t, err)
+	assert.ErrorContains(t, addEphemeralAgents(ctx, wl, ac, true), "unable to add ephemeral traffic-agent to 1 of the pods of Deployment echo.some-ns")
+}
+
+func TestAgentConfigFromEnv(t *testing.T) {
+	ac := &agentconfig.Sidecar{AgentName: "echo", Ephemeral: true}
+	js, err := json.Marshal(ac)
+	require.NoError(t, err)
+	assert.Equal(t, ac, agentConfigFromEnv([]core.EnvVar{{Name: "A", Value: "a"}, {Name: agentconfig.EnvAgentConfig, Value: string(js)}}))
+	assert.Nil(t, agentConfigFromEnv([]core.EnvVar{{Name: agentconfig.EnvAgentConfig, Value: "{"}}))
+	assert.Nil(t, agentConfigFromEnv(nil))
+}
@@ -0,0 +1,101 @@
+package mutator
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
+)
+
+// HasInterceptsFunc returns true if the workload of the traffic-agent with the given name and namespace has
+// intercepts.
+type HasInterceptsFunc func(name, namespace string) bool
+
+// idleAgents removes the traffic-agents of workloads that have had no intercepts for a given duration. The
+// config entry of such an agent is deleted, which in turn causes a rollout of its workload, so the removal
+// only takes place within the configured removal window.
+type idleAgents struct {
+	configs       Map
+	hasIntercepts HasInterceptsFunc
+	timeout       time.Duration
+	window        managerutil.TimeWindow
+
+	// idleSince is keyed by "<agent name>.<namespace>". It's only used by the goroutine that calls collect.
+	idleSince map[string]time.Time
+}
+
+func newIdleAgents(configs Map, hasIntercepts HasInterceptsFunc, timeout time.Duration, window managerutil.TimeWindow) *idleAgents {
+	return &idleAgents{
+		configs:       configs,
+		hasIntercepts: hasIntercepts,
+		timeout:       timeout,
+		window:        window,
+		idleSince:     make(map[string]time.Time),
+	}
+}
+
+func (ia *idleAgents) run(ctx context.Context) error {
+	dlog.Infof(ctx, "Removing traffic-agents that have been idle for %s, within window %q", ia.timeout, ia.window)
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			ia.collect(ctx, now)
+		}
+	}
+}
+
+func (ia *idleAgents) collect(ctx context.Context, now time.Time) {
+	seen := make(map[string]struct{})
+	for _, ac := range ia.configs.AgentConfigs(ctx) {
+		if ac.Create || ac.Manual || ac.WorkloadKind == "Job" {
+			// Not yet generated, not ours to remove, or removed together with the Job
+			continue
+		}
+		key := ac.AgentName + "." + ac.Namespace
+		seen[key] = struct{}{}
+		if ia.hasIntercepts(ac.AgentName, ac.Namespace) {
+			delete(ia.idleSince, key)
+			continue
+		}
+		since, ok := ia.idleSince[key]
+		if !ok {
+			// The time that the agent has been idle before it was first seen here is unknown
+			ia.idleSince[key] = now
+			continue
+		}
+		if now.Sub(since) < ia.timeout || !ia.window.Contains(now) {
+			continue
+		}
+		wl, err := k8sapi.GetWorkload(ctx, ac.WorkloadName, ac.Namespace, ac.WorkloadKind)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				dlog.Error(ctx, err)
+			}
+			continue
+		}
+		if wl.GetPodTemplate().Annotations[agentconfig.InjectAnnotation] == "enabled" {
+			// Explicitly injected, so it's not up to us to remove it
+			continue
+		}
+		dlog.Infof(ctx, "Removing the %s of %s %s.%s, idle since %s", agentconfig.ContainerName, ac.WorkloadKind, ac.WorkloadName, ac.Namespace, since.Format(time.RFC3339))
+		if err = ia.configs.Delete(ctx, ac.AgentName, ac.Namespace); err != nil {
+			dlog.Error(ctx, err)
+			continue
+		}
+		delete(ia.idleSince, key)
+	}
+	for key := range ia.idleSince {
+		if _, ok := seen[key]; !ok {
+			delete(ia.idleSince, key)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package mutator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
+)
+
+func TestIdleAgents(t *testing.T) {
+	deployment := func(name string, annotations map[string]string) *apps.Deployment {
+		return &apps.Deployment{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      name,
+				Namespace: "some-ns",
+			},
+			Spec: apps.DeploymentSpec{
+				Template: core.PodTemplateSpec{
+					ObjectMeta: meta.ObjectMeta{Annotations: annotations},
+				},
+			},
+		}
+	}
+	data := make(map[string]string)
+	for _, ac := range []*agentconfig.Sidecar{
+		{AgentName: "idle", Namespace: "some-ns", WorkloadName: "idle", WorkloadKind: "Deployment"},
+		{AgentName: "busy", Namespace: "some-ns", WorkloadName: "busy", WorkloadKind: "Deployment"},
+		{AgentName: "enabled", Namespace: "some-ns", WorkloadName: "enabled", WorkloadKind: "Deployment"},
+		{AgentName: "manual", Namespace: "some-ns", WorkloadName: "manual", WorkloadKind: "Deployment", Manual: true},
+	} {
+		y, err := yaml.Marshal(ac)
+		require.NoError(t, err)
+		data[ac.AgentName] = string(y)
+	}
+	cm := &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      agentconfig.ConfigMap,
+			Namespace: "some-ns",
+		},
+		Data: data,
+	}
+	cs := fake.NewSimpleClientset(cm,
+		deployment("idle", nil),
+		deployment("busy", nil),
+		deployment("enabled", map[string]string{agentconfig.InjectAnnotation: "enabled"}),
+		deployment("manual", nil),
+	)
+	ctx := dlog.NewTestContext(t, false)
+	ctx = k8sapi.WithK8sInterface(ctx, cs)
+
+	cw := NewWatcher(agentconfig.ConfigMap, "some-ns")
+	cw.data["some-ns"] = data
+
+	window, err := managerutil.NewTimeWindow("01:00-05:00")
+	require.NoError(t, err)
+	ia := newIdleAgents(cw, func(name, namespace string) bool {
+		return name == "busy" && namespace == "some-ns"
+	}, time.Hour, window)
+
+	configNames := func() []string {
+		t.Helper()
+		cm, err := cs.CoreV1().ConfigMaps("some-ns").Get(ctx, agentconfig.ConfigMap, meta.GetOptions{})
+		require.NoError(t, err)
+		var names []string
+		for n := range cm.Data {
+			names = append(names, n)
+		}
+		return names
+	}
+
+	start := time.Date(2022, 8, 1, 23, 0, 0, 0, time.UTC)
+	ia.collect(ctx, start)
+	assert.Len(t, configNames(), 4)
+
+	// Idle long enough, but outside the removal window
+	ia.collect(ctx, start.Add(90*time.Minute))
+	assert.Len(t, configNames(), 4)
+
+	// Within the removal window
+	ia.collect(ctx, start.Add(3*time.Hour))
+	assert.ElementsMatch(t, []string{"busy", "enabled", "manual"}, configNames())
+}
@@ -0,0 +1,98 @@
+package mutator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
+)
+
+const injectionFailedReason = "AgentInjectionFailed"
+
+// recordInjectionFailure makes the reason why the traffic-agent couldn't be injected visible to users that
+// have no access to the traffic-manager log. A warning event is emitted on the workload, and the workload is
+// annotated with the error so that clients can report it. No event is emitted on the pod, because the pod
+// doesn't exist yet when it's rejected by the agent injector. The event is only emitted when the error differs
+// from the one already recorded.
+func recordInjectionFailure(ctx context.Context, wl k8sapi.Workload, err error) {
+	msg := err.Error()
+	if wl.GetAnnotations()[agentmap.InjectionErrorAnnotation] == msg {
+		return
+	}
+	recordEvent(ctx, &core.ObjectReference{
+		APIVersion: workloadAPIVersion(wl),
+		Kind:       wl.GetKind(),
+		Name:       wl.GetName(),
+		Namespace:  wl.GetNamespace(),
+		UID:        wl.GetUID(),
+	}, core.EventTypeWarning, injectionFailedReason, msg)
+	setInjectionError(ctx, wl, &msg)
+}
+
+// recordInjectionSuccess removes the error annotation that an earlier failure left on the workload.
+func recordInjectionSuccess(ctx context.Context, wl k8sapi.Workload) {
+	if _, ok := wl.GetAnnotations()[agentmap.InjectionErrorAnnotation]; ok {
+		setInjectionError(ctx, wl, nil)
+	}
+}
+
+func setInjectionError(ctx context.Context, wl k8sapi.Workload, msg *string) {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]*string{agentmap.InjectionErrorAnnotation: msg},
+		},
+	})
+	if err == nil {
+		err = wl.Patch(ctx, types.MergePatchType, patch)
+	}
+	if err != nil {
+		dlog.Errorf(ctx, "unable to annotate %s %s.%s with the agent injection status: %v", wl.GetKind(), wl.GetName(), wl.GetNamespace(), err)
+	}
+}
+
+func recordEvent(ctx context.Context, ref *core.ObjectReference, eventType, reason, msg string) {
+	now := meta.Now()
+	ev := &core.Event{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", ref.Name, now.UnixNano()),
+			Namespace: ref.Namespace,
+		},
+		InvolvedObject:      *ref,
+		Reason:              reason,
+		Message:             msg,
+		Source:              core.EventSource{Component: agentmap.ManagerAppName},
+		FirstTimestamp:      now,
+		LastTimestamp:       now,
+		Count:               1,
+		Type:                eventType,
+		ReportingController: agentmap.ManagerAppName,
+		ReportingInstance:   agentmap.AgentInjectorName,
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := k8sapi.GetK8sInterface(ctx).CoreV1().Events(ref.Namespace).Create(ctx, ev, meta.CreateOptions{}); err != nil {
+		dlog.Errorf(ctx, "unable to create %s event for %s %s.%s: %v", reason, ref.Kind, ref.Name, ref.Namespace, err)
+	}
+}
+
+func workloadAPIVersion(wl k8sapi.Workload) string {
+	if gvk := wl.GetObjectKind().GroupVersionKind(); !gvk.Empty() {
+		return gvk.GroupVersion().String()
+	}
+	switch wl.GetKind() {
+	case "Job":
+		return "batch/v1"
+	case "Rollout":
+		return k8sapi.RolloutResource.GroupVersion().String()
+	default:
+		return "apps/v1"
+	}
+}
@@ -0,0 +1,65 @@
+package mutator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
+)
+
+func TestRecordInjectionStatus(t *testing.T) {
+	dep := &apps.Deployment{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "echo",
+			Namespace: "some-ns",
+			UID:       "echo-uid",
+		},
+	}
+	cs := fake.NewSimpleClientset(dep)
+	ctx := dlog.NewTestContext(t, false)
+	ctx = k8sapi.WithK8sInterface(ctx, cs)
+
+	getDeployment := func() k8sapi.Workload {
+		t.Helper()
+		wl, err := k8sapi.GetWorkload(ctx, "echo", "some-ns", "Deployment")
+		require.NoError(t, err)
+		return wl
+	}
+	events := func() []core.Event {
+		t.Helper()
+		evs, err := cs.CoreV1().Events("some-ns").List(ctx, meta.ListOptions{})
+		require.NoError(t, err)
+		return evs.Items
+	}
+
+	injectErr := errors.New("found no service with a port that matches a container in pod echo-.some-ns")
+	recordInjectionFailure(ctx, getDeployment(), injectErr)
+
+	wl := getDeployment()
+	assert.Equal(t, injectErr.Error(), wl.GetAnnotations()[agentmap.InjectionErrorAnnotation])
+	evs := events()
+	require.Len(t, evs, 1)
+	ev := evs[0]
+	assert.Equal(t, core.EventTypeWarning, ev.Type)
+	assert.Equal(t, injectionFailedReason, ev.Reason)
+	assert.Equal(t, injectErr.Error(), ev.Message)
+	assert.Equal(t, "Deployment", ev.InvolvedObject.Kind)
+	assert.Equal(t, dep.UID, ev.InvolvedObject.UID)
+
+	// The same error is not reported on the workload again
+	recordInjectionFailure(ctx, wl, injectErr)
+	assert.Len(t, events(), 1)
+
+	recordInjectionSuccess(ctx, getDeployment())
+	_, ok := getDeployment().GetAnnotations()[agentmap.InjectionErrorAnnotation]
+	assert.False(t, ok)
+}
@@ -53,7 +53,8 @@ func (p patchOps) String() string {
 
 type mutatorFunc func(context.Context, *admission.AdmissionRequest) (patchOps, error)
 
-func ServeMutator(ctx context.Context) error {
+// ServeMutator serves the agent injector webhook. The given function is used when removing idle traffic-agents.
+func ServeMutator(ctx context.Context, hasIntercepts HasInterceptsFunc) error {
 	certPath := filepath.Join(tlsDir, tlsCertFile)
 	keyPath := filepath.Join(tlsDir, tlsKeyFile)
 	missing := ""
@@ -129,6 +130,10 @@ func ServeMutator(ctx context.Context) error {
 		dtime.SleepWithContext(ctx, time.Second) // Give the server some time to start
 		return cw.Run(ctx)
 	})
+	if env.AgentIdleTimeout > 0 {
+		ia := newIdleAgents(cw, hasIntercepts, env.AgentIdleTimeout, env.AgentRemovalWindow)
+		dgroup.ParentGroup(ctx).Go("idle-agents", ia.run)
+	}
 
 	wrapped := otelhttp.NewHandler(mux, "agent-injector", otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
 		return operation + r.URL.Path
@@ -0,0 +1,188 @@
+package mutator
+
+import (
+	"context"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
+)
+
+// updateServiceTargetPorts replaces the target ports of the service ports that the given config redirects
+// using the ServiceTargetPort policy, or using an ephemeral traffic-agent, with the port of the traffic-agent,
+// and restores the target ports that were rewritten earlier but no longer are. All target ports that were
+// rewritten in the services referenced by the config are restored when restore is true.
+//
+// A target port is only replaced when every running pod that the service selects has a running traffic-agent,
+// because a pod without one doesn't serve the port of the traffic-agent. Target ports that were replaced are
+// restored when that is no longer the case. Target ports of service ports that are redirected by one of the
+// others configs are never restored.
+func updateServiceTargetPorts(ctx context.Context, ac *agentconfig.Sidecar, restore bool, others []*agentconfig.Sidecar) {
+	svcs := make(map[string][]*agentconfig.Intercept)
+	for _, cc := range ac.Containers {
+		for _, ic := range cc.Intercepts {
+			ics := svcs[ic.ServiceName]
+			if ic.TargetPortRewritten && !restore {
+				ics = append(ics, ic)
+			}
+			svcs[ic.ServiceName] = ics
+		}
+	}
+	for name, ics := range svcs {
+		if err := updateTargetPorts(ctx, ac, name, ics, claimedServicePorts(ac, name, others)); err != nil {
+			dlog.Errorf(ctx, "unable to update the target ports of service %s.%s: %v", name, ac.Namespace, err)
+		}
+	}
+}
+
+// hasRewrittenTargetPorts returns true if the given config redirects a service port by rewriting its target port.
+func hasRewrittenTargetPorts(ac *agentconfig.Sidecar) bool {
+	for _, cc := range ac.Containers {
+		for _, ic := range cc.Intercepts {
+			if ic.TargetPortRewritten {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimedServicePorts returns the intercepts of the others configs that redirect a port of the service with
+// the given name in the namespace of the given config by rewriting its target port.
+func claimedServicePorts(ac *agentconfig.Sidecar, name string, others []*agentconfig.Sidecar) []*agentconfig.Intercept {
+	var claimed []*agentconfig.Intercept
+	for _, oc := range others {
+		if oc.Namespace != ac.Namespace || oc.AgentName == ac.AgentName {
+			continue
+		}
+		for _, cc := range oc.Containers {
+			for _, ic := range cc.Intercepts {
+				if ic.TargetPortRewritten && ic.ServiceName == name {
+					claimed = append(claimed, ic)
+				}
+			}
+		}
+	}
+	return claimed
+}
+
+// agentsReady returns true if every running pod that the given service selects has a running traffic-agent.
+func agentsReady(ctx context.Context, svc *core.Service) (bool, error) {
+	if len(svc.Spec.Selector) == 0 {
+		return true, nil
+	}
+	pods, err := k8sapi.GetK8sInterface(ctx).CoreV1().Pods(svc.Namespace).List(ctx, meta.ListOptions{
+		LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+	})
+	if err != nil {
+		return false, err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != core.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if !agentRunning(pod) {
+			dlog.Debugf(ctx, "Pod %s.%s selected by service %s has no running %s", pod.Name, pod.Namespace, svc.Name, agentconfig.ContainerName)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// agentRunning returns true if the given pod has a running regular or ephemeral traffic-agent.
+func agentRunning(pod *core.Pod) bool {
+	for _, css := range [][]core.ContainerStatus{pod.Status.ContainerStatuses, pod.Status.EphemeralContainerStatuses} {
+		for i := range css {
+			if cs := &css[i]; cs.Name == agentconfig.ContainerName {
+				return cs.State.Running != nil
+			}
+		}
+	}
+	return false
+}
+
+func updateTargetPorts(ctx context.Context, ac *agentconfig.Sidecar, name string, ics, claimed []*agentconfig.Intercept) error {
+	namespace := ac.Namespace
+	api := k8sapi.GetK8sInterface(ctx).CoreV1().Services(namespace)
+	svc, err := api.Get(ctx, name, meta.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return err
+	}
+	rewritten, err := agentmap.RewrittenTargetPorts(svc)
+	if err != nil {
+		return err
+	}
+	if rewritten == nil {
+		rewritten = make(map[string]intstr.IntOrString)
+	}
+	if len(ics) > 0 {
+		ready, err := agentsReady(ctx, svc)
+		if err != nil {
+			return err
+		}
+		if !ready {
+			// Restore, or leave as is, until all pods can serve the port of the traffic-agent.
+			ics = nil
+		}
+	}
+
+	modified := false
+	ports := svc.Spec.Ports
+nextPort:
+	for i := range ports {
+		p := &ports[i]
+		for _, ic := range ics {
+			if p.Name == ic.ServicePortName && p.Port == int32(ic.ServicePort) {
+				if tp := agentmap.RewrittenTargetPort(ac, ic); p.TargetPort != tp {
+					if orig, ok := rewritten[p.TargetPort.String()]; ok {
+						// Rewritten earlier using another policy
+						p.TargetPort = orig
+					}
+					rewritten[tp.String()] = p.TargetPort
+					p.TargetPort = tp
+					modified = true
+				}
+				continue nextPort
+			}
+		}
+		for _, ic := range claimed {
+			if p.Name == ic.ServicePortName && p.Port == int32(ic.ServicePort) {
+				// Another workload's traffic-agent still uses the rewritten target port
+				continue nextPort
+			}
+		}
+		if orig, ok := rewritten[p.TargetPort.String()]; ok {
+			p.TargetPort = orig
+			modified = true
+		}
+	}
+	if !modified {
+		return nil
+	}
+
+	// Only keep the target ports that are still in use
+	inUse := make(map[string]struct{}, len(ports))
+	for i := range ports {
+		inUse[ports[i].TargetPort.String()] = struct{}{}
+	}
+	for n := range rewritten {
+		if _, ok := inUse[n]; !ok {
+			delete(rewritten, n)
+		}
+	}
+	agentmap.SetRewrittenTargetPorts(svc, rewritten)
+	dlog.Infof(ctx, "Updating target ports of service %s.%s", name, namespace)
+	_, err = api.Update(ctx, svc, meta.UpdateOptions{})
+	return err
+}
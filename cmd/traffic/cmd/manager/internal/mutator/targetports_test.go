@@ -0,0 +1,301 @@
+package mutator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
+)
+
+func TestUpdateServiceTargetPorts(t *testing.T) {
+	svc := &core.Service{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "echo",
+			Namespace: "some-ns",
+		},
+		Spec: core.ServiceSpec{
+			Ports: []core.ServicePort{
+				{
+					Name:       "http",
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+				},
+				{
+					Name: "grpc",
+					Port: 8001,
+				},
+				{
+					Name:       "metrics",
+					Port:       9090,
+					TargetPort: intstr.FromInt(9090),
+				},
+			},
+		},
+	}
+	ctx := dlog.NewTestContext(t, false)
+	ctx = k8sapi.WithK8sInterface(ctx, fake.NewSimpleClientset(svc))
+
+	ac := &agentconfig.Sidecar{
+		Namespace: "some-ns",
+		Containers: []*agentconfig.Container{{
+			Name: "echo",
+			Intercepts: []*agentconfig.Intercept{
+				{
+					ContainerPortName:   "tp-8080",
+					ServiceName:         "echo",
+					ServicePortName:     "http",
+					ServicePort:         80,
+					TargetPortRewritten: true,
+					ContainerPort:       8080,
+				},
+				{
+					ContainerPortName:   "tp-8001",
+					ServiceName:         "echo",
+					ServicePortName:     "grpc",
+					ServicePort:         8001,
+					TargetPortRewritten: true,
+					ContainerPort:       8001,
+				},
+			},
+		}},
+	}
+
+	getService := func() *core.Service {
+		s, err := k8sapi.GetK8sInterface(ctx).CoreV1().Services("some-ns").Get(ctx, "echo", meta.GetOptions{})
+		require.NoError(t, err)
+		return s
+	}
+
+	updateServiceTargetPorts(ctx, ac, false, nil)
+	s := getService()
+	assert.Equal(t, intstr.FromString("tp-8080"), s.Spec.Ports[0].TargetPort)
+	assert.Equal(t, intstr.FromString("tp-8001"), s.Spec.Ports[1].TargetPort)
+	assert.Equal(t, intstr.FromInt(9090), s.Spec.Ports[2].TargetPort)
+	rewritten, err := agentmap.RewrittenTargetPorts(s)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]intstr.IntOrString{"tp-8080": intstr.FromInt(8080), "tp-8001": {}}, rewritten)
+
+	// Updating again is a no-op
+	updateServiceTargetPorts(ctx, ac, false, nil)
+	assert.Equal(t, s, getService())
+
+	// A port that no longer is rewritten is restored
+	ac.Containers[0].Intercepts[1].TargetPortRewritten = false
+	updateServiceTargetPorts(ctx, ac, false, nil)
+	s = getService()
+	assert.Equal(t, intstr.FromString("tp-8080"), s.Spec.Ports[0].TargetPort)
+	assert.Equal(t, intstr.IntOrString{}, s.Spec.Ports[1].TargetPort)
+	rewritten, err = agentmap.RewrittenTargetPorts(s)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]intstr.IntOrString{"tp-8080": intstr.FromInt(8080)}, rewritten)
+
+	// All ports are restored and the annotation is removed
+	updateServiceTargetPorts(ctx, ac, true, nil)
+	s = getService()
+	assert.Equal(t, intstr.FromInt(8080), s.Spec.Ports[0].TargetPort)
+	assert.Equal(t, intstr.IntOrString{}, s.Spec.Ports[1].TargetPort)
+	assert.NotContains(t, s.Annotations, agentmap.RewrittenTargetPortsAnnotation)
+}
+
+func TestUpdateServiceTargetPortsEphemeral(t *testing.T) {
+	svc := &core.Service{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "echo",
+			Namespace: "some-ns",
+		},
+		Spec: core.ServiceSpec{
+			Ports: []core.ServicePort{
+				{
+					Name:       "http",
+					Port:       80,
+					TargetPort: intstr.FromString("http"),
+				},
+				{
+					Name:       "grpc",
+					Port:       8001,
+					TargetPort: intstr.FromInt(8001),
+				},
+			},
+		},
+	}
+	ctx := dlog.NewTestContext(t, false)
+	ctx = k8sapi.WithK8sInterface(ctx, fake.NewSimpleClientset(svc))
+
+	ac := &agentconfig.Sidecar{
+		Namespace: "some-ns",
+		Ephemeral: true,
+		Containers: []*agentconfig.Container{{
+			Name: "echo",
+			Intercepts: []*agentconfig.Intercept{
+				{
+					ServiceName:         "echo",
+					ServicePortName:     "http",
+					ServicePort:         80,
+					TargetPortRewritten: true,
+					ContainerPort:       8080,
+					AgentPort:           9900,
+				},
+				{
+					ServiceName:         "echo",
+					ServicePortName:     "grpc",
+					ServicePort:         8001,
+					TargetPortRewritten: true,
+					ContainerPort:       8001,
+					AgentPort:           9901,
+				},
+			},
+		}},
+	}
+
+	getService := func() *core.Service {
+		s, err := k8sapi.GetK8sInterface(ctx).CoreV1().Services("some-ns").Get(ctx, "echo", meta.GetOptions{})
+		require.NoError(t, err)
+		return s
+	}
+
+	// Both named and numeric target ports are replaced with the agent's port number
+	updateServiceTargetPorts(ctx, ac, false, nil)
+	s := getService()
+	assert.Equal(t, intstr.FromInt(9900), s.Spec.Ports[0].TargetPort)
+	assert.Equal(t, intstr.FromInt(9901), s.Spec.Ports[1].TargetPort)
+	rewritten, err := agentmap.RewrittenTargetPorts(s)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]intstr.IntOrString{"9900": intstr.FromString("http"), "9901": intstr.FromInt(8001)}, rewritten)
+
+	// Updating again is a no-op
+	updateServiceTargetPorts(ctx, ac, false, nil)
+	assert.Equal(t, s, getService())
+
+	// All ports are restored and the annotation is removed
+	updateServiceTargetPorts(ctx, ac, true, nil)
+	s = getService()
+	assert.Equal(t, intstr.FromString("http"), s.Spec.Ports[0].TargetPort)
+	assert.Equal(t, intstr.FromInt(8001), s.Spec.Ports[1].TargetPort)
+	assert.NotContains(t, s.Annotations, agentmap.RewrittenTargetPortsAnnotation)
+}
+
+func TestUpdateServiceTargetPortsAwaitsAgents(t *testing.T) {
+	svc := &core.Service{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "echo",
+			Namespace: "some-ns",
+		},
+		Spec: core.ServiceSpec{
+			Selector: map[string]string{"app": "echo"},
+			Ports: []core.ServicePort{{
+				Name:       "http",
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+	pod := func(name string, withAgent bool) *core.Pod {
+		pod := &core.Pod{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      name,
+				Namespace: "some-ns",
+				Labels:    map[string]string{"app": "echo"},
+			},
+			Status: core.PodStatus{
+				Phase: core.PodRunning,
+				ContainerStatuses: []core.ContainerStatus{{
+					Name:  "echo",
+					State: core.ContainerState{Running: &core.ContainerStateRunning{}},
+				}},
+			},
+		}
+		if withAgent {
+			pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, core.ContainerStatus{
+				Name:  agentconfig.ContainerName,
+				State: core.ContainerState{Running: &core.ContainerStateRunning{}},
+			})
+		}
+		return pod
+	}
+	ctx := dlog.NewTestContext(t, false)
+	ki := fake.NewSimpleClientset(svc, pod("echo-1", true), pod("echo-2", false))
+	ctx = k8sapi.WithK8sInterface(ctx, ki)
+
+	newConfig := func(agentName string) *agentconfig.Sidecar {
+		return &agentconfig.Sidecar{
+			AgentName: agentName,
+			Namespace: "some-ns",
+			Containers: []*agentconfig.Container{{
+				Name: "echo",
+				Intercepts: []*agentconfig.Intercept{{
+					ContainerPortName:   "tp-8080",
+					ServiceName:         "echo",
+					ServicePortName:     "http",
+					ServicePort:         80,
+					TargetPortRewritten: true,
+					ContainerPort:       8080,
+				}},
+			}},
+		}
+	}
+	ac := newConfig("echo")
+
+	getTargetPort := func() intstr.IntOrString {
+		s, err := ki.CoreV1().Services("some-ns").Get(ctx, "echo", meta.GetOptions{})
+		require.NoError(t, err)
+		return s.Spec.Ports[0].TargetPort
+	}
+
+	// A pod without a traffic-agent prevents the rewrite
+	updateServiceTargetPorts(ctx, ac, false, nil)
+	assert.Equal(t, intstr.FromInt(8080), getTargetPort())
+
+	// Pods that aren't running are ignored
+	p2, err := ki.CoreV1().Pods("some-ns").Get(ctx, "echo-2", meta.GetOptions{})
+	require.NoError(t, err)
+	p2.Status.Phase = core.PodPending
+	_, err = ki.CoreV1().Pods("some-ns").Update(ctx, p2, meta.UpdateOptions{})
+	require.NoError(t, err)
+	updateServiceTargetPorts(ctx, ac, false, nil)
+	assert.Equal(t, intstr.FromString("tp-8080"), getTargetPort())
+
+	// The target port is restored when a running pod has no traffic-agent
+	p2.Status.Phase = core.PodRunning
+	_, err = ki.CoreV1().Pods("some-ns").Update(ctx, p2, meta.UpdateOptions{})
+	require.NoError(t, err)
+	updateServiceTargetPorts(ctx, ac, false, nil)
+	assert.Equal(t, intstr.FromInt(8080), getTargetPort())
+
+	// The target port isn't restored while another workload's traffic-agent uses it
+	require.NoError(t, ki.CoreV1().Pods("some-ns").Delete(ctx, "echo-2", meta.DeleteOptions{}))
+	updateServiceTargetPorts(ctx, ac, false, nil)
+	assert.Equal(t, intstr.FromString("tp-8080"), getTargetPort())
+	other := newConfig("echo-canary")
+	updateServiceTargetPorts(ctx, ac, true, []*agentconfig.Sidecar{ac, other})
+	assert.Equal(t, intstr.FromString("tp-8080"), getTargetPort())
+	updateServiceTargetPorts(ctx, other, true, []*agentconfig.Sidecar{other})
+	assert.Equal(t, intstr.FromInt(8080), getTargetPort())
+}
+
+func TestAgentRunning(t *testing.T) {
+	running := core.ContainerState{Running: &core.ContainerStateRunning{}}
+	waiting := core.ContainerState{Waiting: &core.ContainerStateWaiting{}}
+	pod := &core.Pod{Status: core.PodStatus{
+		ContainerStatuses: []core.ContainerStatus{{Name: "echo", State: running}},
+	}}
+	assert.False(t, agentRunning(pod))
+
+	pod.Status.EphemeralContainerStatuses = []core.ContainerStatus{{Name: agentconfig.ContainerName, State: waiting}}
+	assert.False(t, agentRunning(pod))
+
+	pod.Status.EphemeralContainerStatuses[0].State = running
+	assert.True(t, agentRunning(pod))
+
+	pod.Status.EphemeralContainerStatuses = nil
+	pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, core.ContainerStatus{Name: agentconfig.ContainerName, State: running})
+	assert.True(t, agentRunning(pod))
+}
@@ -26,6 +26,7 @@ import (
 
 type Map interface {
 	GetInto(string, string, any) (bool, error)
+	AgentConfigs(context.Context) []*agentconfig.Sidecar
 	Run(context.Context) error
 	Delete(context.Context, string, string) error
 	Store(context.Context, *agentconfig.Sidecar, bool) error
@@ -58,12 +59,18 @@ func (e *entry) workload(ctx context.Context) (*agentconfig.Sidecar, k8sapi.Work
 	}
 	wl, err := k8sapi.GetWorkload(ctx, ac.WorkloadName, ac.Namespace, ac.WorkloadKind)
 	if err != nil {
-		return nil, nil, err
+		// The config is returned so that the caller can act on it even if the workload is gone.
+		return ac, nil, err
 	}
 	return ac, wl, nil
 }
 
 func triggerRollout(ctx context.Context, wl k8sapi.Workload) {
+	if _, ok := k8sapi.JobImpl(wl); ok {
+		// The pod template of a Job is immutable and its pods are created when the Job is.
+		dlog.Debugf(ctx, "Job %s.%s cannot be rolled out", wl.GetName(), wl.GetNamespace())
+		return
+	}
 	if rs, ok := k8sapi.ReplicaSetImpl(wl); ok {
 		// Rollout of a replicatset will not recreate the pods. In order for that to happen, the
 		// set must be scaled down and then up again.
@@ -87,6 +94,18 @@ func triggerRollout(ctx context.Context, wl k8sapi.Workload) {
 		}
 		return
 	}
+	if _, ok := k8sapi.RolloutImpl(wl); ok {
+		// An Argo Rollout restarts its pods when its restartAt is set. Changing the pod template
+		// would instead create a new revision and take it through all steps of the rollout strategy.
+		dlog.Debugf(ctx, "Performing Rollout restart of %s.%s", wl.GetName(), wl.GetNamespace())
+		restartAt := fmt.Sprintf(`{"spec": {"restartAt": "%s"}}`, time.Now().UTC().Format(time.RFC3339))
+		if err := wl.Patch(ctx, types.MergePatchType, []byte(restartAt)); err != nil {
+			dlog.Errorf(ctx, "unable to restart Rollout %s.%s: %v", wl.GetName(), wl.GetNamespace(), err)
+			return
+		}
+		dlog.Infof(ctx, "Successfully rolled out %s.%s", wl.GetName(), wl.GetNamespace())
+		return
+	}
 	restartAnnotation := fmt.Sprintf(
 		`{"spec": {"template": {"metadata": {"annotations": {"%srestartedAt": "%s"}}}}}`,
 		install.DomainPrefix,
@@ -129,14 +148,20 @@ func (c *configWatcher) Run(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	var agentImage string
+	targetPortsTicker := time.NewTicker(targetPortsInterval)
+	defer targetPortsTicker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-targetPortsTicker.C:
+			c.updateTargetPorts(ctx)
 		case e := <-delCh:
 			dlog.Debugf(ctx, "del %s.%s", e.name, e.namespace)
 			ac, wl, err := e.workload(ctx)
+			if ac != nil && !(ac.Create || ac.Manual) {
+				updateServiceTargetPorts(ctx, ac, true, c.AgentConfigs(ctx))
+			}
 			if err != nil {
 				if !errors.IsNotFound(err) {
 					dlog.Error(ctx, err)
@@ -162,18 +187,57 @@ func (c *configWatcher) Run(ctx context.Context) error {
 				continue
 			}
 			if ac.Create {
-				if agentImage == "" {
-					agentImage = managerutil.GetAgentImage(ctx)
-				}
-				if ac, err = agentmap.Generate(ctx, wl, managerutil.GetEnv(ctx).GeneratorConfig(agentImage)); err != nil {
+				if ac, err = agentmap.Generate(ctx, wl, managerutil.GetEnv(ctx).GeneratorConfig(getAgentImage(ctx))); err != nil {
 					dlog.Error(ctx, err)
+					recordInjectionFailure(ctx, wl, err)
 				} else if err = c.Store(ctx, ac, false); err != nil {
 					dlog.Error(ctx, err)
 				}
 				continue // Calling Store() will generate a new event, so we skip rollout here
 			}
-			triggerRollout(ctx, wl)
+			if ac.Ephemeral {
+				// The target ports are rewritten by updateTargetPorts once all the ephemeral traffic-agents run.
+				if err = addEphemeralAgents(ctx, wl, ac, true); err != nil {
+					dlog.Error(ctx, err)
+					updateServiceTargetPorts(ctx, ac, true, c.AgentConfigs(ctx))
+					continue
+				}
+			} else {
+				triggerRollout(ctx, wl)
+			}
+			updateServiceTargetPorts(ctx, ac, false, c.AgentConfigs(ctx))
+		}
+	}
+}
+
+// targetPortsInterval is the interval between the checks of the target ports of services that are redirected by
+// rewriting their target ports.
+const targetPortsInterval = 5 * time.Second
+
+// updateTargetPorts updates the target ports of the services that are redirected by the current agent configs. A
+// target port is rewritten when all pods of its service have a running traffic-agent, and restored when that is
+// no longer the case, so this is checked periodically. Pods that were started or failed to get an ephemeral
+// traffic-agent since the last check get one now, and the target ports are restored if that fails.
+func (c *configWatcher) updateTargetPorts(ctx context.Context) {
+	acs := c.AgentConfigs(ctx)
+	for _, ac := range acs {
+		if ac.Create || ac.Manual || !hasRewrittenTargetPorts(ac) {
+			continue
+		}
+		if ac.Ephemeral {
+			wl, err := k8sapi.GetWorkload(ctx, ac.WorkloadName, ac.Namespace, ac.WorkloadKind)
+			if err == nil {
+				err = addEphemeralAgents(ctx, wl, ac, false)
+			}
+			if err != nil {
+				if !errors.IsNotFound(err) {
+					dlog.Error(ctx, err)
+				}
+				updateServiceTargetPorts(ctx, ac, true, acs)
+				continue
+			}
 		}
+		updateServiceTargetPorts(ctx, ac, false, acs)
 	}
 }
 
@@ -194,6 +258,24 @@ func (c *configWatcher) GetInto(key, ns string, into any) (bool, error) {
 	return true, nil
 }
 
+// AgentConfigs returns the agent configs of the current snapshot. Entries that cannot be decoded are skipped.
+func (c *configWatcher) AgentConfigs(ctx context.Context) []*agentconfig.Sidecar {
+	c.RLock()
+	defer c.RUnlock()
+	var acs []*agentconfig.Sidecar
+	for _, vs := range c.data {
+		for k, v := range vs {
+			ac := &agentconfig.Sidecar{}
+			if err := decode(v, ac); err != nil {
+				dlog.Errorf(ctx, "failed to decode ConfigMap entry %q into an agent config: %v", k, err)
+				continue
+			}
+			acs = append(acs, ac)
+		}
+	}
+	return acs
+}
+
 // Delete will delete an agent config from the agents ConfigMap for the given namespace. It will
 // also update the current snapshot.
 // An attempt to delete a manually added config is a no-op
@@ -463,6 +545,12 @@ func (c *configWatcher) affectedConfigs(ctx context.Context, svc *core.Service,
 		if stss, err := k8sapi.StatefulSets(ctx, ns, selector); err == nil {
 			wls = append(wls, stss...)
 		}
+		if dss, err := k8sapi.DaemonSets(ctx, ns, selector); err == nil {
+			wls = append(wls, dss...)
+		}
+		if ros, err := k8sapi.Rollouts(ctx, ns, selector); err == nil {
+			wls = append(wls, ros...)
+		}
 	}
 	return c.configsAffectedByWorkloads(ctx, nsData, wls)
 }
@@ -488,6 +576,7 @@ func (c *configWatcher) updateSvc(ctx context.Context, svc *core.Service, isDele
 		acn, err := agentmap.Generate(ctx, wl, cfg)
 		if err != nil {
 			dlog.Error(ctx, err)
+			recordInjectionFailure(ctx, wl, err)
 			continue
 		}
 		if err = c.Store(ctx, acn, false); err != nil {
@@ -570,6 +659,10 @@ func (c *configWatcher) DeleteMapsAndRolloutAll(ctx context.Context) {
 		for k, v := range wlm {
 			e := &entry{name: k, namespace: ns, value: v}
 			ac, wl, err := e.workload(ctx)
+			if ac != nil && !(ac.Create || ac.Manual) {
+				// All configs are deleted, so no target port is used by another traffic-agent
+				updateServiceTargetPorts(ctx, ac, true, nil)
+			}
 			if err != nil {
 				if !errors.IsNotFound(err) {
 					dlog.Errorf(ctx, "unable to get workload for %s.%s %s: %v", k, ns, v, err)
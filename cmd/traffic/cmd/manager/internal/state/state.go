@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"sync"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 
 	"github.com/datawire/dlib/dlog"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/internal/lifecycle"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/internal/watchable"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
@@ -196,16 +198,19 @@ type State struct {
 	//  7. `cfgMapLocks` access must be concurrency protected
 	//  8. `cachedAgentImage` access must be concurrency protected
 	//  9. `interceptState` must be concurrency protected and updated/deleted in sync with intercepts
+	//  10. `interceptQueues` must be updated in sync with `intercepts`
 	intercepts       watchable.Map[*rpc.InterceptInfo]
 	agents           watchable.Map[*rpc.AgentInfo]        // info for agent sessions
 	clients          watchable.Map[*rpc.ClientInfo]       // info for client sessions
 	sessions         map[string]SessionState              // info for all sessions
 	agentsByName     map[string]map[string]*rpc.AgentInfo // indexed copy of `agents`
 	interceptStates  map[string]*interceptState
+	interceptQueues  map[string][]string // IDs of QUEUED intercepts, keyed by "<agent>.<namespace>"
 	timedLogLevel    log.TimedLevel
 	llSubs           *loglevelSubscribers
 	cfgMapLocks      map[string]*sync.Mutex
 	cachedAgentImage string
+	notifier         *lifecycle.Notifier
 }
 
 func NewState(ctx context.Context) *State {
@@ -216,8 +221,10 @@ func NewState(ctx context.Context) *State {
 		agentsByName:    make(map[string]map[string]*rpc.AgentInfo),
 		cfgMapLocks:     make(map[string]*sync.Mutex),
 		interceptStates: make(map[string]*interceptState),
+		interceptQueues: make(map[string][]string),
 		timedLogLevel:   log.NewTimedLevel(loglevel, log.SetLevel),
 		llSubs:          newLoglevelSubscribers(),
+		notifier:        lifecycle.GetNotifier(ctx),
 	}
 }
 
@@ -253,6 +260,9 @@ func (s *State) unlockedCheckAgentsForIntercept(intercept *rpc.InterceptInfo) (e
 	case rpc.InterceptDispositionType_BAD_ARGS:
 		// Don't overwrite this error state.
 		return intercept.Disposition, intercept.Message
+	case rpc.InterceptDispositionType_QUEUED:
+		// No agent is involved until the intercept leaves the queue.
+		return rpc.InterceptDispositionType_UNSPECIFIED, ""
 	}
 
 	// main ////////////////////////////////////////////////////////////////
@@ -272,6 +282,21 @@ func (s *State) unlockedCheckAgentsForIntercept(intercept *rpc.InterceptInfo) (e
 		return
 	}
 
+	if pod := intercept.Spec.PodName; pod != "" {
+		found := false
+		for _, agent := range agentList {
+			if agent.PodName == pod {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errCode = rpc.InterceptDispositionType_NO_AGENT
+			errMsg = fmt.Sprintf("No agent found for %q in pod %s", intercept.Spec.Agent, pod)
+			return
+		}
+	}
+
 	if !managerutil.AgentsAreCompatible(agentList) {
 		errCode = rpc.InterceptDispositionType_NO_AGENT
 		errMsg = fmt.Sprintf("Agents for %q are not consistent", intercept.Spec.Agent)
@@ -287,6 +312,37 @@ func (s *State) unlockedCheckAgentsForIntercept(intercept *rpc.InterceptInfo) (e
 	return rpc.InterceptDispositionType_UNSPECIFIED, ""
 }
 
+// unlockedSelectTargetPod (1) assumes that s.mu is already locked, and (2) selects the pod whose agent
+// serves the given intercept when the spec limits it to one replica. The current target is kept for as
+// long as its agent remains. Otherwise, the pod with the lowest name is selected, ignoring the agent
+// of the given exceptSessionID. Returns true if the target pod changed.
+func (s *State) unlockedSelectTargetPod(intercept *rpc.InterceptInfo, exceptSessionID string) bool {
+	spec := intercept.Spec
+	target := spec.PodName
+	if target == "" && spec.Replicas == 1 {
+		var pods []string
+		for sessionID, agent := range s.agentsByName[spec.Agent] {
+			if sessionID == exceptSessionID || agent.Namespace != spec.Namespace || agent.PodName == "" {
+				continue
+			}
+			if agent.PodName == intercept.TargetPod {
+				// Keep the current target
+				return false
+			}
+			pods = append(pods, agent.PodName)
+		}
+		if len(pods) > 0 {
+			sort.Strings(pods)
+			target = pods[0]
+		}
+	}
+	if target == intercept.TargetPod {
+		return false
+	}
+	intercept.TargetPod = target
+	return true
+}
+
 // Sessions: common ////////////////////////////////////////////////////////////////////////////////
 
 // MarkSession marks a session as being present at the indicated time.  Returns true if everything goes OK,
@@ -331,16 +387,41 @@ func (s *State) gcSessionIntercepts(sessionID string) {
 			// Client went away:
 			// Delete it.
 			s.unlockedRemoveIntercept(interceptID)
-		} else if errCode, errMsg := s.unlockedCheckAgentsForIntercept(intercept); errCode != 0 {
+			continue
+		}
+
+		// An attached client went away:
+		// Detach it, and route the traffic back to the owner if it was routed to that client.
+		changed := detachClient(intercept, sessionID)
+		if errCode, errMsg := s.unlockedCheckAgentsForIntercept(intercept); errCode != 0 {
 			// Refcount went to zero:
 			// Tell the client, so that the client can tell us to delete it.
 			intercept.Disposition = errCode
 			intercept.Message = errMsg
-			s.intercepts.Store(interceptID, intercept)
-		} else if isAgent && agent.PodIp == intercept.PodIp {
-			// The agent whose podIP was stored by the intercept is dead, but it's not the last agent
-			// Send it back to waiting so that one of the other agents can pick it up and set their own podIP
-			intercept.Disposition = rpc.InterceptDispositionType_WAITING
+			changed = true
+		} else if isAgent && intercept.Spec.PodName != "" && intercept.Spec.PodName == agent.PodName &&
+			intercept.Spec.Agent == agent.Name && intercept.Spec.Namespace == agent.Namespace {
+			// The agent of the pod that the intercept is explicitly targeting is dead. The intercept
+			// must not move to another pod, so tell the client, so that the client can tell us to delete it.
+			intercept.Disposition = rpc.InterceptDispositionType_NO_AGENT
+			intercept.Message = fmt.Sprintf("No agent found for %q in pod %s", intercept.Spec.Agent, intercept.Spec.PodName)
+			changed = true
+		} else if isAgent {
+			// An intercept that is limited to one replica must move to another pod if it targets the dead agent
+			if s.unlockedSelectTargetPod(intercept, sessionID) {
+				changed = true
+				if intercept.Disposition == rpc.InterceptDispositionType_ACTIVE {
+					intercept.Disposition = rpc.InterceptDispositionType_WAITING
+				}
+			}
+			if agent.PodIp == intercept.PodIp {
+				// The agent whose podIP was stored by the intercept is dead, but it's not the last agent
+				// Send it back to waiting so that one of the other agents can pick it up and set their own podIP
+				intercept.Disposition = rpc.InterceptDispositionType_WAITING
+				changed = true
+			}
+		}
+		if changed {
 			s.intercepts.Store(interceptID, intercept)
 		}
 	}
@@ -381,6 +462,9 @@ func (s *State) ExpireSessions(ctx context.Context, clientMoment, agentMoment ti
 		if _, ok := sess.(*clientSessionState); ok {
 			if sess.LastMarked().Before(clientMoment) {
 				dlog.Debugf(ctx, "Client Session %s removed. It has expired", id)
+				if client, ok := s.clients.Load(id); ok {
+					s.notifier.ClientEvent(ctx, lifecycle.ClientExpired, id, client)
+				}
 				s.unlockedRemoveSession(id)
 			}
 		} else {
@@ -405,6 +489,17 @@ func (s *State) SessionDone(id string) (<-chan struct{}, error) {
 	return sess.Done(), nil
 }
 
+// SessionLastMarked returns the time when the session with the given ID was last marked, and
+// false if no such session exists.
+func (s *State) SessionLastMarked(sessionID string) (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if sess, ok := s.sessions[sessionID]; ok {
+		return sess.LastMarked(), true
+	}
+	return time.Time{}, false
+}
+
 // Sessions: Clients ///////////////////////////////////////////////////////////////////////////////
 
 func (s *State) AddClient(client *rpc.ClientInfo, now time.Time) string {
@@ -429,6 +524,7 @@ func (s *State) addClient(sessionID string, client *rpc.ClientInfo, now time.Tim
 		name:         client.Name,
 		pool:         tunnel.NewPool(),
 	}
+	s.notifier.ClientEvent(s.ctx, lifecycle.ClientArrived, sessionID, client)
 	return sessionID
 }
 
@@ -490,6 +586,9 @@ func (s *State) AddAgent(agent *rpc.AgentInfo, now time.Time) string {
 		} else if intercept.Disposition == rpc.InterceptDispositionType_NO_AGENT {
 			intercept.Disposition = rpc.InterceptDispositionType_WAITING
 			intercept.Message = ""
+			s.unlockedSelectTargetPod(intercept, "")
+			s.intercepts.Store(interceptID, intercept)
+		} else if intercept.TargetPod == "" && s.unlockedSelectTargetPod(intercept, "") {
 			s.intercepts.Store(interceptID, intercept)
 		}
 	}
@@ -530,9 +629,25 @@ func (s *State) WatchAgents(
 	}
 }
 
+// WatchAgentsSince is like WatchAgents, but the initial snapshot is resumed from the given revision
+// when the history of agents reaches back to it.
+func (s *State) WatchAgentsSince(
+	ctx context.Context,
+	since uint64,
+	filter func(sessionID string, agent *rpc.AgentInfo) bool,
+) <-chan watchable.Snapshot[*rpc.AgentInfo] {
+	if filter == nil {
+		filter = func(string, *rpc.AgentInfo) bool { return true }
+	}
+	return s.agents.SubscribeSubsetSince(ctx, since, filter)
+}
+
 // Intercepts //////////////////////////////////////////////////////////////////////////////////////
 
-func (s *State) AddIntercept(sessionID, clusterID, apiKey string, client *rpc.ClientInfo, spec *rpc.InterceptSpec) (*rpc.InterceptInfo, error) {
+// AddIntercept adds a new intercept for the given client session. If wait is true and the
+// intercepted workload is busy with another intercept, then the new intercept is QUEUED and
+// will transition to WAITING when the intercepts ahead of it have been removed.
+func (s *State) AddIntercept(sessionID, clusterID, apiKey string, client *rpc.ClientInfo, spec *rpc.InterceptSpec, wait bool) (*rpc.InterceptInfo, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -556,6 +671,17 @@ func (s *State) AddIntercept(sessionID, clusterID, apiKey string, client *rpc.Cl
 		ApiKey: apiKey,
 	}
 
+	queueKey := interceptQueueKey(spec)
+	if queued := len(s.interceptQueues[queueKey]); queued > 0 || wait && s.unlockedIsWorkloadIntercepted(spec) {
+		if !wait {
+			// The queued intercepts come first. Letting this one through would make it conflict with them.
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"%s.%s has %d queued intercepts; use --wait to queue this one", spec.Agent, spec.Namespace, queued)
+		}
+		cept.Disposition = rpc.InterceptDispositionType_QUEUED
+		setQueuePosition(cept, queued+1)
+	}
+
 	// Wrap each potential-state-change in a
 	//
 	//     if cept.Disposition == rpc.InterceptDispositionType_WAITING { … }
@@ -567,6 +693,7 @@ func (s *State) AddIntercept(sessionID, clusterID, apiKey string, client *rpc.Cl
 			cept.Message = errMsg
 		}
 	}
+	s.unlockedSelectTargetPod(cept, "")
 
 	if _, hasConflict := s.intercepts.LoadOrStore(cept.Id, cept); hasConflict {
 		return nil, status.Errorf(codes.AlreadyExists, "Intercept named %q already exists", spec.Name)
@@ -574,10 +701,93 @@ func (s *State) AddIntercept(sessionID, clusterID, apiKey string, client *rpc.Cl
 
 	state := newInterceptState(sess.ctx, s.ctx, cept.Id)
 	s.interceptStates[interceptID] = state
+	if cept.Disposition == rpc.InterceptDispositionType_QUEUED {
+		s.interceptQueues[queueKey] = append(s.interceptQueues[queueKey], interceptID)
+	}
+	s.notifier.InterceptEvent(s.ctx, lifecycle.InterceptCreated, cept, client)
 
 	return cept, nil
 }
 
+func interceptQueueKey(spec *rpc.InterceptSpec) string {
+	return spec.Agent + "." + spec.Namespace
+}
+
+func setQueuePosition(cept *rpc.InterceptInfo, pos int) {
+	cept.QueuePosition = int32(pos)
+	cept.Message = fmt.Sprintf("Queued at position %d, waiting for %s.%s to become available", pos, cept.Spec.Agent, cept.Spec.Namespace)
+}
+
+// unlockedIsWorkloadIntercepted returns true if the workload targeted by the given spec is held by
+// an intercept that is either ACTIVE or WAITING for the agent to make it active.
+func (s *State) unlockedIsWorkloadIntercepted(spec *rpc.InterceptSpec) bool {
+	holders := s.intercepts.LoadAllMatching(func(_ string, ii *rpc.InterceptInfo) bool {
+		if ii.Spec.Agent != spec.Agent || ii.Spec.Namespace != spec.Namespace {
+			return false
+		}
+		return ii.Disposition == rpc.InterceptDispositionType_ACTIVE || ii.Disposition == rpc.InterceptDispositionType_WAITING
+	})
+	return len(holders) > 0
+}
+
+// HasIntercepts returns true if the workload of the given agent has intercepts, regardless of their disposition.
+func (s *State) HasIntercepts(agent, namespace string) bool {
+	intercepts := s.intercepts.LoadAllMatching(func(_ string, ii *rpc.InterceptInfo) bool {
+		return ii.Spec.Agent == agent && ii.Spec.Namespace == namespace
+	})
+	return len(intercepts) > 0
+}
+
+// unlockedUpdateQueue (1) assumes that s.mu is already locked, and (2) updates the queue of the
+// workload targeted by a removed intercept. A removed QUEUED intercept is dropped from the queue. When
+// a removed intercept leaves the workload available, the first intercept in the queue transitions to
+// WAITING. The positions of the intercepts that remain in the queue are then updated.
+func (s *State) unlockedUpdateQueue(removed *rpc.InterceptInfo) {
+	key := interceptQueueKey(removed.Spec)
+	queue := s.interceptQueues[key]
+	if len(queue) == 0 {
+		return
+	}
+
+	if removed.Disposition == rpc.InterceptDispositionType_QUEUED {
+		for i, id := range queue {
+			if id == removed.Id {
+				queue = append(queue[:i:i], queue[i+1:]...)
+				break
+			}
+		}
+	} else {
+		if s.unlockedIsWorkloadIntercepted(removed.Spec) {
+			return
+		}
+		next := queue[0]
+		queue = queue[1:]
+		if cept, ok := s.intercepts.Load(next); ok {
+			cept.Disposition = rpc.InterceptDispositionType_WAITING
+			cept.Message = "Waiting for Agent approval"
+			cept.QueuePosition = 0
+			if errCode, errMsg := s.unlockedCheckAgentsForIntercept(cept); errCode != 0 {
+				cept.Disposition = errCode
+				cept.Message = errMsg
+			}
+			s.unlockedSelectTargetPod(cept, "")
+			s.intercepts.Store(next, cept)
+		}
+	}
+
+	if len(queue) == 0 {
+		delete(s.interceptQueues, key)
+		return
+	}
+	s.interceptQueues[key] = queue
+	for i, id := range queue {
+		if cept, ok := s.intercepts.Load(id); ok && cept.QueuePosition != int32(i+1) {
+			setQueuePosition(cept, i+1)
+			s.intercepts.Store(id, cept)
+		}
+	}
+}
+
 func (s *State) AddInterceptFinalizer(interceptID string, finalizer InterceptFinalizer) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -593,7 +803,7 @@ func (s *State) AddInterceptFinalizer(interceptID string, finalizer InterceptFin
 // intercepted by the client with the given client session ID.
 func (s *State) getAgentsInterceptedByClient(clientSessionID string) []string {
 	intercepts := s.intercepts.LoadAllMatching(func(_ string, ii *rpc.InterceptInfo) bool {
-		return ii.ClientSession.SessionId == clientSessionID
+		return IsInterceptClient(ii, clientSessionID)
 	})
 	if len(intercepts) == 0 {
 		return nil
@@ -640,6 +850,14 @@ func (s *State) UpdateIntercept(interceptID string, apply func(*rpc.InterceptInf
 		swapped := s.intercepts.CompareAndSwap(newInfo.Id, cur, newInfo)
 		if swapped {
 			// Success!
+			if cur.Disposition != newInfo.Disposition {
+				switch newInfo.Disposition {
+				case rpc.InterceptDispositionType_ACTIVE:
+					s.notifyIntercept(lifecycle.InterceptActive, newInfo)
+				case rpc.InterceptDispositionType_AGENT_ERROR:
+					s.notifyIntercept(lifecycle.InterceptAgentError, newInfo)
+				}
+			}
 			return newInfo
 		}
 	}
@@ -657,14 +875,157 @@ func (s *State) unlockedRemoveIntercept(interceptID string) bool {
 		delete(s.interceptStates, interceptID)
 		state.terminate(intercept)
 	}
+	if didDelete {
+		s.notifyIntercept(lifecycle.InterceptRemoved, intercept)
+		s.unlockedUpdateQueue(intercept)
+	}
 
 	return didDelete
 }
 
+// notifyIntercept sends a lifecycle event for the given intercept and the client that owns it.
+func (s *State) notifyIntercept(et lifecycle.EventType, intercept *rpc.InterceptInfo) {
+	client, _ := s.clients.Load(intercept.ClientSession.SessionId)
+	s.notifier.InterceptEvent(s.ctx, et, intercept, client)
+}
+
+// AttachIntercept attaches the client with the given session ID to the ACTIVE intercept of the given
+// workload. The attached client will receive the traffic of the intercept on the given targetPort if
+// the owner of the intercept hands the routing over to it. Attaching an already attached client will
+// update its targetPort.
+func (s *State) AttachIntercept(sessionID, agent, namespace string, targetPort int32) (*rpc.InterceptInfo, error) {
+	s.mu.RLock()
+	_, ok := s.sessions[sessionID].(*clientSessionState)
+	s.mu.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "session %q not found", sessionID)
+	}
+	client, _ := s.clients.Load(sessionID)
+
+	var interceptID string
+	for id, ii := range s.intercepts.LoadAllMatching(func(_ string, ii *rpc.InterceptInfo) bool {
+		return ii.Spec.Agent == agent && ii.Spec.Namespace == namespace && ii.Disposition == rpc.InterceptDispositionType_ACTIVE
+	}) {
+		if ii.ClientSession.SessionId == sessionID {
+			return nil, status.Errorf(codes.FailedPrecondition, "intercept %q is owned by this client", ii.Spec.Name)
+		}
+		interceptID = id
+		break
+	}
+	if interceptID == "" {
+		return nil, status.Errorf(codes.NotFound, "no active intercept found for %s.%s", agent, namespace)
+	}
+
+	// The intercept may have changed since it was found, so the conditions are checked again
+	// against the version that is updated.
+	var err error
+	cept := s.UpdateIntercept(interceptID, func(ii *rpc.InterceptInfo) {
+		switch {
+		case ii.Disposition != rpc.InterceptDispositionType_ACTIVE:
+			err = status.Errorf(codes.FailedPrecondition, "intercept %q is no longer active", ii.Spec.Name)
+			return
+		case ii.ClientSession.SessionId == sessionID:
+			err = status.Errorf(codes.FailedPrecondition, "intercept %q is owned by this client", ii.Spec.Name)
+			return
+		}
+		err = nil
+		if ac := attachedClient(ii, sessionID); ac != nil {
+			ac.TargetPort = targetPort
+			return
+		}
+		ii.AttachedClients = append(ii.AttachedClients, &rpc.AttachedClient{
+			Session:    &rpc.SessionInfo{SessionId: sessionID},
+			Client:     client.GetName(),
+			TargetPort: targetPort,
+		})
+	})
+	if cept == nil {
+		return nil, status.Errorf(codes.NotFound, "no active intercept found for %s.%s", agent, namespace)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cept, nil
+}
+
+// DetachIntercept detaches the client with the given session ID from the given intercept. If the
+// traffic of the intercept was routed to the detached client, then it is routed back to the owner.
+func (s *State) DetachIntercept(sessionID, interceptID string) error {
+	cept, ok := s.intercepts.Load(interceptID)
+	if !ok || attachedClient(cept, sessionID) == nil {
+		return status.Errorf(codes.NotFound, "session %q is not attached to intercept %q", sessionID, interceptID)
+	}
+	s.UpdateIntercept(interceptID, func(ii *rpc.InterceptInfo) {
+		detachClient(ii, sessionID)
+	})
+	return nil
+}
+
+// HandoverIntercept routes the traffic of the intercept with the given name, owned by the client with the
+// given session ID, to the attached client with the given targetSessionID. An empty targetSessionID
+// routes the traffic back to the owner.
+func (s *State) HandoverIntercept(sessionID, name, targetSessionID string) (*rpc.InterceptInfo, error) {
+	interceptID := sessionID + ":" + name
+	cept, ok := s.intercepts.Load(interceptID)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "intercept named %q not found", name)
+	}
+	if targetSessionID != "" && attachedClient(cept, targetSessionID) == nil {
+		return nil, status.Errorf(codes.NotFound, "session %q is not attached to intercept %q", targetSessionID, name)
+	}
+	cept = s.UpdateIntercept(interceptID, func(ii *rpc.InterceptInfo) {
+		if targetSessionID == "" || attachedClient(ii, targetSessionID) != nil {
+			ii.RoutedSessionId = targetSessionID
+		}
+	})
+	if cept == nil {
+		return nil, status.Errorf(codes.NotFound, "intercept named %q not found", name)
+	}
+	if cept.RoutedSessionId != targetSessionID {
+		return nil, status.Errorf(codes.NotFound, "session %q is not attached to intercept %q", targetSessionID, name)
+	}
+	return cept, nil
+}
+
+// IsInterceptClient returns true if the client with the given session ID owns, or is attached to, the
+// given intercept.
+func IsInterceptClient(ii *rpc.InterceptInfo, sessionID string) bool {
+	return ii.ClientSession.SessionId == sessionID || attachedClient(ii, sessionID) != nil
+}
+
+func attachedClient(ii *rpc.InterceptInfo, sessionID string) *rpc.AttachedClient {
+	for _, ac := range ii.AttachedClients {
+		if ac.Session.SessionId == sessionID {
+			return ac
+		}
+	}
+	return nil
+}
+
+// detachClient removes the client with the given session ID from the attached clients of the given
+// intercept and routes the traffic back to the owner if it was routed to that client. It returns
+// true if the intercept was modified.
+func detachClient(ii *rpc.InterceptInfo, sessionID string) bool {
+	for i, ac := range ii.AttachedClients {
+		if ac.Session.SessionId == sessionID {
+			ii.AttachedClients = append(ii.AttachedClients[:i:i], ii.AttachedClients[i+1:]...)
+			if ii.RoutedSessionId == sessionID {
+				ii.RoutedSessionId = ""
+			}
+			return true
+		}
+	}
+	return false
+}
+
 func (s *State) GetIntercept(interceptID string) (*rpc.InterceptInfo, bool) {
 	return s.intercepts.Load(interceptID)
 }
 
+func (s *State) GetAllIntercepts() map[string]*rpc.InterceptInfo {
+	return s.intercepts.LoadAll()
+}
+
 func (s *State) WatchIntercepts(
 	ctx context.Context,
 	filter func(sessionID string, intercept *rpc.InterceptInfo) bool,
@@ -676,6 +1037,19 @@ func (s *State) WatchIntercepts(
 	}
 }
 
+// WatchInterceptsSince is like WatchIntercepts, but the initial snapshot is resumed from the given
+// revision when the history of intercepts reaches back to it.
+func (s *State) WatchInterceptsSince(
+	ctx context.Context,
+	since uint64,
+	filter func(sessionID string, intercept *rpc.InterceptInfo) bool,
+) <-chan watchable.Snapshot[*rpc.InterceptInfo] {
+	if filter == nil {
+		filter = func(string, *rpc.InterceptInfo) bool { return true }
+	}
+	return s.intercepts.SubscribeSubsetSince(ctx, since, filter)
+}
+
 func (s *State) Tunnel(ctx context.Context, stream tunnel.Stream) error {
 	ctx, span := otel.Tracer("").Start(ctx, "state.Tunnel")
 	defer span.End()
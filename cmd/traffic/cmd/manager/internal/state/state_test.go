@@ -5,6 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"google.golang.org/protobuf/proto"
+
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
 	manager "github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/internal/state"
 	testdata "github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/internal/test"
 )
@@ -124,4 +127,268 @@ func TestStateInternal(topT *testing.T) {
 		a.False(state.Mark(c2, clock.Now()))
 		a.False(state.Mark(c3, clock.Now()))
 	})
+
+	topT.Run("intercept-queue", func(t *testing.T) {
+		a := assertNew(t)
+
+		clock := &FakeClock{}
+		state := manager.NewState(ctx)
+		state.AddAgent(testAgents["hello"], clock.Now())
+
+		addIntercept := func(client string, wait bool) *rpc.InterceptInfo {
+			t.Helper()
+			sessionID := state.AddClient(testClients[client], clock.Now())
+			ii, err := state.AddIntercept(sessionID, "cluster", "", testClients[client], &rpc.InterceptSpec{
+				Name:      client,
+				Client:    testClients[client].Name,
+				Agent:     "hello",
+				Namespace: "default",
+				Mechanism: "tcp",
+			}, wait)
+			a.NoError(err)
+			return ii
+		}
+		disposition := func(ii *rpc.InterceptInfo) (rpc.InterceptDispositionType, int32) {
+			t.Helper()
+			cur, ok := state.GetIntercept(ii.Id)
+			a.True(ok)
+			return cur.Disposition, cur.QueuePosition
+		}
+
+		alice := addIntercept("alice", true)
+		a.Equal(rpc.InterceptDispositionType_WAITING, alice.Disposition)
+
+		// Without wait, the intercept is not queued, and is left to the agent to reject
+		bob := addIntercept("bob", false)
+		a.Equal(rpc.InterceptDispositionType_WAITING, bob.Disposition)
+		a.True(state.RemoveIntercept(bob.Id))
+
+		bob = addIntercept("bob", true)
+		a.Equal(rpc.InterceptDispositionType_QUEUED, bob.Disposition)
+		a.Equal(int32(1), bob.QueuePosition)
+
+		cameron := addIntercept("cameron", true)
+		a.Equal(rpc.InterceptDispositionType_QUEUED, cameron.Disposition)
+		a.Equal(int32(2), cameron.QueuePosition)
+
+		pat := addIntercept("pat", true)
+		a.Equal(int32(3), pat.QueuePosition)
+
+		// Without wait, the intercept is rejected since it would jump the queue
+		sessionID := state.AddClient(testClients["bob"], clock.Now())
+		_, err := state.AddIntercept(sessionID, "cluster", "", testClients["bob"], &rpc.InterceptSpec{
+			Name:      "jumper",
+			Client:    testClients["bob"].Name,
+			Agent:     "hello",
+			Namespace: "default",
+			Mechanism: "tcp",
+		}, false)
+		a.Error(err)
+		state.RemoveSession(ctx, sessionID)
+
+		// Cameron's client departs, so Pat moves up in the queue
+		state.RemoveSession(ctx, cameron.ClientSession.SessionId)
+		_, ok := state.GetIntercept(cameron.Id)
+		a.False(ok)
+		d, pos := disposition(pat)
+		a.Equal(rpc.InterceptDispositionType_QUEUED, d)
+		a.Equal(int32(2), pos)
+
+		// Alice leaves, so Bob gets the workload
+		a.True(state.RemoveIntercept(alice.Id))
+		d, pos = disposition(bob)
+		a.Equal(rpc.InterceptDispositionType_WAITING, d)
+		a.Equal(int32(0), pos)
+		d, pos = disposition(pat)
+		a.Equal(rpc.InterceptDispositionType_QUEUED, d)
+		a.Equal(int32(1), pos)
+
+		// Bob's client departs, so Pat gets the workload
+		state.RemoveSession(ctx, bob.ClientSession.SessionId)
+		d, pos = disposition(pat)
+		a.Equal(rpc.InterceptDispositionType_WAITING, d)
+		a.Equal(int32(0), pos)
+	})
+
+	topT.Run("attach-intercept", func(t *testing.T) {
+		a := assertNew(t)
+
+		clock := &FakeClock{}
+		state := manager.NewState(ctx)
+		state.AddAgent(testAgents["hello"], clock.Now())
+
+		aliceID := state.AddClient(testClients["alice"], clock.Now())
+		bobID := state.AddClient(testClients["bob"], clock.Now())
+		cameronID := state.AddClient(testClients["cameron"], clock.Now())
+
+		// Nothing to attach to until the intercept is active
+		_, err := state.AttachIntercept(bobID, "hello", "default", 8080)
+		a.Error(err)
+
+		cept, err := state.AddIntercept(aliceID, "cluster", "", testClients["alice"], &rpc.InterceptSpec{
+			Name:       "hello",
+			Client:     testClients["alice"].Name,
+			Agent:      "hello",
+			Namespace:  "default",
+			Mechanism:  "tcp",
+			TargetPort: 8080,
+		}, false)
+		a.NoError(err)
+		state.UpdateIntercept(cept.Id, func(ii *rpc.InterceptInfo) {
+			ii.Disposition = rpc.InterceptDispositionType_ACTIVE
+		})
+
+		// The owner cannot attach to its own intercept
+		_, err = state.AttachIntercept(aliceID, "hello", "default", 8080)
+		a.Error(err)
+
+		ii, err := state.AttachIntercept(bobID, "hello", "default", 9090)
+		a.NoError(err)
+		a.Equal(cept.Id, ii.Id)
+		a.Len(ii.AttachedClients, 1)
+		a.Equal(testClients["bob"].Name, ii.AttachedClients[0].Client)
+		a.Equal(int32(9090), ii.AttachedClients[0].TargetPort)
+		a.True(manager.IsInterceptClient(ii, bobID))
+		a.False(manager.IsInterceptClient(ii, cameronID))
+
+		_, err = state.AttachIntercept(cameronID, "hello", "default", 7070)
+		a.NoError(err)
+
+		// Only attached clients can receive the traffic, and only the owner can hand it over
+		_, err = state.HandoverIntercept(aliceID, "hello", "nobody")
+		a.Error(err)
+		_, err = state.HandoverIntercept(bobID, "hello", cameronID)
+		a.Error(err)
+		ii, err = state.HandoverIntercept(aliceID, "hello", bobID)
+		a.NoError(err)
+		a.Equal(bobID, ii.RoutedSessionId)
+
+		// Bob's client departs, so the traffic is routed back to the owner
+		state.RemoveSession(ctx, bobID)
+		ii, ok := state.GetIntercept(cept.Id)
+		a.True(ok)
+		a.Equal(rpc.InterceptDispositionType_ACTIVE, ii.Disposition)
+		a.Equal("", ii.RoutedSessionId)
+		a.Len(ii.AttachedClients, 1)
+
+		a.NoError(state.DetachIntercept(cameronID, cept.Id))
+		a.Error(state.DetachIntercept(cameronID, cept.Id))
+		ii, _ = state.GetIntercept(cept.Id)
+		a.Empty(ii.AttachedClients)
+	})
+
+	topT.Run("attach-intercept-while-inactivated", func(t *testing.T) {
+		a := assertNew(t)
+
+		clock := &FakeClock{}
+		state := manager.NewState(ctx)
+		state.AddAgent(testAgents["hello"], clock.Now())
+
+		aliceID := state.AddClient(testClients["alice"], clock.Now())
+		bobID := state.AddClient(testClients["bob"], clock.Now())
+
+		cept, err := state.AddIntercept(aliceID, "cluster", "", testClients["alice"], &rpc.InterceptSpec{
+			Name:       "hello",
+			Client:     testClients["alice"].Name,
+			Agent:      "hello",
+			Namespace:  "default",
+			Mechanism:  "tcp",
+			TargetPort: 8080,
+		}, false)
+		a.NoError(err)
+		setDisposition := func(d rpc.InterceptDispositionType) {
+			state.UpdateIntercept(cept.Id, func(ii *rpc.InterceptInfo) {
+				ii.Disposition = d
+			})
+		}
+		setDisposition(rpc.InterceptDispositionType_ACTIVE)
+
+		// The intercept toggles between active and waiting while Bob attaches and detaches. An attach
+		// must never succeed unless the intercept that it returns is active.
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 1000; i++ {
+				setDisposition(rpc.InterceptDispositionType_WAITING)
+				setDisposition(rpc.InterceptDispositionType_ACTIVE)
+			}
+		}()
+		for running := true; running; {
+			select {
+			case <-done:
+				running = false
+			default:
+			}
+			ii, err := state.AttachIntercept(bobID, "hello", "default", 9090)
+			if err != nil {
+				continue
+			}
+			a.Equal(rpc.InterceptDispositionType_ACTIVE, ii.Disposition)
+			_ = state.DetachIntercept(bobID, cept.Id)
+		}
+	})
+
+	topT.Run("single-replica-intercept", func(t *testing.T) {
+		a := assertNew(t)
+
+		clock := &FakeClock{}
+		state := manager.NewState(ctx)
+
+		demoAgent1 := proto.Clone(testAgents["demo1"]).(*rpc.AgentInfo)
+		demoAgent1.PodName = "demo-abcdef-456"
+		demoAgent1.PodIp = "10.0.0.1"
+		demoAgent2 := proto.Clone(testAgents["demo2"]).(*rpc.AgentInfo)
+		demoAgent2.PodName = "demo-abcdef-123"
+		demoAgent2.PodIp = "10.0.0.2"
+		d1 := state.AddAgent(demoAgent1, clock.Now())
+		d2 := state.AddAgent(demoAgent2, clock.Now())
+
+		addIntercept := func(client, podName string, replicas int32) *rpc.InterceptInfo {
+			t.Helper()
+			sessionID := state.AddClient(testClients[client], clock.Now())
+			ii, err := state.AddIntercept(sessionID, "cluster", "", testClients[client], &rpc.InterceptSpec{
+				Name:      client,
+				Client:    testClients[client].Name,
+				Agent:     "demo",
+				Namespace: "default",
+				Mechanism: "tcp",
+				PodName:   podName,
+				Replicas:  replicas,
+			}, false)
+			a.NoError(err)
+			return ii
+		}
+
+		// The traffic-manager chooses the pod with the lowest name
+		alice := addIntercept("alice", "", 1)
+		a.Equal(rpc.InterceptDispositionType_WAITING, alice.Disposition)
+		a.Equal("demo-abcdef-123", alice.TargetPod)
+
+		// A named pod is targeted regardless of replicas
+		bob := addIntercept("bob", "demo-abcdef-456", 0)
+		a.Equal(rpc.InterceptDispositionType_WAITING, bob.Disposition)
+		a.Equal("demo-abcdef-456", bob.TargetPod)
+
+		cameron := addIntercept("cameron", "demo-nope", 0)
+		a.Equal(rpc.InterceptDispositionType_NO_AGENT, cameron.Disposition)
+
+		state.UpdateIntercept(alice.Id, func(ii *rpc.InterceptInfo) {
+			ii.Disposition = rpc.InterceptDispositionType_ACTIVE
+			ii.PodIp = demoAgent2.PodIp
+		})
+
+		// The targeted pod goes away, so the intercept moves to the remaining pod
+		state.RemoveSession(ctx, d2)
+		ii, ok := state.GetIntercept(alice.Id)
+		a.True(ok)
+		a.Equal(rpc.InterceptDispositionType_WAITING, ii.Disposition)
+		a.Equal("demo-abcdef-456", ii.TargetPod)
+		// The named pod goes away, so the intercept that targets it loses its agent
+		state.AddAgent(demoAgent2, clock.Now())
+		state.RemoveSession(ctx, d1)
+		ii, ok = state.GetIntercept(bob.Id)
+		a.True(ok)
+		a.Equal(rpc.InterceptDispositionType_NO_AGENT, ii.Disposition)
+		a.Equal("demo-abcdef-456", ii.TargetPod)
+	})
 }
@@ -2,11 +2,16 @@ package watchable
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
 	"google.golang.org/protobuf/proto"
 )
 
+// DefaultHistorySize is the number of changes that a Map remembers when its HistorySize is zero.
+const DefaultHistorySize = 1024
+
 type Message = proto.Message
 
 // Update describes a mutation made to a Map.
@@ -14,6 +19,8 @@ type Update[V Message] struct {
 	Key    string
 	Delete bool // Whether this is deleting the entry for .Key, or setting it to .Value.
 	Value  V
+
+	revision uint64
 }
 
 // Snapshot contains a snapshot of the current state of a Map, as well as a list of
@@ -26,6 +33,44 @@ type Snapshot[V Message] struct {
 	// deleted.  No-op updates are not included (i.e., setting something to its current value,
 	// or deleting something that does not exist).
 	Updates []Update[V]
+
+	revision uint64
+	resumed  bool
+}
+
+// Revision returns the revision of the Map that the State of this snapshot reflects. Passing
+// it to SubscribeSubsetSince resumes a subscription from this snapshot.
+func (s Snapshot[V]) Revision() uint64 {
+	return s.revision
+}
+
+// Resumed returns true if this is the initial snapshot of a subscription that was resumed
+// from a revision. The Updates of such a snapshot contain one entry for each key that changed
+// since that revision, and that satisfied the subscription's predicate either at that revision
+// or now. Deletes in those Updates have .Value set to the value at that revision.
+func (s Snapshot[V]) Resumed() bool {
+	return s.resumed
+}
+
+// Changes returns the entries that were set by the Updates of this snapshot, and the keys of
+// the entries that were deleted. An entry that was changed more than once is only included
+// once, with its value from the State.
+func (s Snapshot[V]) Changes() (set map[string]V, deleted []string) {
+	set = make(map[string]V)
+	seen := make(map[string]struct{}, len(s.Updates))
+	for _, u := range s.Updates {
+		if _, ok := seen[u.Key]; ok {
+			continue
+		}
+		seen[u.Key] = struct{}{}
+		if v, ok := s.State[u.Key]; ok {
+			set[u.Key] = v
+		} else {
+			deleted = append(deleted, u.Key)
+		}
+	}
+	sort.Strings(deleted)
+	return set, deleted
 }
 
 // Map is a wrapper around map[string]VALTYPE that is very similar to sync.Map, and that
@@ -36,25 +81,80 @@ type Snapshot[V Message] struct {
 // 3. it provides a compare-and-swap operation
 // 4. you can Subscribe to either the whole map or just a subset of the map to watch for updates.
 //    This gives you complete snapshots, deltas, and coalescing of rapid updates.
+// 5. it keeps a revision counter and a bounded history of changes, so that a subscription can
+//    be resumed from the revision of a snapshot that was received earlier.
 type Map[V Message] struct {
+	// HistorySize is the number of changes that the map remembers. Subscriptions can be
+	// resumed from revisions that are no more than HistorySize changes old. Zero means
+	// DefaultHistorySize. Must be set before the map is first used.
+	HistorySize int
+
 	lock sync.RWMutex
 	// things guarded by 'lock'
 	close       chan struct{} // can read from the channel while unlocked, IF you've already validated it's non-nil
 	value       map[string]V
 	subscribers map[<-chan Update[V]]chan<- Update[V] // readEnd ↦ writeEnd
+	revision    uint64
+	history     []historyEntry[V]
 
 	// not guarded by 'lock'
 	wg sync.WaitGroup
 }
 
+// historyEntry records the key of a change, the revision that the change resulted in, and the
+// value that the key had before the change.
+type historyEntry[V Message] struct {
+	key      string
+	revision uint64
+	prev     V
+	hadPrev  bool
+}
+
 func (tm *Map[V]) unlockedInit() {
 	if tm.close == nil {
 		tm.close = make(chan struct{})
 		tm.value = make(map[string]V)
 		tm.subscribers = make(map[<-chan Update[V]]chan<- Update[V])
+
+		// Revisions start at the time of initialization, so that a revision that was obtained
+		// from a previous incarnation of the map is never mistaken for a current one.
+		tm.revision = uint64(time.Now().UnixNano())
 	}
 }
 
+// unlockedAddHistory (1) assumes that tm.lock is already locked, and (2) bumps the revision
+// and records the change of the given key in the history. It must be called before the change
+// is made to tm.value.
+func (tm *Map[V]) unlockedAddHistory(key string) uint64 {
+	tm.revision++
+	max := tm.HistorySize
+	if max <= 0 {
+		max = DefaultHistorySize
+	}
+	if len(tm.history) >= max {
+		n := copy(tm.history, tm.history[len(tm.history)-max+1:])
+		tm.history = tm.history[:n]
+	}
+	prev, hadPrev := tm.value[key]
+	tm.history = append(tm.history, historyEntry[V]{key: key, revision: tm.revision, prev: prev, hadPrev: hadPrev})
+	return tm.revision
+}
+
+// unlockedChangedSince (1) assumes that tm.lock is already locked, and (2) returns the keys that
+// changed after the given revision, mapped to their first change after that revision, so that
+// the entry describes the value that the key had at that revision. The returned bool is false if
+// the history doesn't reach back to that revision.
+func (tm *Map[V]) unlockedChangedSince(since uint64) (map[string]historyEntry[V], bool) {
+	if since == 0 || since > tm.revision || tm.revision-since > uint64(len(tm.history)) {
+		return nil, false
+	}
+	changed := make(map[string]historyEntry[V])
+	for i := len(tm.history) - 1; i >= 0 && tm.history[i].revision > since; i-- {
+		changed[tm.history[i].key] = tm.history[i]
+	}
+	return changed, true
+}
+
 func (tm *Map[V]) unlockedIsClosed() bool {
 	select {
 	case <-tm.close:
@@ -162,11 +262,13 @@ func (tm *Map[V]) unlockedStore(key string, val V) {
 		select {}
 	}
 
+	rev := tm.unlockedAddHistory(key)
 	tm.value[key] = val
 	for _, subscriber := range tm.subscribers {
 		subscriber <- Update[V]{
-			Key:   key,
-			Value: proto.Clone(val).(V),
+			Key:      key,
+			Value:    proto.Clone(val).(V),
+			revision: rev,
 		}
 	}
 }
@@ -187,14 +289,13 @@ func (tm *Map[V]) unlockedDelete(key string) {
 		select {}
 	}
 
-	if tm.value == nil {
-		return
-	}
+	rev := tm.unlockedAddHistory(key)
 	delete(tm.value, key)
 	for _, subscriber := range tm.subscribers {
 		subscriber <- Update[V]{
-			Key:    key,
-			Delete: true,
+			Key:      key,
+			Delete:   true,
+			revision: rev,
 		}
 	}
 }
@@ -236,18 +337,21 @@ func (tm *Map[V]) Close() {
 }
 
 // internalSubscribe returns a channel (that blocks on both ends), that is written to on each map
-// update.  If the map is already Close()ed, then this returns nil.
-func (tm *Map[V]) internalSubscribe(_ context.Context) (<-chan Update[V], map[string]V) {
+// update, together with the current state and revision of the map, and the keys that changed since
+// the given revision (nil unless the subscription can be resumed from that revision).  If the map is
+// already Close()ed, then this returns a nil channel.
+func (tm *Map[V]) internalSubscribe(_ context.Context, since uint64) (<-chan Update[V], map[string]V, uint64, map[string]historyEntry[V]) {
 	tm.lock.Lock()
 	defer tm.lock.Unlock()
 	tm.unlockedInit()
 
 	ret := make(chan Update[V])
 	if tm.unlockedIsClosed() {
-		return nil, nil
+		return nil, nil, 0, nil
 	}
 	tm.subscribers[ret] = ret
-	return ret, tm.unlockedLoadAll()
+	changed, _ := tm.unlockedChangedSince(since)
+	return ret, tm.unlockedLoadAll(), tm.revision, changed
 }
 
 // Subscribe returns a channel that will emit a complete snapshot of the map immediately after the
@@ -273,7 +377,17 @@ func (tm *Map[V]) Subscribe(ctx context.Context) <-chan Snapshot[V] {
 // new snapshot to be emitted.  If the value for a key changes from satisfying the predicate to not
 // satisfying it, then this is treated as a delete operation, and a new snapshot is generated.
 func (tm *Map[V]) SubscribeSubset(ctx context.Context, include func(string, V) bool) <-chan Snapshot[V] {
-	upstream, initialSnapshot := tm.internalSubscribe(ctx)
+	return tm.SubscribeSubsetSince(ctx, 0, include)
+}
+
+// SubscribeSubsetSince is like SubscribeSubset, but if the history of the map reaches back to the
+// given revision, then the initial snapshot is Resumed, and its Updates contain the entries that
+// changed since that revision. A deleted entry is only included when its value at that revision
+// satisfied the 'include' predicate, so that a resumed subscription never learns about keys that
+// it didn't see before. Otherwise, the initial snapshot contains 0 updates, just like with
+// SubscribeSubset. A revision of zero never resumes.
+func (tm *Map[V]) SubscribeSubsetSince(ctx context.Context, since uint64, include func(string, V) bool) <-chan Snapshot[V] {
+	upstream, initialSnapshot, revision, changed := tm.internalSubscribe(ctx, since)
 	downstream := make(chan Snapshot[V])
 
 	if upstream == nil {
@@ -282,7 +396,7 @@ func (tm *Map[V]) SubscribeSubset(ctx context.Context, include func(string, V) b
 	}
 
 	tm.wg.Add(1)
-	go tm.coalesce(ctx, include, upstream, downstream, initialSnapshot)
+	go tm.coalesce(ctx, include, upstream, downstream, initialSnapshot, revision, changed)
 
 	return downstream
 }
@@ -293,6 +407,8 @@ func (tm *Map[V]) coalesce(
 	upstream <-chan Update[V],
 	downstream chan<- Snapshot[V],
 	initialSnapshot map[string]V,
+	initialRevision uint64,
+	changedSince map[string]historyEntry[V],
 ) {
 	defer tm.wg.Done()
 	defer close(downstream)
@@ -332,13 +448,33 @@ func (tm *Map[V]) coalesce(
 		State: make(map[string]V, len(cur)),
 
 		Updates: nil,
+
+		revision: initialRevision,
 	}
 	for k, v := range cur {
 		snapshot.State[k] = v
 	}
+	if changedSince != nil {
+		snapshot.resumed = true
+		keys := make([]string, 0, len(changedSince))
+		for k := range changedSince {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if v, ok := cur[k]; ok {
+				snapshot.Updates = append(snapshot.Updates, Update[V]{Key: k, Value: v, revision: initialRevision})
+			} else if h := changedSince[k]; h.hadPrev && includep(k, h.prev) {
+				snapshot.Updates = append(snapshot.Updates, Update[V]{Key: k, Delete: true, Value: proto.Clone(h.prev).(V), revision: initialRevision})
+			}
+		}
+	}
 
 	// applyUpdate applies an update to 'cur', and updates 'snapshot.State' as nescessary.
 	applyUpdate := func(update Update[V]) {
+		// The revision advances even when the update is filtered out, because the state that
+		// the next snapshot reflects includes that update.
+		snapshot.revision = update.revision
 		if update.Delete || !includep(update.Key, update.Value) {
 			if old, haveOld := cur[update.Key]; haveOld {
 				update.Delete = true
@@ -423,3 +423,83 @@ func typedTestMessageMap_SubscribeSubset[V watchable.Message](t *testing.T, ctor
 	assert.False(t, ok)
 	assert.Zero(t, snapshot)
 }
+
+func TestMessageMap_SubscribeSubsetSince(t *testing.T) {
+	typedTestMessageMap_SubscribeSubsetSince[*manager.AgentInfo](t, agentInfoCtor, agentInfoCmp)
+}
+
+func typedTestMessageMap_SubscribeSubsetSince[V watchable.Message](t *testing.T, ctor func(string) V, comp func(V, string) bool) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, true))
+	defer cancel()
+	m := watchable.Map[V]{HistorySize: 4}
+	include := func(k string, v V) bool {
+		return !comp(v, "ignoreme")
+	}
+
+	m.Store("a", ctor("A"))
+	m.Store("b", ctor("B"))
+
+	subCtx, subCancel := context.WithCancel(ctx)
+	snapshot, ok := <-m.SubscribeSubsetSince(subCtx, 0, include)
+	subCancel()
+	assert.True(t, ok)
+	assert.False(t, snapshot.Resumed())
+	rev := snapshot.Revision()
+	assert.NotZero(t, rev)
+
+	m.Store("a", ctor("a"))
+	m.Store("c", ctor("C"))
+	m.Delete("b")
+	m.Store("c", ctor("ignoreme"))
+
+	// Check that a resumed snapshot has the full state, and one update for each changed key
+	subCtx, subCancel = context.WithCancel(ctx)
+	snapshot, ok = <-m.SubscribeSubsetSince(subCtx, rev, include)
+	subCancel()
+	assert.True(t, ok)
+	assert.True(t, snapshot.Resumed())
+	assert.Equal(t, rev+4, snapshot.Revision())
+	assertMessageMapSnapshotEqual(t,
+		watchable.Snapshot[V]{
+			State: map[string]V{
+				"a": ctor("a"),
+			},
+		},
+		watchable.Snapshot[V]{State: snapshot.State})
+	// "c" was neither present at the revision nor is it included now, so it isn't mentioned.
+	if assert.Len(t, snapshot.Updates, 2) {
+		assert.Equal(t, "a", snapshot.Updates[0].Key)
+		assert.False(t, snapshot.Updates[0].Delete)
+		assert.Equal(t, "b", snapshot.Updates[1].Key)
+		assert.True(t, snapshot.Updates[1].Delete)
+		assert.True(t, comp(snapshot.Updates[1].Value, "B"))
+	}
+	set, deleted := snapshot.Changes()
+	assert.Len(t, set, 1)
+	assert.Equal(t, []string{"b"}, deleted)
+
+	// Check that a resume from the current revision yields no updates
+	subCtx, subCancel = context.WithCancel(ctx)
+	ch := m.SubscribeSubsetSince(subCtx, snapshot.Revision(), include)
+	snapshot, ok = <-ch
+	assert.True(t, ok)
+	assert.True(t, snapshot.Resumed())
+	assert.Empty(t, snapshot.Updates)
+
+	// Check that subsequent snapshots carry the revision of the last update
+	m.Store("d", ctor("D"))
+	snapshot, ok = <-ch
+	subCancel()
+	assert.True(t, ok)
+	assert.False(t, snapshot.Resumed())
+	assert.Equal(t, rev+5, snapshot.Revision())
+
+	// Check that a revision older than the history doesn't resume
+	subCtx, subCancel = context.WithCancel(ctx)
+	snapshot, ok = <-m.SubscribeSubsetSince(subCtx, rev, include)
+	subCancel()
+	assert.True(t, ok)
+	assert.False(t, snapshot.Resumed())
+	assert.Empty(t, snapshot.Updates)
+	assert.Len(t, snapshot.State, 2)
+}
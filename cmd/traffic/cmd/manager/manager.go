@@ -12,6 +12,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/datawire/dlib/dhttp"
 	"github.com/datawire/dlib/dlog"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/internal/lifecycle"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/internal/mutator"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
@@ -48,6 +50,11 @@ func Main(ctx context.Context, _ ...string) error {
 		return fmt.Errorf("unable to create the Kubernetes Interface from InClusterConfig: %w", err)
 	}
 	ctx = k8sapi.WithK8sInterface(ctx, ki)
+	di, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("unable to create the Kubernetes dynamic Interface from InClusterConfig: %w", err)
+	}
+	ctx = k8sapi.WithDynamicInterface(ctx, di)
 	mgr, ctx, err := NewManager(ctx)
 	if err != nil {
 		return fmt.Errorf("unable to initialize traffic manager: %w", err)
@@ -82,10 +89,16 @@ func Main(ctx context.Context, _ ...string) error {
 
 	g.Go("prometheus", mgr.servePrometheus)
 
-	g.Go("agent-injector", mutator.ServeMutator)
+	g.Go("agent-injector", func(ctx context.Context) error {
+		return mutator.ServeMutator(ctx, mgr.state.HasIntercepts)
+	})
 
 	g.Go("session-gc", mgr.runSessionGCLoop)
 
+	if notifier := lifecycle.GetNotifier(ctx); notifier != nil {
+		g.Go("lifecycle-webhooks", notifier.Run)
+	}
+
 	// Wait for exit
 	return g.Wait()
 }
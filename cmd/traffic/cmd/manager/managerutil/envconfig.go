@@ -4,6 +4,7 @@ import (
 	"context"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/sethvargo/go-envconfig"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -24,6 +25,9 @@ type Env struct {
 	PrometheusPort string `env:"PROMETHEUS_PORT,default=0"`
 	SystemAHost    string `env:"SYSTEMA_HOST,default=app.getambassador.io"`
 	SystemAPort    string `env:"SYSTEMA_PORT,default=443"`
+	AdminToken     string `env:"TELEPRESENCE_ADMIN_TOKEN,default="`
+
+	LifecycleWebhooks string `env:"LIFECYCLE_WEBHOOKS,default="`
 
 	ManagerNamespace    string                     `env:"MANAGER_NAMESPACE,default="`
 	ManagedNamespaces   string                     `env:"MANAGED_NAMESPACES,default="`
@@ -35,6 +39,17 @@ type Env struct {
 	MaxReceiveSize      resource.Quantity          `env:"TELEPRESENCE_MAX_RECEIVE_SIZE,default=4Mi"`
 	AppProtocolStrategy k8sapi.AppProtocolStrategy `env:"TELEPRESENCE_APP_PROTO_STRATEGY,default="`
 	AgentInjectPolicy   agentconfig.InjectPolicy   `env:"AGENT_INJECT_POLICY,default="`
+	AgentRedirectPolicy agentconfig.RedirectPolicy `env:"AGENT_REDIRECT_POLICY,default="`
+
+	AgentNetfilterBackend agentconfig.NetfilterBackend `env:"AGENT_NETFILTER_BACKEND,default="`
+
+	AgentResources        string `env:"AGENT_RESOURCES,default="`
+	AgentSecurityContext  string `env:"AGENT_SECURITY_CONTEXT,default="`
+	AgentImagePullSecrets string `env:"AGENT_IMAGE_PULL_SECRETS,default="`
+	AgentEphemeral        bool   `env:"AGENT_EPHEMERAL,default=false"`
+
+	AgentIdleTimeout   time.Duration `env:"AGENT_IDLE_TIMEOUT,default="`
+	AgentRemovalWindow TimeWindow    `env:"AGENT_REMOVAL_WINDOW,default="`
 
 	PodCIDRStrategy string `env:"POD_CIDR_STRATEGY,default=auto"`
 	PodCIDRs        string `env:"POD_CIDRS,default="`
@@ -57,6 +72,12 @@ func (e *Env) GeneratorConfig(qualifiedAgentImage string) *agentmap.GeneratorCon
 		QualifiedAgentImage: qualifiedAgentImage,
 		ManagerNamespace:    e.ManagerNamespace,
 		LogLevel:            e.LogLevel,
+		RedirectPolicy:      e.AgentRedirectPolicy,
+		NetfilterBackend:    e.AgentNetfilterBackend,
+		Resources:           e.AgentResources,
+		SecurityContext:     e.AgentSecurityContext,
+		PullSecrets:         e.AgentImagePullSecrets,
+		Ephemeral:           e.AgentEphemeral,
 	}
 }
 
@@ -75,6 +96,13 @@ func (e *Env) GetManagedNamespaces() []string {
 	return nil
 }
 
+func (e *Env) GetLifecycleWebhooks() []string {
+	if lws := e.LifecycleWebhooks; lws != "" {
+		return strings.Split(lws, " ")
+	}
+	return nil
+}
+
 func (e *Env) GetAlsoProxySubnets() ([]*manager.IPNet, error) {
 	return parseRawSubnets(e.DNSAlsoProxySubnets)
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -62,6 +63,16 @@ func TestEnvconfig(t *testing.T) {
 				e.SystemAHost = "app.getambassador.io"
 			},
 		},
+		"idle agents": {
+			Input: map[string]string{
+				"AGENT_IDLE_TIMEOUT":   "72h",
+				"AGENT_REMOVAL_WINDOW": "23:00-05:00",
+			},
+			Output: func(e *managerutil.Env) {
+				e.AgentIdleTimeout = 72 * time.Hour
+				e.AgentRemovalWindow, _ = managerutil.NewTimeWindow("23:00-05:00")
+			},
+		},
 	}
 
 	for tcName, tc := range testcases {
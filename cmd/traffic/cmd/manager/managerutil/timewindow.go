@@ -0,0 +1,71 @@
+package managerutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeWindow is a daily window of time in UTC, such as "01:00-05:00". A window that ends before it starts
+// extends past midnight. The zero TimeWindow contains all times.
+type TimeWindow struct {
+	start time.Duration
+	end   time.Duration
+}
+
+// NewTimeWindow parses a TimeWindow from a string in the form "HH:MM-HH:MM". An empty string
+// yields the zero TimeWindow.
+func NewTimeWindow(s string) (TimeWindow, error) {
+	if s == "" {
+		return TimeWindow{}, nil
+	}
+	ss := strings.Split(s, "-")
+	if len(ss) != 2 {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q, expected HH:MM-HH:MM", s)
+	}
+	var w TimeWindow
+	var err error
+	if w.start, err = parseTimeOfDay(ss[0]); err == nil {
+		w.end, err = parseTimeOfDay(ss[1])
+	}
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q: %w", s, err)
+	}
+	return w, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (w *TimeWindow) EnvDecode(val string) (err error) {
+	*w, err = NewTimeWindow(val)
+	return err
+}
+
+// Contains returns true if the time of day of the given time, in UTC, is within the window.
+func (w TimeWindow) Contains(t time.Time) bool {
+	if w.start == w.end {
+		return true
+	}
+	t = t.UTC()
+	tod := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.start < w.end {
+		return tod >= w.start && tod < w.end
+	}
+	return tod >= w.start || tod < w.end
+}
+
+func (w TimeWindow) String() string {
+	if w.start == w.end {
+		return ""
+	}
+	hm := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d/time.Hour), int(d%time.Hour/time.Minute))
+	}
+	return hm(w.start) + "-" + hm(w.end)
+}
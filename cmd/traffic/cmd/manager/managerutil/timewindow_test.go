@@ -0,0 +1,53 @@
+package managerutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
+)
+
+func TestTimeWindow(t *testing.T) {
+	at := func(hm string) time.Time {
+		tm, err := time.Parse("15:04", hm)
+		require.NoError(t, err)
+		return time.Date(2022, 8, 1, tm.Hour(), tm.Minute(), 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		window  string
+		inside  []string
+		outside []string
+	}{
+		{"", []string{"00:00", "12:00", "23:59"}, nil},
+		{"01:00-05:00", []string{"01:00", "03:30", "04:59"}, []string{"00:59", "05:00", "12:00"}},
+		{"23:00-02:30", []string{"23:00", "23:59", "00:00", "02:29"}, []string{"22:59", "02:30", "12:00"}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.window, func(t *testing.T) {
+			w, err := managerutil.NewTimeWindow(tt.window)
+			require.NoError(t, err)
+			assert.Equal(t, tt.window, w.String())
+			for _, hm := range tt.inside {
+				assert.True(t, w.Contains(at(hm)), hm)
+			}
+			for _, hm := range tt.outside {
+				assert.False(t, w.Contains(at(hm)), hm)
+			}
+		})
+	}
+
+	// The time of day is evaluated in UTC
+	w, err := managerutil.NewTimeWindow("01:00-05:00")
+	require.NoError(t, err)
+	assert.True(t, w.Contains(at("03:00").In(time.FixedZone("UTC+8", 8*3600))))
+
+	for _, bad := range []string{"01:00", "1-5", "01:00-25:00", "01:00-02:00-03:00"} {
+		_, err = managerutil.NewTimeWindow(bad)
+		assert.Error(t, err, bad)
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"net"
 	"os"
 	"path"
-	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,19 +16,24 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	empty "google.golang.org/protobuf/types/known/emptypb"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"github.com/datawire/dlib/dlog"
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/rpc/v2/systema"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/internal/cluster"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/internal/lifecycle"
+	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/internal/mutator"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/internal/state"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/license"
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	"github.com/telepresenceio/telepresence/v2/pkg/a8rcloud"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/tracing"
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 	"github.com/telepresenceio/telepresence/v2/pkg/version"
+	"github.com/telepresenceio/telepresence/v2/pkg/watchstate"
 )
 
 // Clock is the mechanism used by the Manager state to get the current time.
@@ -96,6 +100,7 @@ func NewManager(ctx context.Context) (*Manager, context.Context, error) {
 	ret.ctx = ctx
 	// These are context dependent so build them once the pool is up
 	ret.clusterInfo = cluster.NewInfo(ctx)
+	ctx = lifecycle.WithNotifier(ctx, lifecycle.NewNotifier(managerutil.GetEnv(ctx).GetLifecycleWebhooks()))
 	ret.state = state.NewState(ctx)
 	return ret, ctx, nil
 }
@@ -230,7 +235,8 @@ func (m *Manager) WatchAgents(session *rpc.SessionInfo, stream rpc.Manager_Watch
 				agents = append(agents, agent)
 			}
 			resp := &rpc.AgentInfoSnapshot{
-				Agents: agents,
+				Agents:   agents,
+				Revision: snapshot.Revision(),
 			}
 			if err := stream.Send(resp); err != nil {
 				return err
@@ -243,6 +249,56 @@ func (m *Manager) WatchAgents(session *rpc.SessionInfo, stream rpc.Manager_Watch
 	}
 }
 
+// WatchAgentsSince is like WatchAgentsNS, but sends deltas instead of complete snapshots whenever possible.
+func (m *Manager) WatchAgentsSince(request *rpc.WatchSinceRequest, stream rpc.Manager_WatchAgentsSinceServer) error {
+	ctx := managerutil.WithSessionInfo(stream.Context(), request.Session)
+
+	dlog.Debugf(ctx, "WatchAgentsSince called, since revision %d, namespaces %v", request.SinceRevision, request.Namespaces)
+
+	var filter func(string, *rpc.AgentInfo) bool
+	if nss := request.Namespaces; len(nss) > 0 {
+		filter = func(_ string, a *rpc.AgentInfo) bool {
+			for _, ns := range nss {
+				if ns == a.Namespace {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	snapshotCh := m.state.WatchAgentsSince(ctx, request.SinceRevision, filter)
+	sessionDone, err := m.state.SessionDone(request.Session.GetSessionId())
+	if err != nil {
+		return err
+	}
+	initial := true
+	for {
+		select {
+		case snapshot, ok := <-snapshotCh:
+			if !ok {
+				// The request has been canceled.
+				dlog.Debug(ctx, "WatchAgentsSince request cancelled")
+				return nil
+			}
+			resp := &rpc.AgentInfoSnapshot{Revision: snapshot.Revision()}
+			if initial && !snapshot.Resumed() {
+				resp.SessionAgents = snapshot.State
+			} else {
+				resp.Delta = true
+				resp.SessionAgents, resp.RemovedSessionIds = snapshot.Changes()
+			}
+			initial = false
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		case <-sessionDone:
+			// Manager believes this session has ended.
+			dlog.Debug(ctx, "WatchAgentsSince session cancelled")
+			return nil
+		}
+	}
+}
+
 func infosEqual(a, b *rpc.AgentInfo) bool {
 	ams := a.Mechanisms
 	bms := b.Mechanisms
@@ -347,53 +403,13 @@ func (m *Manager) WatchAgentsNS(request *rpc.AgentsRequest, stream rpc.Manager_W
 // relevant to that client or agent.
 func (m *Manager) WatchIntercepts(session *rpc.SessionInfo, stream rpc.Manager_WatchInterceptsServer) error {
 	ctx := managerutil.WithSessionInfo(stream.Context(), session)
-	sessionID := session.GetSessionId()
 
 	dlog.Debug(ctx, "WatchIntercepts called")
 
-	var sessionDone <-chan struct{}
-	var filter func(id string, info *rpc.InterceptInfo) bool
-	if sessionID == "" {
-		// No sessonID; watch everything
-		filter = func(id string, info *rpc.InterceptInfo) bool {
-			return true
-		}
-	} else {
-		var err error
-		if sessionDone, err = m.state.SessionDone(sessionID); err != nil {
-			return err
-		}
-
-		if agent := m.state.GetAgent(sessionID); agent != nil {
-			// sessionID refers to an agent session
-			filter = func(id string, info *rpc.InterceptInfo) bool {
-				// Don't return intercepts for different agents.
-				if info.Spec.Namespace != agent.Namespace || info.Spec.Agent != agent.Name {
-					dlog.Debugf(ctx, "Intercept mismatch: %s.%s != %s.%s", info.Spec.Agent, info.Spec.Namespace, agent.Name, agent.Namespace)
-					return false
-				}
-				// Don't return intercepts that aren't in a "agent-owned" state.
-				switch info.Disposition {
-				case rpc.InterceptDispositionType_WAITING,
-					rpc.InterceptDispositionType_ACTIVE,
-					rpc.InterceptDispositionType_AGENT_ERROR:
-					// agent-owned state: include the intercept
-					dlog.Debugf(ctx, "Intercept %s.%s valid. Disposition: %s", info.Spec.Agent, info.Spec.Namespace, info.Disposition)
-					return true
-				default:
-					// otherwise: don't return this intercept
-					dlog.Debugf(ctx, "Intercept %s.%s is not in agent-owned state. Disposition: %s", info.Spec.Agent, info.Spec.Namespace, info.Disposition)
-					return false
-				}
-			}
-		} else {
-			// sessionID refers to a client session
-			filter = func(id string, info *rpc.InterceptInfo) bool {
-				return info.ClientSession.SessionId == sessionID
-			}
-		}
+	filter, sessionDone, err := m.interceptsFilter(ctx, session.GetSessionId())
+	if err != nil {
+		return err
 	}
-
 	snapshotCh := m.state.WatchIntercepts(ctx, filter)
 	for {
 		select {
@@ -403,16 +419,10 @@ func (m *Manager) WatchIntercepts(session *rpc.SessionInfo, stream rpc.Manager_W
 				return nil
 			}
 			dlog.Debugf(ctx, "WatchIntercepts sending update")
-			intercepts := make([]*rpc.InterceptInfo, 0, len(snapshot.State))
-			for _, intercept := range snapshot.State {
-				intercepts = append(intercepts, intercept)
-			}
 			resp := &rpc.InterceptInfoSnapshot{
-				Intercepts: intercepts,
+				Intercepts: watchstate.SortedIntercepts(snapshot.State),
+				Revision:   snapshot.Revision(),
 			}
-			sort.Slice(intercepts, func(i, j int) bool {
-				return intercepts[i].Id < intercepts[j].Id
-			})
 			if err := stream.Send(resp); err != nil {
 				dlog.Debugf(ctx, "WatchIntercepts encountered a write error: %v", err)
 				return err
@@ -427,6 +437,95 @@ func (m *Manager) WatchIntercepts(session *rpc.SessionInfo, stream rpc.Manager_W
 	}
 }
 
+// WatchInterceptsSince is like WatchIntercepts, but sends deltas instead of complete snapshots whenever possible.
+func (m *Manager) WatchInterceptsSince(request *rpc.WatchSinceRequest, stream rpc.Manager_WatchInterceptsSinceServer) error {
+	ctx := managerutil.WithSessionInfo(stream.Context(), request.Session)
+
+	dlog.Debugf(ctx, "WatchInterceptsSince called, since revision %d", request.SinceRevision)
+
+	filter, sessionDone, err := m.interceptsFilter(ctx, request.Session.GetSessionId())
+	if err != nil {
+		return err
+	}
+	snapshotCh := m.state.WatchInterceptsSince(ctx, request.SinceRevision, filter)
+	initial := true
+	for {
+		select {
+		case snapshot, ok := <-snapshotCh:
+			if !ok {
+				dlog.Debugf(ctx, "WatchInterceptsSince request cancelled")
+				return nil
+			}
+			resp := &rpc.InterceptInfoSnapshot{Revision: snapshot.Revision()}
+			if initial && !snapshot.Resumed() {
+				resp.Intercepts = watchstate.SortedIntercepts(snapshot.State)
+			} else {
+				var set map[string]*rpc.InterceptInfo
+				set, resp.RemovedIds = snapshot.Changes()
+				resp.Intercepts = watchstate.SortedIntercepts(set)
+				resp.Delta = true
+			}
+			initial = false
+			dlog.Debugf(ctx, "WatchInterceptsSince sending update, revision %d, delta %t", resp.Revision, resp.Delta)
+			if err := stream.Send(resp); err != nil {
+				dlog.Debugf(ctx, "WatchInterceptsSince encountered a write error: %v", err)
+				return err
+			}
+		case <-ctx.Done():
+			dlog.Debugf(ctx, "WatchInterceptsSince context cancelled")
+			return nil
+		case <-sessionDone:
+			dlog.Debugf(ctx, "WatchInterceptsSince session cancelled")
+			return nil
+		}
+	}
+}
+
+// interceptsFilter returns the filter that determines what intercepts that are relevant to the given session,
+// and a channel that is closed when that session ends.
+func (m *Manager) interceptsFilter(ctx context.Context, sessionID string) (func(string, *rpc.InterceptInfo) bool, <-chan struct{}, error) {
+	if sessionID == "" {
+		// No sessonID; watch everything
+		return func(id string, info *rpc.InterceptInfo) bool {
+			return true
+		}, nil, nil
+	}
+
+	sessionDone, err := m.state.SessionDone(sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if agent := m.state.GetAgent(sessionID); agent != nil {
+		// sessionID refers to an agent session
+		return func(id string, info *rpc.InterceptInfo) bool {
+			// Don't return intercepts for different agents.
+			if info.Spec.Namespace != agent.Namespace || info.Spec.Agent != agent.Name {
+				dlog.Debugf(ctx, "Intercept mismatch: %s.%s != %s.%s", info.Spec.Agent, info.Spec.Namespace, agent.Name, agent.Namespace)
+				return false
+			}
+			// Don't return intercepts that aren't in a "agent-owned" state.
+			switch info.Disposition {
+			case rpc.InterceptDispositionType_WAITING,
+				rpc.InterceptDispositionType_ACTIVE,
+				rpc.InterceptDispositionType_AGENT_ERROR:
+				// agent-owned state: include the intercept
+				dlog.Debugf(ctx, "Intercept %s.%s valid. Disposition: %s", info.Spec.Agent, info.Spec.Namespace, info.Disposition)
+				return true
+			default:
+				// otherwise: don't return this intercept
+				dlog.Debugf(ctx, "Intercept %s.%s is not in agent-owned state. Disposition: %s", info.Spec.Agent, info.Spec.Namespace, info.Disposition)
+				return false
+			}
+		}, sessionDone, nil
+	}
+
+	// sessionID refers to a client session
+	return func(id string, info *rpc.InterceptInfo) bool {
+		return state.IsInterceptClient(info, sessionID)
+	}, sessionDone, nil
+}
+
 func (m *Manager) PrepareIntercept(ctx context.Context, request *rpc.CreateInterceptRequest) (*rpc.PreparedIntercept, error) {
 	ctx = managerutil.WithSessionInfo(ctx, request.Session)
 	dlog.Debugf(ctx, "PrepareIntercept called")
@@ -455,7 +554,7 @@ func (m *Manager) CreateIntercept(ctx context.Context, ciReq *rpc.CreateIntercep
 		return nil, status.Errorf(codes.InvalidArgument, val)
 	}
 
-	interceptInfo, err := m.state.AddIntercept(sessionID, m.clusterInfo.GetClusterID(), apiKey, client, spec)
+	interceptInfo, err := m.state.AddIntercept(sessionID, m.clusterInfo.GetClusterID(), apiKey, client, spec, ciReq.GetWait())
 	if err != nil {
 		return nil, err
 	}
@@ -710,6 +809,76 @@ func (m *Manager) GetIntercept(ctx context.Context, request *rpc.GetInterceptReq
 	}
 }
 
+// AttachIntercept attaches a client to an active intercept that is owned by another client.
+func (m *Manager) AttachIntercept(ctx context.Context, req *rpc.AttachInterceptRequest) (*rpc.InterceptInfo, error) {
+	ctx = managerutil.WithSessionInfo(ctx, req.GetSession())
+	sessionID := req.GetSession().GetSessionId()
+
+	dlog.Debugf(ctx, "AttachIntercept called: %s.%s", req.Agent, req.Namespace)
+
+	return m.state.AttachIntercept(sessionID, req.Agent, req.Namespace, req.TargetPort)
+}
+
+// DetachIntercept detaches a client from an intercept that it has attached to.
+func (m *Manager) DetachIntercept(ctx context.Context, req *rpc.DetachInterceptRequest) (*empty.Empty, error) {
+	ctx = managerutil.WithSessionInfo(ctx, req.GetSession())
+	sessionID := req.GetSession().GetSessionId()
+
+	dlog.Debugf(ctx, "DetachIntercept called: %s", req.InterceptId)
+
+	if err := m.state.DetachIntercept(sessionID, req.InterceptId); err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+// HandoverIntercept routes the traffic of an intercept to one of its attached clients, or back to its owner.
+func (m *Manager) HandoverIntercept(ctx context.Context, req *rpc.HandoverInterceptRequest) (*rpc.InterceptInfo, error) {
+	ctx = managerutil.WithSessionInfo(ctx, req.GetSession())
+	sessionID := req.GetSession().GetSessionId()
+
+	dlog.Debugf(ctx, "HandoverIntercept called: %s -> %q", req.Name, req.SessionId)
+
+	if m.state.GetClient(sessionID) == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+	return m.state.HandoverIntercept(sessionID, req.Name, req.SessionId)
+}
+
+// DryRunInject returns the agent config and the pod patch that the agent injector would produce for a workload.
+func (m *Manager) DryRunInject(ctx context.Context, req *rpc.DryRunInjectRequest) (*rpc.DryRunInjectResponse, error) {
+	ctx = managerutil.WithSessionInfo(ctx, req.GetSession())
+	sessionID := req.GetSession().GetSessionId()
+
+	dlog.Debugf(ctx, "DryRunInject called: %s.%s", req.Name, req.Namespace)
+
+	if m.state.GetClient(sessionID) == nil {
+		return nil, status.Errorf(codes.NotFound, "Client session %q not found", sessionID)
+	}
+
+	var wl k8sapi.Workload
+	var err error
+	if len(req.Workload) > 0 {
+		wl, err = mutator.DecodeWorkload(req.Workload, req.Namespace)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	} else {
+		wl, err = k8sapi.GetWorkload(ctx, req.Name, req.Namespace, req.Kind)
+		if err != nil {
+			if k8sErrors.IsNotFound(err) {
+				return nil, status.Errorf(codes.NotFound, "workload %s.%s not found", req.Name, req.Namespace)
+			}
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	config, patch, err := mutator.DryRun(ctx, wl)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &rpc.DryRunInjectResponse{Config: config, Patch: patch}, nil
+}
+
 // ReviewIntercept lets an agent approve or reject an intercept.
 func (m *Manager) ReviewIntercept(ctx context.Context, rIReq *rpc.ReviewInterceptRequest) (*empty.Empty, error) {
 	ctx = managerutil.WithSessionInfo(ctx, rIReq.GetSession())
@@ -729,6 +898,11 @@ func (m *Manager) ReviewIntercept(ctx context.Context, rIReq *rpc.ReviewIntercep
 			return
 		}
 
+		// An intercept that targets a specific pod can only be reviewed by the agent in that pod.
+		if intercept.TargetPod != "" && intercept.TargetPod != agent.PodName {
+			return
+		}
+
 		// Only update intercepts in the waiting state.  Agents race to review an intercept, but we
 		// expect they will always compatible answers.
 		if intercept.Disposition == rpc.InterceptDispositionType_WAITING {
@@ -868,7 +1042,15 @@ func (m *Manager) WatchLogLevel(_ *empty.Empty, stream rpc.Manager_WatchLogLevel
 func (m *Manager) WatchClusterInfo(session *rpc.SessionInfo, stream rpc.Manager_WatchClusterInfoServer) error {
 	ctx := managerutil.WithSessionInfo(stream.Context(), session)
 	dlog.Debugf(ctx, "WatchClusterInfo called")
-	return m.clusterInfo.Watch(ctx, stream)
+	return m.clusterInfo.Watch(ctx, 0, stream)
+}
+
+// WatchClusterInfoSince is like WatchClusterInfo, but doesn't send the current cluster info if its revision
+// is equal to the requested revision.
+func (m *Manager) WatchClusterInfoSince(request *rpc.WatchSinceRequest, stream rpc.Manager_WatchClusterInfoSinceServer) error {
+	ctx := managerutil.WithSessionInfo(stream.Context(), request.Session)
+	dlog.Debugf(ctx, "WatchClusterInfoSince called, since revision %d", request.SinceRevision)
+	return m.clusterInfo.Watch(ctx, request.SinceRevision, stream)
 }
 
 const clientSessionTTL = 24 * time.Hour
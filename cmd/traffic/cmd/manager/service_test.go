@@ -11,7 +11,10 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/proto"
 	empty "google.golang.org/protobuf/types/known/emptypb"
@@ -31,10 +34,13 @@ import (
 	"github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil"
 	mockmanagerutil "github.com/telepresenceio/telepresence/v2/cmd/traffic/cmd/manager/managerutil/mocks"
 	"github.com/telepresenceio/telepresence/v2/pkg/a8rcloud"
+	"github.com/telepresenceio/telepresence/v2/pkg/install"
 	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/version"
 )
 
+const testAdminToken = "s3cr3t"
+
 func dumps(o any) string {
 	bs, _ := json.Marshal(o)
 	return string(bs)
@@ -114,6 +120,7 @@ func TestConnect(t *testing.T) {
 	a.Len(aSnapA.Agents, 1)
 	a.True(proto.Equal(testAgents["hello"], aSnapA.Agents[0]))
 	t.Logf("=> client[alice] agent snapshot = %s", dumps(aSnapA))
+	helloRevision := aSnapA.Revision
 
 	// Demo Deployment comes up with two Pods
 
@@ -186,6 +193,47 @@ func TestConnect(t *testing.T) {
 	a.Len(aSnapA.Agents, 3)
 	t.Logf("=> client[alice] agent snapshot = %s", dumps(aSnapA))
 
+	// Resuming Alice's agent watch from the snapshot with only Hello yields the demo agents. Hello Pro
+	// arrived and departed after that snapshot, so it isn't mentioned.
+
+	resumeCtx, resumeCancel := context.WithCancel(ctx)
+	aliceWAS, err := client.WatchAgentsSince(resumeCtx, &rpc.WatchSinceRequest{Session: aliceSess2, SinceRevision: helloRevision})
+	a.NoError(err)
+	rSnapA, err := aliceWAS.Recv()
+	a.NoError(err)
+	a.True(rSnapA.Delta)
+	a.Equal(aSnapA.Revision, rSnapA.Revision)
+	if a.Len(rSnapA.SessionAgents, 2) {
+		a.True(proto.Equal(testAgents["demo1"], rSnapA.SessionAgents[demo1Sess.SessionId]))
+		a.True(proto.Equal(testAgents["demo2"], rSnapA.SessionAgents[demo2Sess.SessionId]))
+	}
+	a.Empty(rSnapA.RemovedSessionIds)
+	t.Logf("=> client[alice] resumed agent snapshot = %s", dumps(rSnapA))
+	resumeCancel()
+
+	// Resuming from the same revision, but in another namespace, yields nothing
+
+	resumeCtx, resumeCancel = context.WithCancel(ctx)
+	aliceWAS, err = client.WatchAgentsSince(resumeCtx, &rpc.WatchSinceRequest{Session: aliceSess2, SinceRevision: helloRevision, Namespaces: []string{"other"}})
+	a.NoError(err)
+	rSnapA, err = aliceWAS.Recv()
+	a.NoError(err)
+	a.True(rSnapA.Delta)
+	a.Empty(rSnapA.SessionAgents)
+	a.Empty(rSnapA.RemovedSessionIds)
+	resumeCancel()
+
+	// Watching from revision zero yields a complete snapshot
+
+	resumeCtx, resumeCancel = context.WithCancel(ctx)
+	aliceWAS, err = client.WatchAgentsSince(resumeCtx, &rpc.WatchSinceRequest{Session: aliceSess2, Namespaces: []string{"default"}})
+	a.NoError(err)
+	rSnapA, err = aliceWAS.Recv()
+	a.NoError(err)
+	a.False(rSnapA.Delta)
+	a.Len(rSnapA.SessionAgents, 3)
+	resumeCancel()
+
 	// Alice creates an intercept
 
 	spec := &rpc.InterceptSpec{
@@ -211,6 +259,7 @@ func TestConnect(t *testing.T) {
 	a.Len(aSnapI.Intercepts, 1)
 	a.Equal(rpc.InterceptDispositionType_WAITING, aSnapI.Intercepts[0].Disposition)
 	t.Logf("=> client[alice] intercept snapshot = %s", dumps(aSnapI))
+	waitingRevision := aSnapI.Revision
 
 	hSnapI, err = helloWI.Recv()
 	a.NoError(err)
@@ -242,6 +291,20 @@ func TestConnect(t *testing.T) {
 	a.Equal(rpc.InterceptDispositionType_ACTIVE, hSnapI.Intercepts[0].Disposition)
 	t.Logf("=> agent[hello] intercept snapshot = %s", dumps(hSnapI))
 
+	// Resuming Alice's watch from the WAITING snapshot yields only the change
+
+	resumeCtx, resumeCancel = context.WithCancel(ctx)
+	aliceWIS, err := client.WatchInterceptsSince(resumeCtx, &rpc.WatchSinceRequest{Session: aliceSess2, SinceRevision: waitingRevision})
+	a.NoError(err)
+	rSnapI, err := aliceWIS.Recv()
+	a.NoError(err)
+	a.True(rSnapI.Delta)
+	a.Equal(aSnapI.Revision, rSnapI.Revision)
+	a.Len(rSnapI.Intercepts, 1)
+	a.Equal(rpc.InterceptDispositionType_ACTIVE, rSnapI.Intercepts[0].Disposition)
+	t.Logf("=> client[alice] resumed intercept snapshot = %s", dumps(rSnapI))
+	resumeCancel()
+
 	// Creating a duplicate intercept yields an error
 
 	second, err := client.CreateIntercept(ctx, &rpc.CreateInterceptRequest{
@@ -271,6 +334,34 @@ func TestConnect(t *testing.T) {
 	a.Len(hSnapI.Intercepts, 0)
 	t.Logf("=> agent[hello] intercept snapshot = %s", dumps(hSnapI))
 
+	// Resuming Alice's watch from the WAITING snapshot yields the removal
+
+	resumeCtx, resumeCancel = context.WithCancel(ctx)
+	aliceWIS, err = client.WatchInterceptsSince(resumeCtx, &rpc.WatchSinceRequest{Session: aliceSess2, SinceRevision: waitingRevision})
+	a.NoError(err)
+	rSnapI, err = aliceWIS.Recv()
+	a.NoError(err)
+	a.True(rSnapI.Delta)
+	a.Empty(rSnapI.Intercepts)
+	a.Equal([]string{first.Id}, rSnapI.RemovedIds)
+	t.Logf("=> client[alice] resumed intercept snapshot = %s", dumps(rSnapI))
+	resumeCancel()
+
+	// Resuming Bob's watch from the same revision doesn't reveal Alice's intercept
+
+	bobSess, err := client.ArriveAsClient(ctx, testClients["bob"])
+	a.NoError(err)
+	resumeCtx, resumeCancel = context.WithCancel(ctx)
+	bobWIS, err := client.WatchInterceptsSince(resumeCtx, &rpc.WatchSinceRequest{Session: bobSess, SinceRevision: waitingRevision})
+	a.NoError(err)
+	rSnapI, err = bobWIS.Recv()
+	a.NoError(err)
+	a.True(rSnapI.Delta)
+	a.Empty(rSnapI.Intercepts)
+	a.Empty(rSnapI.RemovedIds)
+	t.Logf("=> client[bob] resumed intercept snapshot = %s", dumps(rSnapI))
+	resumeCancel()
+
 	// Removing a bogus intercept yields an error
 
 	_, err = client.RemoveIntercept(ctx, &rpc.RemoveInterceptRequest2{
@@ -286,6 +377,8 @@ func TestConnect(t *testing.T) {
 	a.Error(err)
 	_, err = client.Depart(ctx, aliceSess2)
 	a.NoError(err)
+	_, err = client.Depart(ctx, bobSess)
+	a.NoError(err)
 	_, err = client.Depart(ctx, helloSess)
 	a.NoError(err)
 	_, err = client.Depart(ctx, demo1Sess)
@@ -628,6 +721,91 @@ func TestUpdateIntercept(t *testing.T) {
 	})
 }
 
+func TestAdminAPI(t *testing.T) {
+	dlog.SetFallbackLogger(dlog.WrapTB(t, false))
+	ctx := dlog.NewTestContext(t, false)
+	a := assert.New(t)
+
+	testClients := testdata.GetTestClients(t)
+	testAgents := testdata.GetTestAgents(t)
+
+	conn := getTestClientConn(ctx, t)
+	defer conn.Close()
+	client := rpc.NewManagerClient(conn)
+
+	aliceSess, err := client.ArriveAsClient(ctx, testClients["alice"])
+	a.NoError(err)
+	bobSess, err := client.ArriveAsClient(ctx, testClients["bob"])
+	a.NoError(err)
+	helloSess, err := client.ArriveAsAgent(ctx, testAgents["hello"])
+	a.NoError(err)
+	defer func() {
+		_, _ = client.Depart(ctx, helloSess)
+	}()
+
+	spec := &rpc.InterceptSpec{
+		Name:       "first",
+		Namespace:  "default",
+		Client:     testClients["alice"].Name,
+		Agent:      testAgents["hello"].Name,
+		Mechanism:  "tcp",
+		TargetHost: "asdf",
+		TargetPort: 9876,
+	}
+	first, err := client.CreateIntercept(ctx, &rpc.CreateInterceptRequest{
+		Session:       aliceSess,
+		InterceptSpec: spec,
+	})
+	a.NoError(err)
+
+	// Calls without a valid token are denied
+
+	_, err = client.ListClientSessions(ctx, &empty.Empty{})
+	a.Equal(codes.PermissionDenied, status.Code(err))
+
+	badCtx := metadata.AppendToOutgoingContext(ctx, install.AdminTokenHeader, "wrong")
+	_, err = client.EvictSession(badCtx, bobSess)
+	a.Equal(codes.PermissionDenied, status.Code(err))
+
+	// Calls with a valid token succeed
+
+	adminCtx := metadata.AppendToOutgoingContext(ctx, install.AdminTokenHeader, testAdminToken)
+	snapshot, err := client.ListClientSessions(adminCtx, &empty.Empty{})
+	a.NoError(err)
+	if a.Len(snapshot.Sessions, 2) {
+		alice := snapshot.Sessions[0]
+		a.Equal(aliceSess.SessionId, alice.Session.SessionId)
+		a.Equal(testClients["alice"].Name, alice.Client.Name)
+		a.Empty(alice.Client.ApiKey)
+		if a.Len(alice.Intercepts, 1) {
+			a.Equal(first.Id, alice.Intercepts[0].Id)
+		}
+		a.Equal(bobSess.SessionId, snapshot.Sessions[1].Session.SessionId)
+		a.Len(snapshot.Sessions[1].Intercepts, 0)
+	}
+
+	_, err = client.AdminRemoveIntercept(adminCtx, &rpc.AdminRemoveInterceptRequest{InterceptId: first.Id})
+	a.NoError(err)
+	_, err = client.AdminRemoveIntercept(adminCtx, &rpc.AdminRemoveInterceptRequest{InterceptId: first.Id})
+	a.Equal(codes.NotFound, status.Code(err))
+
+	_, err = client.EvictSession(adminCtx, bobSess)
+	a.NoError(err)
+	_, err = client.EvictSession(adminCtx, bobSess)
+	a.Equal(codes.NotFound, status.Code(err))
+
+	snapshot, err = client.ListClientSessions(adminCtx, &empty.Empty{})
+	a.NoError(err)
+	if a.Len(snapshot.Sessions, 1) {
+		a.Equal(aliceSess.SessionId, snapshot.Sessions[0].Session.SessionId)
+		a.Len(snapshot.Sessions[0].Intercepts, 0)
+	}
+
+	// Bob's session is gone
+	_, err = client.Remain(ctx, &rpc.RemainRequest{Session: bobSess})
+	a.Equal(codes.NotFound, status.Code(err))
+}
+
 func getTestClientConn(ctx context.Context, t *testing.T) *grpc.ClientConn {
 	const bufsize = 64 * 1024
 	var cancel func()
@@ -651,6 +829,7 @@ func getTestClientConn(ctx context.Context, t *testing.T) *grpc.ClientConn {
 		MaxReceiveSize:  resource.Quantity{},
 		PodCIDRStrategy: "environment",
 		PodCIDRs:        "192.168.0.0/16",
+		AdminToken:      testAdminToken,
 	})
 
 	conn, err := grpc.DialContext(ctx, "bufnet", grpc.WithContextDialer(bufDialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
@@ -61,6 +61,10 @@ func (s *connectedSuite) Test_SuccessfullyInterceptsStatefulSet() {
 	s.successfulIntercept("StatefulSet", "ss-echo", "9092")
 }
 
+func (s *connectedSuite) Test_SuccessfullyInterceptsDaemonSet() {
+	s.successfulIntercept("DaemonSet", "ds-echo", "9095")
+}
+
 func (s *connectedSuite) Test_SuccessfullyInterceptsDeploymentWithNoVolumes() {
 	s.successfulIntercept("Deployment", "echo-no-vols", "9093")
 }
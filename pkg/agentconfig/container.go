@@ -1,6 +1,7 @@
 package agentconfig
 
 import (
+	"encoding/json"
 	"strconv"
 	"strings"
 
@@ -38,6 +39,15 @@ func AgentContainer(
 			Value: strconv.Itoa(int(config.APIPort)),
 		})
 	}
+	if config.Ephemeral {
+		// The config is passed in the environment, because an ephemeral container cannot mount the config volume.
+		// The regular traffic-agent gets it too, so that both can be checked against the current config.
+		js, _ := json.Marshal(config)
+		evs = append(evs, core.EnvVar{
+			Name:  EnvAgentConfig,
+			Value: string(js),
+		})
+	}
 	evs = append(evs,
 		core.EnvVar{
 			Name: EnvPrefixAgent + "POD_IP",
@@ -86,13 +96,15 @@ func AgentContainer(
 		efs = nil
 	}
 	return &core.Container{
-		Name:         ContainerName,
-		Image:        config.AgentImage,
-		Args:         []string{"agent"},
-		Ports:        ports,
-		Env:          evs,
-		EnvFrom:      efs,
-		VolumeMounts: mounts,
+		Name:            ContainerName,
+		Image:           config.AgentImage,
+		Args:            []string{"agent"},
+		Ports:           ports,
+		Env:             evs,
+		EnvFrom:         efs,
+		VolumeMounts:    mounts,
+		Resources:       config.Resources.CoreResources(),
+		SecurityContext: config.SecurityContext.DeepCopy(),
 		ReadinessProbe: &core.Probe{
 			ProbeHandler: core.ProbeHandler{
 				Exec: &core.ExecAction{
@@ -103,20 +115,70 @@ func AgentContainer(
 	}
 }
 
-func InitContainer(qualifiedAgentImage string) *core.Container {
+// EphemeralAgentContainer will return a configured traffic-agent that can be added as an ephemeral container
+// to the given running pod. An ephemeral container cannot declare ports, probes, or resources, and it can only
+// mount volumes that the pod already has, so the volume mounts of the agent's own volumes are dropped.
+func EphemeralAgentContainer(pod *core.Pod, config *Sidecar) *core.EphemeralContainer {
+	ac := AgentContainer(pod, config)
+	if ac == nil {
+		return nil
+	}
+	vols := make(map[string]struct{}, len(pod.Spec.Volumes))
+	for _, v := range pod.Spec.Volumes {
+		vols[v.Name] = struct{}{}
+	}
+	mounts := make([]core.VolumeMount, 0, len(ac.VolumeMounts))
+	for _, m := range ac.VolumeMounts {
+		if _, ok := vols[m.Name]; ok {
+			mounts = append(mounts, m)
+		}
+	}
+	return &core.EphemeralContainer{
+		EphemeralContainerCommon: core.EphemeralContainerCommon{
+			Name:            ac.Name,
+			Image:           ac.Image,
+			Args:            ac.Args,
+			Env:             ac.Env,
+			EnvFrom:         ac.EnvFrom,
+			VolumeMounts:    mounts,
+			SecurityContext: ac.SecurityContext,
+		},
+	}
+}
+
+// InitContainer will return a configured init container. It uses the security context of the config, except
+// for RunAsNonRoot and RunAsUser, because the init container must run as root to configure iptables, and it
+// always adds the NET_ADMIN capability.
+func InitContainer(config *Sidecar) *core.Container {
+	sc := config.SecurityContext.DeepCopy()
+	if sc == nil {
+		sc = &core.SecurityContext{}
+	}
+	sc.RunAsNonRoot = nil
+	sc.RunAsUser = nil
+	if sc.Capabilities == nil {
+		sc.Capabilities = &core.Capabilities{}
+	}
+	hasNetAdmin := false
+	for _, c := range sc.Capabilities.Add {
+		if c == "NET_ADMIN" {
+			hasNetAdmin = true
+			break
+		}
+	}
+	if !hasNetAdmin {
+		sc.Capabilities.Add = append(sc.Capabilities.Add, "NET_ADMIN")
+	}
 	return &core.Container{
 		Name:  InitContainerName,
-		Image: qualifiedAgentImage,
+		Image: config.AgentImage,
 		Args:  []string{"agent-init"},
 		VolumeMounts: []core.VolumeMount{{
 			Name:      ConfigVolumeName,
 			MountPath: ConfigMountPoint,
 		}},
-		SecurityContext: &core.SecurityContext{
-			Capabilities: &core.Capabilities{
-				Add: []core.Capability{"NET_ADMIN"},
-			},
-		},
+		Resources:       config.Resources.CoreResources(),
+		SecurityContext: sc,
 	}
 }
 
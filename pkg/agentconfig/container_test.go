@@ -0,0 +1,87 @@
+package agentconfig_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	core "k8s.io/api/core/v1"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+func TestInitContainer_SecurityContext(t *testing.T) {
+	yes := true
+	no := false
+	uid := int64(1000)
+	gid := int64(2000)
+	netAdmin := &core.Capabilities{Add: []core.Capability{"NET_ADMIN"}}
+	tests := []struct {
+		name     string
+		sc       *core.SecurityContext
+		expected *core.SecurityContext
+	}{
+		{
+			"no security context",
+			nil,
+			&core.SecurityContext{Capabilities: netAdmin},
+		},
+		{
+			"non-root user is not inherited",
+			&core.SecurityContext{RunAsNonRoot: &yes, RunAsUser: &uid},
+			&core.SecurityContext{Capabilities: netAdmin},
+		},
+		{
+			"policy settings are inherited",
+			&core.SecurityContext{
+				RunAsNonRoot:             &yes,
+				RunAsUser:                &uid,
+				RunAsGroup:               &gid,
+				AllowPrivilegeEscalation: &no,
+				ReadOnlyRootFilesystem:   &yes,
+				SeccompProfile:           &core.SeccompProfile{Type: core.SeccompProfileTypeRuntimeDefault},
+				SELinuxOptions:           &core.SELinuxOptions{Type: "container_t"},
+			},
+			&core.SecurityContext{
+				RunAsGroup:               &gid,
+				AllowPrivilegeEscalation: &no,
+				ReadOnlyRootFilesystem:   &yes,
+				SeccompProfile:           &core.SeccompProfile{Type: core.SeccompProfileTypeRuntimeDefault},
+				SELinuxOptions:           &core.SELinuxOptions{Type: "container_t"},
+				Capabilities:             netAdmin,
+			},
+		},
+		{
+			"capabilities are merged",
+			&core.SecurityContext{
+				RunAsUser: &uid,
+				Capabilities: &core.Capabilities{
+					Add:  []core.Capability{"NET_RAW"},
+					Drop: []core.Capability{"ALL"},
+				},
+			},
+			&core.SecurityContext{
+				Capabilities: &core.Capabilities{
+					Add:  []core.Capability{"NET_RAW", "NET_ADMIN"},
+					Drop: []core.Capability{"ALL"},
+				},
+			},
+		},
+		{
+			"NET_ADMIN is not duplicated",
+			&core.SecurityContext{Capabilities: &core.Capabilities{Add: []core.Capability{"NET_ADMIN"}}},
+			&core.SecurityContext{Capabilities: netAdmin},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &agentconfig.Sidecar{SecurityContext: tt.sc}
+			orig := tt.sc.DeepCopy()
+			ic := agentconfig.InitContainer(cfg)
+			assert.Equal(t, tt.expected, ic.SecurityContext)
+
+			// The config must not be modified
+			assert.Equal(t, orig, cfg.SecurityContext)
+		})
+	}
+}
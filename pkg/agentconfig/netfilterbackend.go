@@ -0,0 +1,64 @@
+package agentconfig
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NetfilterBackend specifies what the init container uses to configure the netfilter rules that redirect
+// the traffic of intercepted ports to the traffic-agent.
+type NetfilterBackend int
+
+var nbNames = [...]string{"Auto", "IPTables", "NFTables"}
+
+const (
+	// NetfilterAuto tells the init container to use iptables when the node supports it and fall back to
+	// nftables when it doesn't.
+	//
+	// This is the default setting.
+	NetfilterAuto NetfilterBackend = iota
+
+	// NetfilterIPTables tells the init container to always use the iptables and ip6tables binaries.
+	NetfilterIPTables
+
+	// NetfilterNFTables tells the init container to always use the native nftables API, which is
+	// required on nodes that lack the legacy ip_tables kernel modules.
+	NetfilterNFTables
+)
+
+func (nb NetfilterBackend) String() string {
+	return nbNames[nb]
+}
+
+func NewNetfilterBackend(s string) (NetfilterBackend, error) {
+	for i, n := range nbNames {
+		if s == n {
+			return NetfilterBackend(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid NetfilterBackend: %q", s)
+}
+
+func (nb NetfilterBackend) MarshalYAML() (any, error) {
+	return nb.String(), nil
+}
+
+func (nb *NetfilterBackend) EnvDecode(val string) (err error) {
+	var as NetfilterBackend
+	if val == "" {
+		as = NetfilterAuto
+	} else if as, err = NewNetfilterBackend(val); err != nil {
+		return err
+	}
+	*nb = as
+	return nil
+}
+
+func (nb *NetfilterBackend) UnmarshalYAML(node *yaml.Node) (err error) {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	return nb.EnvDecode(s)
+}
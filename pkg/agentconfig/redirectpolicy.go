@@ -0,0 +1,62 @@
+package agentconfig
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedirectPolicy specifies how the traffic that a service sends to a numeric container port is redirected
+// to the traffic-agent.
+type RedirectPolicy int
+
+var rpNames = [...]string{"InitContainer", "ServiceTargetPort"}
+
+const (
+	// RedirectInitContainer tells the injector to add an init container that uses iptables to redirect the
+	// traffic to the traffic-agent. The init container requires the NET_ADMIN capability.
+	//
+	// This is the default setting.
+	RedirectInitContainer RedirectPolicy = iota
+
+	// RedirectServiceTargetPort tells the injector to replace the numeric targetPort of the service port with
+	// the symbolic name of a port of the traffic-agent, so that no privileged init container is needed.
+	// The numeric targetPort is restored when the traffic-agent is removed.
+	RedirectServiceTargetPort
+)
+
+func (rp RedirectPolicy) String() string {
+	return rpNames[rp]
+}
+
+func NewRedirectPolicy(s string) (RedirectPolicy, error) {
+	for i, n := range rpNames {
+		if s == n {
+			return RedirectPolicy(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid RedirectPolicy: %q", s)
+}
+
+func (rp RedirectPolicy) MarshalYAML() (any, error) {
+	return rp.String(), nil
+}
+
+func (rp *RedirectPolicy) EnvDecode(val string) (err error) {
+	var as RedirectPolicy
+	if val == "" {
+		as = RedirectInitContainer
+	} else if as, err = NewRedirectPolicy(val); err != nil {
+		return err
+	}
+	*rp = as
+	return nil
+}
+
+func (rp *RedirectPolicy) UnmarshalYAML(node *yaml.Node) (err error) {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	return rp.EnvDecode(s)
+}
@@ -2,6 +2,7 @@ package agentconfig
 
 import (
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -34,6 +35,9 @@ const (
 	// EnvAPIPort is the port number of the Telepresence API server, when it is enabled
 	EnvAPIPort = "TELEPRESENCE_API_PORT"
 
+	// EnvAgentConfig is the JSON encoded config of a traffic-agent that is added as an ephemeral container
+	EnvAgentConfig = EnvPrefixAgent + "CONFIG"
+
 	DomainPrefix     = "telepresence.getambassador.io/"
 	InjectAnnotation = DomainPrefix + "inject-" + ContainerName
 )
@@ -55,6 +59,10 @@ type Intercept struct {
 	// TargetPortNumeric is set to true unless the servicePort has a symbolic target port
 	TargetPortNumeric bool `json:"targetPortNumeric,omitempty" yaml:"targetPortNumeric,omitempty"`
 
+	// TargetPortRewritten is set to true when the target port of the service port is replaced with the
+	// ContainerPortName of the traffic-agent, or with its AgentPort when the Sidecar is Ephemeral
+	TargetPortRewritten bool `json:"targetPortRewritten,omitempty" yaml:"targetPortRewritten,omitempty"`
+
 	// L4 protocol used by the intercepted port
 	Protocol core.Protocol `json:"protocol,omitempty" yaml:"protocol,omitempty"`
 
@@ -132,4 +140,54 @@ type Sidecar struct {
 
 	// The intercepts managed by the agent
 	Containers []*Container `json:"containers,omitempty" yaml:"containers,omitempty"`
+
+	// Compute resources of the traffic-agent and its init container
+	Resources *ResourceRequirements `json:"resources,omitempty" yaml:"resources,omitempty"`
+
+	// Security context of the traffic-agent and its init container
+	SecurityContext *core.SecurityContext `json:"securityContext,omitempty" yaml:"securityContext,omitempty"`
+
+	// Names of secrets that are added to the imagePullSecrets of the pod
+	PullSecrets []string `json:"pullSecrets,omitempty" yaml:"pullSecrets,omitempty"`
+
+	// If Ephemeral is true, then the traffic-agent is added as an ephemeral container to running pods
+	// and reads this config from its environment
+	Ephemeral bool `json:"ephemeral,omitempty" yaml:"ephemeral,omitempty"`
+
+	// The backend that the init container uses to configure the redirect of intercepted ports
+	NetfilterBackend NetfilterBackend `json:"netfilterBackend,omitempty" yaml:"netfilterBackend,omitempty"`
+}
+
+// ResourceRequirements describes the compute resources of the traffic-agent and its init container. The
+// quantities are kept in their string form, because a resource.Quantity can't be YAML encoded.
+type ResourceRequirements struct {
+	// Limits describes the maximum amount of compute resources allowed
+	Limits map[core.ResourceName]string `json:"limits,omitempty" yaml:"limits,omitempty"`
+
+	// Requests describes the minimum amount of compute resources required
+	Requests map[core.ResourceName]string `json:"requests,omitempty" yaml:"requests,omitempty"`
+}
+
+// CoreResources returns the core.ResourceRequirements that corresponds to this ResourceRequirements. Quantities
+// that cannot be parsed are ignored. They are validated when the Sidecar is generated.
+func (r *ResourceRequirements) CoreResources() core.ResourceRequirements {
+	var rr core.ResourceRequirements
+	if r != nil {
+		rr.Limits = resourceList(r.Limits)
+		rr.Requests = resourceList(r.Requests)
+	}
+	return rr
+}
+
+func resourceList(m map[core.ResourceName]string) core.ResourceList {
+	if len(m) == 0 {
+		return nil
+	}
+	rl := make(core.ResourceList, len(m))
+	for n, v := range m {
+		if q, err := resource.ParseQuantity(v); err == nil {
+			rl[n] = q
+		}
+	}
+	return rl
 }
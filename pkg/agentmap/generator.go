@@ -3,6 +3,7 @@ package agentmap
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -15,9 +16,19 @@ import (
 const (
 	ServicePortAnnotation = agentconfig.DomainPrefix + "inject-service-port"
 	ServiceNameAnnotation = agentconfig.DomainPrefix + "inject-service-name"
+	RunJobAnnotation      = agentconfig.DomainPrefix + "run-job"
 	ManagerAppName        = "traffic-manager"
 	ManagerPortHTTP       = 8081
 	AgentInjectorName     = "agent-injector"
+
+	AgentResourcesAnnotation       = agentconfig.DomainPrefix + "inject-agent-resources"
+	AgentSecurityContextAnnotation = agentconfig.DomainPrefix + "inject-agent-security-context"
+	AgentPullSecretsAnnotation     = agentconfig.DomainPrefix + "inject-agent-image-pull-secrets"
+	RedirectPolicyAnnotation       = agentconfig.DomainPrefix + "inject-redirect-policy"
+	RewrittenTargetPortsAnnotation = agentconfig.DomainPrefix + "rewritten-target-ports"
+	InjectionErrorAnnotation       = agentconfig.DomainPrefix + "agent-injection-error"
+	EphemeralAnnotation            = agentconfig.DomainPrefix + "inject-ephemeral"
+	NetfilterBackendAnnotation     = agentconfig.DomainPrefix + "inject-netfilter-backend"
 )
 
 type GeneratorConfig struct {
@@ -27,6 +38,16 @@ type GeneratorConfig struct {
 	QualifiedAgentImage string
 	ManagerNamespace    string
 	LogLevel            string
+	RedirectPolicy      agentconfig.RedirectPolicy
+	NetfilterBackend    agentconfig.NetfilterBackend
+	Ephemeral           bool
+
+	// Resources and SecurityContext are JSON encoded defaults for the traffic-agent and its init container,
+	// and PullSecrets is a comma separated list of secret names. The corresponding annotations of the pod
+	// take precedence.
+	Resources       string
+	SecurityContext string
+	PullSecrets     string
 }
 
 func GenerateForPod(ctx context.Context, pod *core.Pod, env *GeneratorConfig) (*agentconfig.Sidecar, error) {
@@ -56,57 +77,109 @@ func Generate(ctx context.Context, wl k8sapi.Workload, cfg *GeneratorConfig) (*a
 		}
 	}
 
-	svcs, err := findServicesForPod(ctx, pod, pod.Annotations[ServiceNameAnnotation])
-	if err != nil {
-		return nil, err
-	}
-
 	var ccs []*agentconfig.Container
-	pns := make(map[int32]uint16)
-	portNumber := func(cnPort int32) uint16 {
-		if p, ok := pns[cnPort]; ok {
-			// Port already mapped. Reuse that mapping
+	ephemeral := false
+	nb := agentconfig.NetfilterAuto
+	if wl.GetKind() == "Job" {
+		// A Job receives no traffic. Its agent only provides the environment and the mounts of
+		// its containers, and it never terminates, so it's only injected into the jobs that
+		// are created by "telepresence run-job".
+		if _, ok := pod.Annotations[RunJobAnnotation]; !ok {
+			return nil, fmt.Errorf("the Job %s.%s can only be injected using telepresence run-job", wl.GetName(), wl.GetNamespace())
+		}
+		ccs = appendJobContainerConfigs(pod, ccs)
+	} else {
+		rp, err := redirectPolicy(pod, cfg)
+		if err != nil {
+			return nil, err
+		}
+		svcs, err := findServicesForPod(ctx, pod, pod.Annotations[ServiceNameAnnotation])
+		if err != nil {
+			return nil, err
+		}
+
+		pns := make(map[int32]uint16)
+		portNumber := func(cnPort int32) uint16 {
+			if p, ok := pns[cnPort]; ok {
+				// Port already mapped. Reuse that mapping
+				return p
+			}
+			p := cfg.AgentPort + uint16(len(pns))
+			pns[cnPort] = p
 			return p
 		}
-		p := cfg.AgentPort + uint16(len(pns))
-		pns[cnPort] = p
-		return p
-	}
 
-	for _, svc := range svcs {
-		svcImpl, _ := k8sapi.ServiceImpl(svc)
-		if ccs, err = appendAgentContainerConfigs(svcImpl, pod, portNumber, ccs); err != nil {
+		for _, svc := range svcs {
+			svcImpl, _ := k8sapi.ServiceImpl(svc)
+			if ccs, err = appendAgentContainerConfigs(svcImpl, pod, portNumber, rp, ccs); err != nil {
+				return nil, err
+			}
+		}
+		if ephemeral, err = injectEphemeral(pod, cfg); err != nil {
+			return nil, err
+		}
+		if nb, err = netfilterBackend(pod, cfg); err != nil {
 			return nil, err
 		}
+		if ephemeral {
+			// The services will reference the agent's ports by number, so neither an init container
+			// nor renamed container ports are needed.
+			for _, cc := range ccs {
+				for _, ic := range cc.Intercepts {
+					ic.ContainerPortName = ""
+					ic.TargetPortNumeric = false
+					ic.TargetPortRewritten = true
+				}
+			}
+		}
 	}
 	if len(ccs) == 0 {
 		return nil, fmt.Errorf("found no service with a port that matches a container in pod %s.%s", pod.Name, pod.Namespace)
 	}
 
 	ag := &agentconfig.Sidecar{
-		AgentImage:   cfg.QualifiedAgentImage,
-		AgentName:    wl.GetName(),
-		LogLevel:     cfg.LogLevel,
-		Namespace:    wl.GetNamespace(),
-		WorkloadName: wl.GetName(),
-		WorkloadKind: wl.GetKind(),
-		ManagerHost:  ManagerAppName + "." + cfg.ManagerNamespace,
-		ManagerPort:  ManagerPortHTTP,
-		APIPort:      cfg.APIPort,
-		TracingPort:  cfg.TracingPort,
-		Containers:   ccs,
+		AgentImage:       cfg.QualifiedAgentImage,
+		AgentName:        wl.GetName(),
+		LogLevel:         cfg.LogLevel,
+		Namespace:        wl.GetNamespace(),
+		WorkloadName:     wl.GetName(),
+		WorkloadKind:     wl.GetKind(),
+		ManagerHost:      ManagerAppName + "." + cfg.ManagerNamespace,
+		ManagerPort:      ManagerPortHTTP,
+		APIPort:          cfg.APIPort,
+		TracingPort:      cfg.TracingPort,
+		Containers:       ccs,
+		Ephemeral:        ephemeral,
+		NetfilterBackend: nb,
+	}
+	if err := configureAgentContainer(pod, cfg, ag); err != nil {
+		return nil, fmt.Errorf("unable to configure the %s of pod %s.%s: %w", agentconfig.ContainerName, pod.Name, pod.Namespace, err)
 	}
 	return ag, nil
 }
 
-func appendAgentContainerConfigs(svc *core.Service, pod *core.PodTemplateSpec, portNumber func(int32) uint16, ccs []*agentconfig.Container) ([]*agentconfig.Container, error) {
+func appendAgentContainerConfigs(
+	svc *core.Service,
+	pod *core.PodTemplateSpec,
+	portNumber func(int32) uint16,
+	rp agentconfig.RedirectPolicy,
+	ccs []*agentconfig.Container,
+) ([]*agentconfig.Container, error) {
 	portNameOrNumber := pod.Annotations[ServicePortAnnotation]
 	ports, err := install.FilterServicePorts(svc, portNameOrNumber)
 	if err != nil {
 		return nil, err
 	}
+	rewritten, err := RewrittenTargetPorts(svc)
+	if err != nil {
+		return nil, err
+	}
 nextSvcPort:
 	for _, port := range ports {
+		// A target port that has been rewritten is matched using its original value
+		if tp, ok := rewritten[port.TargetPort.String()]; ok {
+			port.TargetPort = tp
+		}
 		cn, i := findContainerMatchingPort(&port, pod.Spec.Containers)
 		if cn == nil || cn.Name == agentconfig.ContainerName {
 			continue
@@ -138,6 +211,12 @@ nextSvcPort:
 			ContainerPortName: appPort.Name,
 			ContainerPort:     uint16(appPort.ContainerPort),
 		}
+		if ic.TargetPortNumeric && rp == agentconfig.RedirectServiceTargetPort {
+			// The service will reference the agent's port by name, so no init container is needed
+			ic.ContainerPortName = RewrittenTargetPortName(appPort.ContainerPort)
+			ic.TargetPortNumeric = false
+			ic.TargetPortRewritten = true
+		}
 
 		// The container might already have intercepts declared
 		for _, cc := range ccs {
@@ -146,20 +225,64 @@ nextSvcPort:
 				continue nextSvcPort
 			}
 		}
-		var mounts []string
-		if l := len(cn.VolumeMounts); l > 0 {
-			mounts = make([]string, l)
-			for i, vm := range cn.VolumeMounts {
-				mounts[i] = vm.MountPath
-			}
-		}
 		ccs = append(ccs, &agentconfig.Container{
 			Name:       cn.Name,
 			EnvPrefix:  CapsBase26(uint64(len(ccs))) + "_",
 			MountPoint: agentconfig.MountPrefixApp + "/" + cn.Name,
-			Mounts:     mounts,
+			Mounts:     containerMounts(cn),
 			Intercepts: []*agentconfig.Intercept{ic},
 		})
 	}
 	return ccs, nil
 }
+
+// appendJobContainerConfigs appends a config without intercepts for each container in the given pod.
+func appendJobContainerConfigs(pod *core.PodTemplateSpec, ccs []*agentconfig.Container) []*agentconfig.Container {
+	cns := pod.Spec.Containers
+	for i := range cns {
+		cn := &cns[i]
+		if cn.Name == agentconfig.ContainerName {
+			continue
+		}
+		ccs = append(ccs, &agentconfig.Container{
+			Name:       cn.Name,
+			EnvPrefix:  CapsBase26(uint64(len(ccs))) + "_",
+			MountPoint: agentconfig.MountPrefixApp + "/" + cn.Name,
+			Mounts:     containerMounts(cn),
+		})
+	}
+	return ccs
+}
+
+func containerMounts(cn *core.Container) []string {
+	var mounts []string
+	if l := len(cn.VolumeMounts); l > 0 {
+		mounts = make([]string, l)
+		for i, vm := range cn.VolumeMounts {
+			mounts[i] = vm.MountPath
+		}
+	}
+	return mounts
+}
+
+func netfilterBackend(pod *core.PodTemplateSpec, cfg *GeneratorConfig) (agentconfig.NetfilterBackend, error) {
+	if a, ok := pod.Annotations[NetfilterBackendAnnotation]; ok {
+		nb, err := agentconfig.NewNetfilterBackend(a)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value for annotation %s: %w", NetfilterBackendAnnotation, err)
+		}
+		return nb, nil
+	}
+	return cfg.NetfilterBackend, nil
+}
+
+func injectEphemeral(pod *core.PodTemplateSpec, cfg *GeneratorConfig) (bool, error) {
+	if a, ok := pod.Annotations[EphemeralAnnotation]; ok {
+		ephemeral, err := strconv.ParseBool(a)
+		if err != nil {
+			return false, fmt.Errorf("invalid value for annotation %s: %w", EphemeralAnnotation, err)
+		}
+		return ephemeral, nil
+	}
+	return cfg.Ephemeral, nil
+}
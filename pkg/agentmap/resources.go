@@ -0,0 +1,107 @@
+package agentmap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// configureAgentContainer assigns the resources, security context, and image pull secrets of the traffic-agent
+// and its init container to the given Sidecar. A value from an annotation of the pod takes precedence over the
+// default in the GeneratorConfig.
+func configureAgentContainer(pod *core.PodTemplateSpec, cfg *GeneratorConfig, ag *agentconfig.Sidecar) error {
+	value := func(annotation, dflt string) string {
+		if v, ok := pod.Annotations[annotation]; ok {
+			return v
+		}
+		return dflt
+	}
+	var err error
+	if ag.Resources, err = parseResources(value(AgentResourcesAnnotation, cfg.Resources)); err != nil {
+		return err
+	}
+	if ag.SecurityContext, err = parseSecurityContext(value(AgentSecurityContextAnnotation, cfg.SecurityContext)); err != nil {
+		return err
+	}
+	ag.PullSecrets, err = parsePullSecrets(value(AgentPullSecretsAnnotation, cfg.PullSecrets))
+	return err
+}
+
+func parseResources(s string) (*agentconfig.ResourceRequirements, error) {
+	var rr core.ResourceRequirements
+	if ok, err := decodeStrict(s, &rr); !ok {
+		if err != nil {
+			err = fmt.Errorf("invalid resources %q: %w", s, err)
+		}
+		return nil, err
+	}
+	for n, l := range rr.Limits {
+		if r, ok := rr.Requests[n]; ok && r.Cmp(l) > 0 {
+			return nil, fmt.Errorf("invalid resources %q: %s request %s is greater than its limit %s", s, n, r.String(), l.String())
+		}
+	}
+	toStrings := func(rl core.ResourceList) map[core.ResourceName]string {
+		if len(rl) == 0 {
+			return nil
+		}
+		m := make(map[core.ResourceName]string, len(rl))
+		for n, q := range rl {
+			m[n] = q.String()
+		}
+		return m
+	}
+	ar := &agentconfig.ResourceRequirements{
+		Limits:   toStrings(rr.Limits),
+		Requests: toStrings(rr.Requests),
+	}
+	if ar.Limits == nil && ar.Requests == nil {
+		return nil, nil
+	}
+	return ar, nil
+}
+
+func parseSecurityContext(s string) (*core.SecurityContext, error) {
+	var sc core.SecurityContext
+	if ok, err := decodeStrict(s, &sc); !ok {
+		if err != nil {
+			err = fmt.Errorf("invalid security context %q: %w", s, err)
+		}
+		return nil, err
+	}
+	return &sc, nil
+}
+
+func parsePullSecrets(s string) ([]string, error) {
+	var pss []string
+	for _, ps := range strings.Split(s, ",") {
+		if ps = strings.TrimSpace(ps); ps == "" {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(ps); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid image pull secret name %q: %s", ps, strings.Join(errs, ", "))
+		}
+		pss = append(pss, ps)
+	}
+	return pss, nil
+}
+
+// decodeStrict decodes the given JSON string into v and returns true if it was successful. Unknown fields are
+// considered errors. False is returned without an error when the string is empty.
+func decodeStrict(s string, v any) (bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "{}" {
+		return false, nil
+	}
+	d := json.NewDecoder(bytes.NewReader([]byte(s)))
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
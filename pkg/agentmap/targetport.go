@@ -0,0 +1,67 @@
+package agentmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+)
+
+// RewrittenTargetPortName returns the symbolic name that replaces the given numeric target port of a service
+// port when the ServiceTargetPort redirect policy is used.
+func RewrittenTargetPortName(port int32) string {
+	return "tp-" + strconv.Itoa(int(port))
+}
+
+// RewrittenTargetPorts returns the target ports of the given service that have been rewritten, mapped from
+// their new value to their original value. An original value of zero means that the target port wasn't set.
+func RewrittenTargetPorts(svc *core.Service) (map[string]intstr.IntOrString, error) {
+	a, ok := svc.Annotations[RewrittenTargetPortsAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var m map[string]intstr.IntOrString
+	if err := json.Unmarshal([]byte(a), &m); err != nil {
+		return nil, fmt.Errorf("unable to parse annotation %s of service %s.%s: %w", RewrittenTargetPortsAnnotation, svc.Name, svc.Namespace, err)
+	}
+	return m, nil
+}
+
+// SetRewrittenTargetPorts stores the given map of rewritten target ports in the annotations of the given service.
+// The annotation is removed when the map is empty.
+func SetRewrittenTargetPorts(svc *core.Service, m map[string]intstr.IntOrString) {
+	if len(m) == 0 {
+		delete(svc.Annotations, RewrittenTargetPortsAnnotation)
+		return
+	}
+	js, _ := json.Marshal(m)
+	if svc.Annotations == nil {
+		svc.Annotations = make(map[string]string)
+	}
+	svc.Annotations[RewrittenTargetPortsAnnotation] = string(js)
+}
+
+// RewrittenTargetPort returns the target port that replaces the target port of the service port of the given
+// intercept when the intercept's TargetPortRewritten is true. It's the agent's port number when the traffic-agent
+// is ephemeral, because an ephemeral container cannot declare named ports.
+func RewrittenTargetPort(ac *agentconfig.Sidecar, ic *agentconfig.Intercept) intstr.IntOrString {
+	if ac.Ephemeral {
+		return intstr.FromInt(int(ic.AgentPort))
+	}
+	return intstr.FromString(ic.ContainerPortName)
+}
+
+func redirectPolicy(pod *core.PodTemplateSpec, cfg *GeneratorConfig) (agentconfig.RedirectPolicy, error) {
+	if a, ok := pod.Annotations[RedirectPolicyAnnotation]; ok {
+		rp, err := agentconfig.NewRedirectPolicy(a)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value for annotation %s: %w", RedirectPolicyAnnotation, err)
+		}
+		return rp, nil
+	}
+	return cfg.RedirectPolicy, nil
+}
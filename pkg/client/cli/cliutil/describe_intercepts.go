@@ -31,7 +31,7 @@ func describeIntercept(ii *manager.InterceptInfo, volumeMountsPrevented error, d
 	fields = append(fields, kv{"Intercept name", ii.Spec.Name})
 	fields = append(fields, kv{"State", func() string {
 		msg := ""
-		if ii.Disposition > manager.InterceptDispositionType_WAITING {
+		if ii.Disposition > manager.InterceptDispositionType_WAITING && ii.Disposition != manager.InterceptDispositionType_QUEUED {
 			msg += "error: "
 		}
 		msg += ii.Disposition.String()
@@ -41,6 +41,9 @@ func describeIntercept(ii *manager.InterceptInfo, volumeMountsPrevented error, d
 		return msg
 	}()})
 	fields = append(fields, kv{"Workload kind", ii.Spec.WorkloadKind})
+	if ii.TargetPod != "" {
+		fields = append(fields, kv{"Pod", ii.TargetPod})
+	}
 
 	if debug {
 		fields = append(fields, kv{"ID", ii.Id})
@@ -74,6 +77,21 @@ func describeIntercept(ii *manager.InterceptInfo, volumeMountsPrevented error, d
 		return ii.MechanismArgsDesc
 	}()})
 
+	if len(ii.AttachedClients) > 0 {
+		owner := ii.Spec.Client
+		routedTo := owner
+		acs := make([]string, len(ii.AttachedClients))
+		for i, ac := range ii.AttachedClients {
+			acs[i] = fmt.Sprintf("%s (session %s, port %d)", ac.Client, ac.Session.SessionId, ac.TargetPort)
+			if ac.Session.SessionId == ii.RoutedSessionId {
+				routedTo = ac.Client
+			}
+		}
+		fields = append(fields, kv{"Owner", owner})
+		fields = append(fields, kv{"Attached clients", strings.Join(acs, "\n")})
+		fields = append(fields, kv{"Traffic routed to", routedTo})
+	}
+
 	if ii.PreviewDomain != "" {
 		previewURL := ii.PreviewDomain
 		// Right now SystemA gives back domains with the leading "https://", but
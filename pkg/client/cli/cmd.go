@@ -121,7 +121,7 @@ func Command(ctx context.Context) *cobra.Command {
 		"Session Commands": []*cobra.Command{connectCommand(), LoginCommand(), LogoutCommand(), LicenseCommand(), statusCommand(), quitCommand()},
 		"Traffic Commands": []*cobra.Command{listCommand(), leaveCommand(), previewCommand()},
 		"Install Commands": []*cobra.Command{helmCommand()},
-		"Debug Commands":   []*cobra.Command{loglevelCommand(), gatherLogsCommand()},
+		"Debug Commands":   []*cobra.Command{loglevelCommand(), gatherLogsCommand(), captureCommand(), adminCommand()},
 		"Other Commands":   []*cobra.Command{versionCommand(), dashboardCommand(), ClusterIdCommand(), genYAMLCommand(), vpnDiagCommand()},
 	}
 	for name, cmds := range static {
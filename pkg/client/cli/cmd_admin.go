@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"google.golang.org/grpc/metadata"
+	empty "google.golang.org/protobuf/types/known/emptypb"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/cliutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/output"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/install"
+)
+
+type adminInfo struct {
+	token      string
+	kubeConfig *genericclioptions.ConfigFlags
+	kubeFlags  *pflag.FlagSet
+}
+
+func adminCommand() *cobra.Command {
+	ai := &adminInfo{kubeConfig: genericclioptions.NewConfigFlags(false)}
+	cmd := &cobra.Command{
+		Use:  "admin",
+		Args: cobra.NoArgs,
+
+		Short: "Administer client sessions and intercepts of the traffic-manager",
+		Long: `Administer client sessions and intercepts of the traffic-manager.
+
+The admin API must be enabled in the traffic-manager Helm chart (adminApi.enabled=true). The admin token
+is read from the --token flag, the TELEPRESENCE_ADMIN_TOKEN environment variable, or from the
+"` + install.AdminTokenSecretName + `" secret in the traffic-manager's namespace.
+
+The Kubernetes flags apply both to reading the secret and to the connection to the traffic-manager. A
+running session that uses a different cluster configuration must be quit first.`,
+	}
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&ai.token, "token", "", "The admin token. Read from the traffic-manager's admin secret when not given")
+
+	ai.kubeConfig.Namespace = nil // the secret is always read from the traffic-manager's namespace
+	ai.kubeFlags = pflag.NewFlagSet("Kubernetes flags", 0)
+	ai.kubeConfig.AddFlags(ai.kubeFlags)
+	flags.AddFlagSet(ai.kubeFlags)
+
+	cmd.AddCommand(ai.sessionsCommand(), ai.removeInterceptCommand(), ai.evictCommand())
+	return cmd
+}
+
+func (ai *adminInfo) sessionsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:  "sessions",
+		Args: cobra.NoArgs,
+
+		Short: "List all client sessions and their intercepts",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return ai.withAdminManager(cmd, func(ctx context.Context, mgr manager.ManagerClient) error {
+				snapshot, err := mgr.ListClientSessions(ctx, &empty.Empty{})
+				if err != nil {
+					return err
+				}
+				printSessions(snapshot.Sessions, cmd.OutOrStdout(), output.WantsJSONOutput(cmd.Flags()))
+				return nil
+			})
+		},
+	}
+}
+
+func (ai *adminInfo) removeInterceptCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:  "remove-intercept <intercept-id>",
+		Args: cobra.ExactArgs(1),
+
+		Short: "Forcibly remove an intercept owned by any client session",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ai.withAdminManager(cmd, func(ctx context.Context, mgr manager.ManagerClient) error {
+				if _, err := mgr.AdminRemoveIntercept(ctx, &manager.AdminRemoveInterceptRequest{InterceptId: args[0]}); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Intercept %s removed\n", args[0])
+				return nil
+			})
+		},
+	}
+}
+
+func (ai *adminInfo) evictCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:  "evict <session-id>",
+		Args: cobra.ExactArgs(1),
+
+		Short: "Evict a client session and remove all its intercepts",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return ai.withAdminManager(cmd, func(ctx context.Context, mgr manager.ManagerClient) error {
+				if _, err := mgr.EvictSession(ctx, &manager.SessionInfo{SessionId: args[0]}); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Session %s evicted\n", args[0])
+				return nil
+			})
+		},
+	}
+}
+
+// withAdminManager connects to the traffic-manager and calls the given function with a context that
+// carries the admin token.
+func (ai *adminInfo) withAdminManager(cmd *cobra.Command, f func(context.Context, manager.ManagerClient) error) error {
+	token, err := ai.getToken(cmd.Context())
+	if err != nil {
+		return err
+	}
+	// Only pass a request when kubernetes flags are given, so that a running session is used as is.
+	var request *connector.ConnectRequest
+	if kf := kubeFlagMap(ai.kubeFlags); len(kf) > 0 {
+		request = &connector.ConnectRequest{KubeFlags: kf}
+	}
+	return withConnector(cmd, true, request, func(ctx context.Context, _ *connectorState) error {
+		return cliutil.WithManager(ctx, func(ctx context.Context, mgr manager.ManagerClient) error {
+			return f(metadata.AppendToOutgoingContext(ctx, install.AdminTokenHeader, token), mgr)
+		})
+	})
+}
+
+// getToken returns the admin token from the --token flag or the TELEPRESENCE_ADMIN_TOKEN environment
+// variable. When neither is set, the token is read from the admin secret in the traffic-manager's namespace.
+// Reading that secret requires the permissions that the admin role grants.
+func (ai *adminInfo) getToken(ctx context.Context) (string, error) {
+	if ai.token != "" {
+		return ai.token, nil
+	}
+	if token := os.Getenv("TELEPRESENCE_ADMIN_TOKEN"); token != "" {
+		return token, nil
+	}
+	restConfig, err := ai.kubeConfig.ToRESTConfig()
+	if err != nil {
+		return "", err
+	}
+	ki, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return "", err
+	}
+	ns := client.GetEnv(ctx).ManagerNamespace
+	secret, err := ki.CoreV1().Secrets(ns).Get(ctx, install.AdminTokenSecretName, meta.GetOptions{})
+	if err != nil {
+		return "", errcat.User.Newf("unable to read admin token from secret %s.%s: %w", install.AdminTokenSecretName, ns, err)
+	}
+	token := string(secret.Data[install.AdminTokenSecretKey])
+	if token == "" {
+		return "", errcat.User.Newf("secret %s.%s has no %q entry", install.AdminTokenSecretName, ns, install.AdminTokenSecretKey)
+	}
+	return token, nil
+}
+
+func printSessions(sessions []*manager.ClientSessionInfo, stdout io.Writer, jsonOut bool) {
+	if jsonOut {
+		streamerOut, _ := stdout.(output.StructuredStreamer)
+		if streamerOut == nil {
+			panic("writer not output.StructuredStreamer")
+		}
+		if len(sessions) == 0 {
+			streamerOut.StructuredStream([]struct{}{}, nil)
+		} else {
+			streamerOut.StructuredStream(sessions, nil)
+		}
+		return
+	}
+
+	if len(sessions) == 0 {
+		fmt.Fprintln(stdout, "No client sessions")
+		return
+	}
+	for _, s := range sessions {
+		c := s.Client
+		fmt.Fprintf(stdout, "%s: %s@%s (%s %s), last seen %s\n",
+			s.Session.SessionId, c.Name, c.InstallId, c.Product, c.Version, s.LastMarked.AsTime().Local().Format(time.RFC3339))
+		for _, ii := range s.Intercepts {
+			spec := ii.Spec
+			fmt.Fprintf(stdout, "    %s: %s.%s (%s) %s\n", ii.Id, spec.Agent, spec.Namespace, spec.Name, ii.Disposition)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/cliutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/pcap"
+)
+
+type captureArgs struct {
+	outputFile string
+	filter     string
+	maxSize    string
+	duration   time.Duration
+}
+
+func captureCommand() *cobra.Command {
+	ca := &captureArgs{}
+	cmd := &cobra.Command{
+		Use:   "capture",
+		Args:  cobra.NoArgs,
+		Short: "Capture the packets that the root daemon reads from, and writes to, the TUN device",
+		Long: `Capture the packets that the root daemon reads from, and writes to, the TUN device, and
+write them to a file in pcap format. The capture ends when the duration or the size limit
+is reached, or when the command is interrupted. The file can be analyzed using tools like
+Wireshark or tcpdump.
+
+The filter uses a subset of the tcpdump syntax. Supported primitives are
+"[src|dst] host <ip>", "[src|dst] net <cidr>", "[src|dst] port <number>", "ip", "ip6",
+"tcp", "udp", "icmp", and "icmp6". They can be combined using "and", "or", "not", and
+parentheses.`,
+		Example: `Here are a few examples of how you can use this command:
+# Capture all packets during one minute
+telepresence capture -o /tmp/telepresence.pcap
+
+# Capture packets to or from port 8080 of a specific pod, until interrupted
+telepresence capture -o /tmp/telepresence.pcap -d 0 --filter "tcp and host 10.42.0.12 and port 8080"
+
+# Capture DNS traffic and pipe it to Wireshark
+telepresence capture -o - --filter "udp port 53" | wireshark -k -i -
+`,
+		RunE: ca.run,
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&ca.outputFile, "output-file", "o", "telepresence.pcap", `The file to write the captured packets to, or "-" for stdout`)
+	flags.StringVarP(&ca.filter, "filter", "f", "", "An expression that selects the packets to capture")
+	flags.StringVar(&ca.maxSize, "max-size", "10Mi", "The maximum size of the capture (0 means no limit)")
+	flags.DurationVarP(&ca.duration, "duration", "d", time.Minute, "The maximum duration of the capture (0s means no limit)")
+	return cmd
+}
+
+func (ca *captureArgs) run(cmd *cobra.Command, _ []string) error {
+	maxSize, err := resource.ParseQuantity(ca.maxSize)
+	if err != nil {
+		return errcat.User.Newf("invalid max-size %q: %w", ca.maxSize, err)
+	}
+	if _, err = pcap.ParseFilter(ca.filter); err != nil {
+		return errcat.User.New(err)
+	}
+	return cliutil.WithNetwork(cmd.Context(), func(ctx context.Context, daemonClient daemon.DaemonClient) error {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// Ensure that an interrupt ends the capture gracefully.
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt)
+		defer signal.Stop(sigCh)
+		go func() {
+			select {
+			case <-ctx.Done():
+			case <-sigCh:
+				cancel()
+			}
+		}()
+
+		stream, err := daemonClient.Capture(ctx, &daemon.CaptureRequest{
+			Filter:   ca.filter,
+			MaxSize:  maxSize.Value(),
+			Duration: durationpb.New(ca.duration),
+		})
+		if err != nil {
+			return err
+		}
+
+		// The output file is created when the first chunk arrives, so that an error returned by the
+		// daemon doesn't leave an empty file behind.
+		var out io.Writer
+		size := 0
+		for {
+			cd, err := stream.Recv()
+			if err != nil {
+				if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled && ctx.Err() != nil {
+					break
+				}
+				if status.Code(err) == codes.Unavailable {
+					return errcat.User.New("the root daemon has no active session, please use telepresence connect")
+				}
+				return err
+			}
+			if out == nil {
+				if ca.outputFile == "-" {
+					out = cmd.OutOrStdout()
+				} else {
+					f, err := os.Create(ca.outputFile)
+					if err != nil {
+						return errcat.User.New(err)
+					}
+					defer f.Close()
+					out = f
+				}
+			}
+			if _, err = out.Write(cd.Data); err != nil {
+				return err
+			}
+			size += len(cd.Data)
+		}
+		if ca.outputFile != "-" {
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d bytes to %s\n", size, ca.outputFile)
+		}
+		return nil
+	})
+}
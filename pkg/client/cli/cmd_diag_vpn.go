@@ -11,6 +11,7 @@ import (
 	empty "google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/cliutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
 	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/routing"
@@ -151,6 +152,9 @@ func (di *vpnDiagInfo) run(cmd *cobra.Command, _ []string) (err error) {
 		// this could happen for all kinds of reasons, but it makes no sense to go on if it does.
 		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 		defer cancel()
+		if cs.rootD == nil {
+			return errcat.User.New("the VPN diagnostics are not applicable when connected in proxy mode")
+		}
 		clusterSubnets, err := cs.rootD.GetClusterSubnets(ctx, &empty.Empty{})
 		if err != nil {
 			return err
@@ -121,7 +121,7 @@ func (s *listInfo) printList(workloads []*connector.WorkloadInfo, stdout io.Writ
 		if jsonOut {
 			streamerOut.StructuredStream([]struct{}{}, nil)
 		} else {
-			fmt.Fprintln(stdout, "No Workloads (Deployments, StatefulSets, or ReplicaSets)")
+			fmt.Fprintln(stdout, "No Workloads (Deployments, StatefulSets, ReplicaSets, DaemonSets, or Rollouts)")
 		}
 		return
 	}
@@ -136,6 +136,11 @@ func (s *listInfo) printList(workloads []*connector.WorkloadInfo, stdout io.Writ
 		}
 		if workload.NotInterceptableReason != "" {
 			return "not interceptable (traffic-agent not installed): " + workload.NotInterceptableReason
+		} else if workload.AgentInjectionError != "" {
+			if !s.debug {
+				return "not interceptable (traffic-agent not installed), use --debug to see why"
+			}
+			return "not interceptable (traffic-agent not installed): " + workload.AgentInjectionError
 		} else {
 			return "ready to intercept (traffic-agent not yet installed)"
 		}
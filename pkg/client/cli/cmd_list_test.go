@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/connector"
+)
+
+func TestListInfo_printList(t *testing.T) {
+	workloads := []*connector.WorkloadInfo{
+		{Name: "echo", Namespace: "default"},
+		{Name: "job", Namespace: "default", NotInterceptableReason: "unsupported workload kind"},
+		{Name: "broken", Namespace: "default", AgentInjectionError: "no port matches the service"},
+	}
+
+	var out bytes.Buffer
+	(&listInfo{}).printList(workloads, &out, false)
+	assert.Equal(t, ""+
+		"echo  : ready to intercept (traffic-agent not yet installed)\n"+
+		"job   : not interceptable (traffic-agent not installed): unsupported workload kind\n"+
+		"broken: not interceptable (traffic-agent not installed), use --debug to see why\n",
+		out.String())
+
+	out.Reset()
+	(&listInfo{debug: true}).printList(workloads, &out, false)
+	assert.Equal(t, ""+
+		"echo  : ready to intercept (traffic-agent not yet installed)\n"+
+		"job   : not interceptable (traffic-agent not installed): unsupported workload kind\n"+
+		"broken: not interceptable (traffic-agent not installed): no port matches the service\n",
+		out.String())
+}
@@ -33,6 +33,7 @@ type daemonStatus struct {
 	Running           bool             `json:"running,omitempty"`
 	Version           string           `json:"version,omitempty"`
 	APIVersion        int32            `json:"api_version,omitempty"`
+	MTU               int32            `json:"mtu,omitempty"`
 	DNS               *daemonStatusDNS `json:"dns,omitempty"`
 	AlsoProxySubnets  []string         `json:"also_proxy_subnets,omitempty"`
 	NeverProxySubnets []string         `json:"never_proxy_subnets,omitempty"`
@@ -57,6 +58,8 @@ type connectorStatus struct {
 	Error             string                         `json:"error,omitempty"`
 	KubernetesServer  string                         `json:"kubernetes_server,omitempty"`
 	KubernetesContext string                         `json:"kubernetes_context,omitempty"`
+	ProxyAddress      string                         `json:"proxy_address,omitempty"`
+	NetworkNamespace  string                         `json:"network_namespace,omitempty"`
 	Intercepts        []connectStatusIntercept       `json:"intercepts,omitempty"`
 }
 
@@ -126,6 +129,7 @@ func (s *statusInfo) daemonStatus(ctx context.Context) (*daemonStatus, error) {
 		ds.Version = version.Version
 		ds.APIVersion = version.ApiVersion
 		if obc := status.OutboundConfig; obc != nil {
+			ds.MTU = obc.Mtu
 			ds.DNS = &daemonStatusDNS{}
 			dns := obc.Dns
 			if dns.LocalIp != nil {
@@ -206,6 +210,8 @@ func (s *statusInfo) connectorStatus(ctx context.Context) (*connectorStatus, err
 		}
 		cs.KubernetesServer = status.ClusterServer
 		cs.KubernetesContext = status.ClusterContext
+		cs.ProxyAddress = status.ProxyAddress
+		cs.NetworkNamespace = status.NetworkNamespace
 		for _, icept := range status.GetIntercepts().GetIntercepts() {
 			cs.Intercepts = append(cs.Intercepts, connectStatusIntercept{
 				Name:   icept.Spec.Name,
@@ -245,6 +251,9 @@ func (s *statusInfo) printDaemonText(ds *daemonStatus) {
 	if ds.Running {
 		s.println("Root Daemon: Running")
 		s.printf("  Version   : %s (api %d)\n", ds.Version, ds.APIVersion)
+		if ds.MTU > 0 {
+			s.printf("  MTU       : %d\n", ds.MTU)
+		}
 		if ds.DNS != nil {
 			s.printf("  DNS       :\n")
 			if len(ds.DNS.LocalIP) > 0 {
@@ -285,6 +294,12 @@ func (s *statusInfo) printConnectorText(cs *connectorStatus) {
 		}
 		s.printf("  Kubernetes server : %s\n", cs.KubernetesServer)
 		s.printf("  Kubernetes context: %s\n", cs.KubernetesContext)
+		if cs.ProxyAddress != "" {
+			s.printf("  Proxy address     : %s\n", cs.ProxyAddress)
+		}
+		if cs.NetworkNamespace != "" {
+			s.printf("  Network namespace : %s\n", cs.NetworkNamespace)
+		}
 		s.printf("  Intercepts        : %d total\n", len(cs.Intercepts))
 		for _, intercept := range cs.Intercepts {
 			s.printf("    %s: %s\n", intercept.Name, intercept.Client)
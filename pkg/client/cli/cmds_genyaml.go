@@ -15,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/yaml"
 
@@ -142,7 +143,7 @@ func (i *genYAMLInfo) loadWorkload(ctx context.Context) (k8sapi.Workload, error)
 	}
 
 	scheme := runtime.NewScheme()
-	scheme.AddKnownTypes(schema.GroupVersion{Group: apps.GroupName, Version: "v1"}, &apps.StatefulSet{}, &apps.Deployment{}, &apps.ReplicaSet{})
+	scheme.AddKnownTypes(schema.GroupVersion{Group: apps.GroupName, Version: "v1"}, &apps.StatefulSet{}, &apps.Deployment{}, &apps.ReplicaSet{}, &apps.DaemonSet{})
 	codecFactory := serializer.NewCodecFactory(scheme)
 	deserializer := codecFactory.UniversalDeserializer()
 
@@ -152,7 +153,7 @@ func (i *genYAMLInfo) loadWorkload(ctx context.Context) (k8sapi.Workload, error)
 	}
 	wl, err := k8sapi.WrapWorkload(obj)
 	if err != nil {
-		return nil, errcat.User.Newf("unexpected object of kind %s; please pass in a Deployment, ReplicaSet, or StatefulSet", kind)
+		return nil, errcat.User.Newf("unexpected object of kind %s; please pass in a Deployment, ReplicaSet, StatefulSet, or DaemonSet", kind)
 	}
 	if wl.GetNamespace() == "" {
 		if d, ok := k8sapi.DeploymentImpl(wl); ok {
@@ -161,6 +162,8 @@ func (i *genYAMLInfo) loadWorkload(ctx context.Context) (k8sapi.Workload, error)
 			r.Namespace = i.namespace
 		} else if s, ok := k8sapi.StatefulSetImpl(wl); ok {
 			s.Namespace = i.namespace
+		} else if ds, ok := k8sapi.DaemonSetImpl(wl); ok {
+			ds.Namespace = i.namespace
 		}
 	}
 	return wl, nil
@@ -224,8 +227,13 @@ func (i *genYAMLInfo) withK8sInterface(ctx context.Context, flagMap map[string]s
 		}
 	}
 	cs, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return ctx, err
+	}
+	ctx = k8sapi.WithK8sInterface(ctx, cs)
+	di, err := dynamic.NewForConfig(restConfig)
 	if err == nil {
-		ctx = k8sapi.WithK8sInterface(ctx, cs)
+		ctx = k8sapi.WithDynamicInterface(ctx, di)
 	}
 	return ctx, err
 }
@@ -404,7 +412,7 @@ func (g *genInitContainerInfo) run(cmd *cobra.Command, kubeFlags map[string]stri
 	for _, cc := range cm.Containers {
 		for _, ic := range cc.Intercepts {
 			if ic.Headless || ic.TargetPortNumeric {
-				return g.writeObjToOutput(agentconfig.InitContainer(cm.AgentImage))
+				return g.writeObjToOutput(agentconfig.InitContainer(cm))
 			}
 		}
 	}
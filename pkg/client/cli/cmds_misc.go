@@ -14,6 +14,7 @@ import (
 	"github.com/telepresenceio/telepresence/rpc/v2/connector"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/cliutil"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 )
@@ -55,6 +56,9 @@ func ClusterIdCommand() *cobra.Command {
 func connectCommand() *cobra.Command {
 	var dnsIP string
 	var mappedNamespaces []string
+	var proxyAddress string
+	var proxyAllowRemote bool
+	var netns bool
 
 	kubeFlags := pflag.NewFlagSet("Kubernetes flags", 0)
 	cmd := &cobra.Command{
@@ -65,6 +69,21 @@ func connectCommand() *cobra.Command {
 			request := &connector.ConnectRequest{
 				KubeFlags:        kubeFlagMap(kubeFlags),
 				MappedNamespaces: mappedNamespaces,
+				ProxyAddress:     proxyAddress,
+				ProxyAllowRemote: proxyAllowRemote,
+				Netns:            netns,
+			}
+
+			if netns {
+				if len(args) == 0 {
+					return errcat.User.New("--netns requires a command to run")
+				}
+				if proxyAddress != "" {
+					return errcat.User.New("--netns cannot be combined with --proxy-address")
+				}
+				return withConnector(cmd, false, request, func(ctx context.Context, cs *connectorState) error {
+					return runInNetworkNamespace(ctx, cs.NetworkNamespace, args)
+				})
 			}
 
 			if len(args) == 0 {
@@ -94,6 +113,22 @@ func connectCommand() *cobra.Command {
 		"mapped-namespaces", nil, ``+
 			`Comma separated list of namespaces considered by DNS resolver and NAT for outbound connections. `+
 			`Defaults to all namespaces`)
+	nwFlags.StringVar(&proxyAddress,
+		"proxy-address", "", ``+
+			`Connect without the root daemon and instead serve a SOCKS5 and HTTP CONNECT proxy to the cluster on `+
+			`the given address, e.g. "localhost:1080". No TUN device, routes, or DNS configuration are used, so `+
+			`sudo isn't required. Only loopback addresses are accepted unless --proxy-allow-remote is set`)
+	nwFlags.BoolVar(&proxyAllowRemote,
+		"proxy-allow-remote", false, ``+
+			`Allow a --proxy-address that isn't a loopback address. The proxy doesn't authenticate its clients, `+
+			`so everyone that can reach the address can reach the cluster`)
+	if runtime.GOOS == "linux" {
+		nwFlags.BoolVar(&netns,
+			"netns", false, ``+
+				`Route the cluster through a TUN device in a dedicated network namespace instead of the host's `+
+				`network, and run the given command in that namespace. Only the command's process tree sees `+
+				`the cluster`)
+	}
 	flags.AddFlagSet(nwFlags)
 
 	kubeConfig := genericclioptions.NewConfigFlags(false)
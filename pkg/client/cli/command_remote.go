@@ -46,7 +46,7 @@ func runRemote(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	return cliutil.WithNetwork(cmd.Context(), func(ctx context.Context, _ daemon.DaemonClient) error {
+	return withOptionalNetwork(cmd.Context(), nil, func(ctx context.Context, _ daemon.DaemonClient) error {
 		return cliutil.WithConnector(ctx, func(ctx context.Context, connectorClient connector.ConnectorClient) error {
 			ctx, cancel := context.WithCancel(dcontext.WithSoftness(ctx))
 
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/datawire/dlib/dtime"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif"
+)
+
+// runInNetworkNamespace runs the given command in the given network namespace, as the current user. The
+// namespace is created by the root daemon, and its resolv.conf gets a nameserver once the daemon's DNS
+// server is up. The command is started when that has happened, so that it never sees a namespace without
+// DNS.
+func runInNetworkNamespace(ctx context.Context, netns string, args []string) error {
+	if netns == "" {
+		return errcat.User.New("the current session has no network namespace; quit and reconnect using --netns")
+	}
+	exe, err := exec.LookPath(args[0])
+	if err != nil {
+		return errcat.User.New(err)
+	}
+	if err = awaitNamespaceDNS(ctx, netns, 10*time.Second); err != nil {
+		return err
+	}
+	nsArgs := []string{"netns", "exec", netns}
+	if proc.IsAdmin() {
+		nsArgs = append(nsArgs, exe)
+		return proc.Run(ctx, nil, "ip", append(nsArgs, args[1:]...)...)
+	}
+
+	// Entering a network namespace requires root, so the command is started using sudo, and then
+	// given back the credentials of the current user.
+	nsArgs = append([]string{"--preserve-env", "ip"}, nsArgs...)
+	nsArgs = append(nsArgs, namespaceUserArgs(os.Getuid(), os.Getgid(), exe, args[1:])...)
+	return proc.Run(ctx, nil, "sudo", nsArgs...)
+}
+
+// namespaceUserArgs returns the arguments that make setpriv run the given executable using the given
+// user and group.
+func namespaceUserArgs(uid, gid int, exe string, args []string) []string {
+	return append([]string{
+		"setpriv",
+		"--reuid=" + strconv.Itoa(uid),
+		"--regid=" + strconv.Itoa(gid),
+		"--init-groups",
+		"--",
+		exe,
+	}, args...)
+}
+
+// awaitNamespaceDNS waits until the resolv.conf of the given network namespace has a nameserver.
+func awaitNamespaceDNS(ctx context.Context, netns string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		if data, err := os.ReadFile(vif.NamespaceResolvConf(netns)); err == nil && bytes.Contains(data, []byte("nameserver ")) {
+			return nil
+		}
+		dtime.SleepWithContext(ctx, 100*time.Millisecond)
+		if ctx.Err() != nil {
+			return errcat.User.Newf("timeout waiting for DNS in network namespace %s", netns)
+		}
+	}
+}
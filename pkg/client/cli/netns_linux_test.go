@@ -0,0 +1,13 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_namespaceUserArgs(t *testing.T) {
+	assert.Equal(t,
+		[]string{"setpriv", "--reuid=1000", "--regid=100", "--init-groups", "--", "/usr/bin/curl", "-s", "http://echo"},
+		namespaceUserArgs(1000, 100, "/usr/bin/curl", []string{"-s", "http://echo"}))
+}
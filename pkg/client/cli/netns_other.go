@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package cli
+
+import (
+	"context"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
+)
+
+func runInNetworkNamespace(_ context.Context, _ string, _ []string) error {
+	return errcat.User.New("network namespaces are only supported on Linux")
+}
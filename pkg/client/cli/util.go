@@ -41,8 +41,11 @@ type connectorState struct {
 //    them down when it's done.  If they were already running, it will leave them running.)
 //
 //  - Makes the connector.Connect gRPC call to set up networking
+//
+// The daemon is neither started nor used when the connector is, or is about to be, connected in proxy mode,
+// and the rootD of the connectorState is then nil.
 func withConnector(cmd *cobra.Command, retain bool, request *connector.ConnectRequest, f func(context.Context, *connectorState) error) error {
-	return cliutil.WithNetwork(cmd.Context(), func(ctx context.Context, daemonClient daemon.DaemonClient) error {
+	return withOptionalNetwork(cmd.Context(), request, func(ctx context.Context, daemonClient daemon.DaemonClient) error {
 		return cliutil.WithConnector(ctx, func(ctx context.Context, connectorClient connector.ConnectorClient) error {
 			didConnect, connInfo, err := connect(ctx, connectorClient, cmd.OutOrStdout(), request)
 			if err != nil {
@@ -61,6 +64,27 @@ func withConnector(cmd *cobra.Command, retain bool, request *connector.ConnectRe
 	})
 }
 
+// withOptionalNetwork calls cliutil.WithNetwork unless the given request, or the session of an already running
+// connector, uses proxy mode, in which case the root daemon isn't needed and f is called with a nil daemon.DaemonClient.
+func withOptionalNetwork(ctx context.Context, request *connector.ConnectRequest, f func(context.Context, daemon.DaemonClient) error) error {
+	if request != nil && request.ProxyAddress != "" || isProxyConnected(ctx) {
+		return f(ctx, nil)
+	}
+	return cliutil.WithNetwork(ctx, f)
+}
+
+// isProxyConnected returns true if the connector is running and connected in proxy mode.
+func isProxyConnected(ctx context.Context) bool {
+	proxied := false
+	_ = cliutil.WithStartedConnector(ctx, false, func(ctx context.Context, connectorClient connector.ConnectorClient) error {
+		if ci, err := connectorClient.Status(ctx, &empty.Empty{}); err == nil {
+			proxied = ci.ProxyAddress != ""
+		}
+		return nil
+	})
+	return proxied
+}
+
 func addKubeconfigEnv(cr *connector.ConnectRequest) {
 	// Certain options' default are bound to the connector daemon process; this is notably true of the kubeconfig file(s) to use,
 	// and since those files can be specified, both as a --kubeconfig flag and in the KUBECONFIG setting, and since the flag won't
@@ -35,6 +35,7 @@ type Config struct {
 	TelepresenceAPI TelepresenceAPI `json:"telepresenceAPI,omitempty" yaml:"telepresenceAPI,omitempty"`
 	Daemons         Daemons         `json:"daemons,omitempty" yaml:"daemons,omitempty"`
 	Intercept       Intercept       `json:"intercept,omitempty" yaml:"intercept,omitempty"`
+	Network         Network         `json:"network,omitempty" yaml:"network,omitempty"`
 }
 
 // Merge merges this instance with the non-zero values of the given argument. The argument values take priority.
@@ -47,6 +48,7 @@ func (c *Config) Merge(o *Config) {
 	c.TelepresenceAPI.merge(&o.TelepresenceAPI)
 	c.Daemons.merge(&o.Daemons)
 	c.Intercept.merge(&o.Intercept)
+	c.Network.merge(&o.Network)
 }
 
 // Watch uses a file system watcher that receives events when the configuration changes
@@ -129,6 +131,8 @@ func (c *Config) UnmarshalYAML(node *yaml.Node) error {
 			err = ms[i+1].Decode(&c.Daemons)
 		case kv == "intercept":
 			err = ms[i+1].Decode(&c.Intercept)
+		case kv == "network":
+			err = ms[i+1].Decode(&c.Network)
 		case parseContext != nil:
 			dlog.Warn(parseContext, withLoc(fmt.Sprintf("unknown key %q", kv), ms[i]))
 		}
@@ -523,6 +527,7 @@ type Images struct {
 	PrivateRegistry        string `json:"registry,omitempty" yaml:"registry,omitempty"`
 	PrivateAgentImage      string `json:"agentImage,omitempty" yaml:"agentImage,omitempty"`
 	PrivateWebhookRegistry string `json:"webhookRegistry,omitempty" yaml:"webhookRegistry,omitempty"`
+	PrivatePauseImage      string `json:"pauseImage,omitempty" yaml:"pauseImage,omitempty"`
 }
 
 // UnmarshalYAML parses the images YAML
@@ -549,6 +554,8 @@ func (img *Images) UnmarshalYAML(node *yaml.Node) (err error) {
 		case "webhookAgentImage":
 			dlog.Warn(parseContext, withLoc(fmt.Sprintf(`deprecated key %q, please use "agentImage" instead`, kv), ms[i]))
 			img.PrivateAgentImage = v.Value
+		case "pauseImage":
+			img.PrivatePauseImage = v.Value
 		default:
 			if parseContext != nil {
 				dlog.Warn(parseContext, withLoc(fmt.Sprintf("unknown key %q", kv), ms[i]))
@@ -568,6 +575,9 @@ func (img *Images) merge(o *Images) {
 	if o.PrivateWebhookRegistry != "" {
 		img.PrivateWebhookRegistry = o.PrivateWebhookRegistry
 	}
+	if o.PrivatePauseImage != "" {
+		img.PrivatePauseImage = o.PrivatePauseImage
+	}
 }
 
 func (img *Images) Registry(c context.Context) string {
@@ -591,6 +601,14 @@ func (img *Images) AgentImage(c context.Context) string {
 	return GetEnv(c).AgentImage
 }
 
+// PauseImage returns the image used for the suspended app containers of jobs started with run-job.
+func (img *Images) PauseImage(c context.Context) string {
+	if img.PrivatePauseImage != "" {
+		return img.PrivatePauseImage
+	}
+	return GetEnv(c).PauseImage
+}
+
 type Cloud struct {
 	SkipLogin       bool          `json:"skipLogin,omitempty" yaml:"skipLogin,omitempty"`
 	RefreshMessages time.Duration `json:"refreshMessages,omitempty" yaml:"refreshMessages,omitempty"`
@@ -798,6 +816,26 @@ func (ic Intercept) MarshalYAML() (any, error) {
 	return im, nil
 }
 
+type Network struct {
+	// TCPStack is the TCP/IP stack that the root daemon uses to terminate TCP connections
+	TCPStack TCPStack `json:"tcpStack,omitempty" yaml:"tcpStack,omitempty"`
+
+	// MTU is the largest packet that the root daemon accepts from the TUN device. Larger packets are
+	// rejected with an ICMP "fragmentation needed" or "packet too big" message, and the maximum segment
+	// size of TCP connections is clamped accordingly. Zero means the MTU of the interface that routes
+	// to the API server, or 1500 if that can't be determined.
+	MTU int `json:"mtu,omitempty" yaml:"mtu,omitempty"`
+}
+
+func (n *Network) merge(o *Network) {
+	if o.TCPStack != LegacyTCPStack {
+		n.TCPStack = o.TCPStack
+	}
+	if o.MTU != 0 {
+		n.MTU = o.MTU
+	}
+}
+
 var parseContext context.Context
 
 type parsedFile struct{}
@@ -869,6 +907,7 @@ func GetDefaultConfig() Config {
 		Intercept: Intercept{
 			DefaultPort: defaultInterceptDefaultPort,
 		},
+		Network: Network{},
 	}
 }
 
@@ -41,11 +41,15 @@ logLevels:
 images:
   registry: testregistry.io
   agentImage: ambassador-telepresence-agent-image:0.0.2
+  pauseImage: testregistry.io/pause:3.9
 telepresenceAPI:
   port: 1234
 intercept:
   appProtocolStrategy: portName
   defaultPort: 9080
+network:
+  tcpStack: gvisor
+  mtu: 1400
 `,
 	}
 
@@ -81,9 +85,12 @@ intercept:
 
 	assert.Equal(t, "testregistry.io", cfg.Images.PrivateRegistry)                             // from user
 	assert.Equal(t, "ambassador-telepresence-agent-image:0.0.2", cfg.Images.PrivateAgentImage) // from user
+	assert.Equal(t, "testregistry.io/pause:3.9", cfg.Images.PauseImage(c))                     // from user
 	assert.Equal(t, 1234, cfg.TelepresenceAPI.Port)                                            // from user
 	assert.Equal(t, k8sapi.PortName, cfg.Intercept.AppProtocolStrategy)                        // from user
 	assert.Equal(t, 9080, cfg.Intercept.DefaultPort)                                           // from user
+	assert.Equal(t, GVisorTCPStack, cfg.Network.TCPStack)                                      // from user
+	assert.Equal(t, 1400, cfg.Network.MTU)                                                     // from user
 }
 
 func Test_ConfigMarshalYAML(t *testing.T) {
@@ -100,6 +107,7 @@ func Test_ConfigMarshalYAML(t *testing.T) {
 	cfg.TelepresenceAPI.Port = 4567
 	cfg.Intercept.AppProtocolStrategy = k8sapi.PortName
 	cfg.Intercept.DefaultPort = 9080
+	cfg.Network.TCPStack = GVisorTCPStack
 	cfgBytes, err := yaml.Marshal(cfg)
 	require.NoError(t, err)
 
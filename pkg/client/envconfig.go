@@ -26,6 +26,9 @@ type Env struct {
 	// This environment variable becomes the default for the images.agentImage and images.webhookAgentImage
 	AgentImage string `env:"TELEPRESENCE_AGENT_IMAGE,default="`
 
+	// This environment variable becomes the default for the images.pauseImage
+	PauseImage string `env:"TELEPRESENCE_PAUSE_IMAGE,default=registry.k8s.io/pause:3.7"`
+
 	lookuper envconfig.Lookuper
 }
 
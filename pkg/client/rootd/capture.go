@@ -0,0 +1,140 @@
+package rootd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/pcap"
+)
+
+const (
+	// captureSnapLen is large enough to never truncate a packet read from, or written to, the TUN device.
+	captureSnapLen = 0x10000
+
+	// captureQueueSize is the number of packets that can wait to be written to the pcap stream. Packets
+	// are dropped rather than blocking the TUN device when the queue is full.
+	captureQueueSize = 0x400
+
+	captureChunkSize = 0x8000
+)
+
+type capturedPacket struct {
+	ts   time.Time
+	data []byte
+}
+
+// captureWriter is an io.Writer that sends what's written to it as CaptureData on a stream.
+type captureWriter struct {
+	stream rpc.Daemon_CaptureServer
+}
+
+func (w captureWriter) Write(data []byte) (int, error) {
+	if err := w.stream.Send(&rpc.CaptureData{Data: data}); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (d *service) Capture(rq *rpc.CaptureRequest, stream rpc.Daemon_CaptureServer) error {
+	d.sessionLock.RLock()
+	s := d.session
+	sessionCtx := d.sessionContext
+	d.sessionLock.RUnlock()
+	if s == nil {
+		return status.Error(codes.Unavailable, "no active session")
+	}
+
+	// The capture ends when the session ends, or when the client cancels the stream.
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-sessionCtx.Done():
+			cancel()
+		}
+	}()
+	return s.capture(ctx, rq, captureWriter{stream: stream})
+}
+
+// capture writes the packets that are read from, and written to, the TUN device to the given io.Writer
+// in pcap format until the context is cancelled, or the limits of the request are reached.
+func (s *session) capture(ctx context.Context, rq *rpc.CaptureRequest, out io.Writer) error {
+	filter, err := pcap.ParseFilter(rq.Filter)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if !atomic.CompareAndSwapInt32(&s.capturing, 0, 1) {
+		return status.Error(codes.AlreadyExists, "a capture is already in progress")
+	}
+	defer atomic.StoreInt32(&s.capturing, 0)
+
+	if rq.Duration != nil && rq.Duration.AsDuration() > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rq.Duration.AsDuration())
+		defer cancel()
+	}
+
+	bw := bufio.NewWriterSize(out, captureChunkSize)
+	pw, err := pcap.NewWriter(bw, captureSnapLen)
+	if err == nil {
+		// Send the file header right away so that the client knows that the capture has started.
+		err = bw.Flush()
+	}
+	if err != nil {
+		return err
+	}
+	size := int64(pcap.FileHeaderLen)
+
+	packets := make(chan capturedPacket, captureQueueSize)
+	dropped := int64(0)
+	s.dev.SetPacketCapture(func(data []byte) {
+		if !filter.Match(data) {
+			return
+		}
+		cp := capturedPacket{ts: time.Now(), data: make([]byte, len(data))}
+		copy(cp.data, data)
+		select {
+		case packets <- cp:
+		default:
+			atomic.AddInt64(&dropped, 1)
+		}
+	})
+	defer s.dev.SetPacketCapture(nil)
+
+	dlog.Infof(ctx, "Packet capture started with filter %q", rq.Filter)
+	count := 0
+	defer func() {
+		dlog.Infof(ctx, "Packet capture ended after %d packets, %d bytes. %d packets were dropped", count, size, atomic.LoadInt64(&dropped))
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return bw.Flush()
+		case cp := <-packets:
+			rl := int64(pw.RecordLen(len(cp.data)))
+			if rq.MaxSize > 0 && size+rl > rq.MaxSize {
+				return bw.Flush()
+			}
+			if err = pw.WritePacket(cp.ts, cp.data); err != nil {
+				return err
+			}
+			size += rl
+			count++
+			if len(packets) == 0 {
+				// Don't keep the client waiting when there's nothing more to write for now.
+				if err = bw.Flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -194,7 +194,11 @@ func (s *Server) resolveInCluster(c context.Context, query string) (results []ne
 	if !s.shouldDoClusterLookup(query) {
 		return nil, nil
 	}
+	return s.lookupInCluster(c, query)
+}
 
+// lookupInCluster looks up the given fully qualified query in the cluster.
+func (s *Server) lookupInCluster(c context.Context, query string) ([]net.IP, error) {
 	// Give the cluster lookup a reasonable timeout.
 	c, cancel := context.WithTimeout(c, s.config.LookupTimeout.AsDuration())
 	defer cancel()
@@ -21,6 +21,10 @@ import (
 var errResolveDNotConfigured = errors.New("resolved not configured")
 
 func (s *Server) Worker(c context.Context, dev *vif.Device, configureDNS func(net.IP, *net.UDPAddr)) error {
+	if ns := dev.NetworkNamespace(); ns != "" {
+		// The host's DNS configuration must be left untouched.
+		return s.runNamespaceServer(dgroup.WithGoroutineName(c, "/netns"), dev, ns, configureDNS)
+	}
 	if runningInDocker() {
 		// Don't bother with systemd-resolved when running in a docker container
 		return s.runOverridingServer(dgroup.WithGoroutineName(c, "/docker"), dev)
@@ -94,6 +98,74 @@ func (s *Server) resolveInSearch(c context.Context, query string) ([]net.IP, err
 	return s.resolveInCluster(c, query)
 }
 
+// runNamespaceServer serves DNS to the processes of the given network namespace. The namespace's resolv.conf
+// is pointed at the cluster's DNS IP, and requests sent to that IP are intercepted by the TUN device and
+// passed on to this server.
+func (s *Server) runNamespaceServer(c context.Context, dev *vif.Device, ns string, configureDNS func(net.IP, *net.UDPAddr)) error {
+	dnsIP := net.IP(s.config.RemoteIp)
+	if dnsIP == nil {
+		return errors.New("unable to determine the cluster's DNS IP")
+	}
+	listener, err := newLocalUDPListener(c)
+	if err != nil {
+		return err
+	}
+	dnsResolverAddr, err := splitToUDPAddr(listener.LocalAddr())
+	if err != nil {
+		return err
+	}
+	configureDNS(dnsIP, dnsResolverAddr)
+	defer configureDNS(nil, nil)
+
+	resolvConf := vif.NamespaceResolvConf(ns)
+	writeResolvConf := func(c context.Context, paths []string, _ *vif.Device) error {
+		// The file is bind mounted by "ip netns exec", so it must be rewritten in place rather than replaced.
+		if err := os.WriteFile(resolvConf, []byte(namespaceResolvConf(dnsIP, paths)), 0644); err != nil {
+			return err
+		}
+		dlog.Debugf(c, "%s set to nameserver %s, search %v", resolvConf, dnsIP, paths)
+		return nil
+	}
+	if err = writeResolvConf(c, nil, dev); err != nil {
+		return err
+	}
+
+	g := dgroup.NewGroup(c, dgroup.GroupConfig{})
+	g.Go("Server", func(c context.Context) error {
+		s.processSearchPaths(g, writeResolvConf, dev)
+		return s.Run(c, make(chan struct{}), []net.PacketConn{listener}, nil, s.resolveInNamespace)
+	})
+	return g.Wait()
+}
+
+// namespaceResolvConf returns the contents of the resolv.conf of a network namespace that uses the given
+// nameserver and the paths that contain a dot as search paths.
+func namespaceResolvConf(dnsIP net.IP, paths []string) string {
+	search := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if strings.ContainsRune(path, '.') {
+			search = append(search, path)
+		}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "nameserver %s\n", dnsIP)
+	if len(search) > 0 {
+		fmt.Fprintf(&sb, "search %s\n", strings.Join(search, " "))
+	}
+	return sb.String()
+}
+
+// resolveInNamespace is used by the namespace resolver. Everything but localhost is resolved in the cluster
+// because the network namespace has no other DNS, and no route other than the one to the cluster.
+func (s *Server) resolveInNamespace(c context.Context, query string) ([]net.IP, error) {
+	query = strings.ToLower(query)
+	query = strings.TrimSuffix(query, tel2SubDomainDot)
+	if query == "localhost." {
+		return localhostIPs, nil
+	}
+	return s.lookupInCluster(c, query)
+}
+
 func (s *Server) runOverridingServer(c context.Context, dev *vif.Device) error {
 	if s.config.LocalIp == nil {
 		dat, err := os.ReadFile("/etc/resolv.conf")
@@ -0,0 +1,114 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	rpc "github.com/telepresenceio/telepresence/rpc/v2/daemon"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif"
+)
+
+func TestNamespaceResolvConf(t *testing.T) {
+	dnsIP := net.IP{10, 0, 0, 10}
+	assert.Equal(t, "nameserver 10.0.0.10\n", namespaceResolvConf(dnsIP, nil))
+	assert.Equal(t,
+		"nameserver 10.0.0.10\nsearch default.svc.cluster.local svc.cluster.local\n",
+		namespaceResolvConf(dnsIP, []string{"default", "default.svc.cluster.local", "svc.cluster.local"}))
+}
+
+func TestResolveInNamespace(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	var queries []string
+	s := NewServer(nil, func(_ context.Context, query string) ([][]byte, error) {
+		queries = append(queries, query)
+		return [][]byte{{10, 1, 0, 1}}, nil
+	})
+
+	// localhost is never resolved in the cluster
+	ips, err := s.resolveInNamespace(ctx, "LocalHost.")
+	require.NoError(t, err)
+	assert.Equal(t, localhostIPs, ips)
+	assert.Empty(t, queries)
+
+	// Everything else is, including the names that the host resolver would exclude
+	for _, q := range []string{"echo.default.", "echo.default." + tel2SubDomainDot, "example.com."} {
+		ips, err = s.resolveInNamespace(ctx, q)
+		require.NoError(t, err)
+		assert.Equal(t, []net.IP{{10, 1, 0, 1}}, ips)
+	}
+	assert.Equal(t, []string{"echo.default", "echo.default", "example.com"}, queries)
+}
+
+func TestRunNamespaceServer(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("writing the resolv.conf of a network namespace requires root")
+	}
+	ns := fmt.Sprintf("teltest%d", os.Getpid())
+	resolvConf := vif.NamespaceResolvConf(ns)
+	require.NoError(t, os.MkdirAll(filepath.Dir(resolvConf), 0o755))
+	defer func() {
+		_ = os.RemoveAll(filepath.Dir(resolvConf))
+		_ = os.Remove(filepath.Dir(filepath.Dir(resolvConf))) // only succeeds when empty
+	}()
+
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	defer cancel()
+	s := NewServer(&rpc.DNSConfig{RemoteIp: net.IP{10, 0, 0, 10}}, func(_ context.Context, query string) ([][]byte, error) {
+		if query == "echo.default" {
+			return [][]byte{{10, 1, 0, 1}}, nil
+		}
+		return nil, nil
+	})
+
+	var mu sync.Mutex
+	var resolverAddr *net.UDPAddr
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.runNamespaceServer(ctx, nil, ns, func(dnsIP net.IP, addr *net.UDPAddr) {
+			mu.Lock()
+			resolverAddr = addr
+			mu.Unlock()
+		})
+	}()
+
+	resolvConfIs := func(expected string) func() bool {
+		return func() bool {
+			data, err := os.ReadFile(resolvConf)
+			return err == nil && string(data) == expected
+		}
+	}
+	require.Eventually(t, resolvConfIs("nameserver 10.0.0.10\n"), 5*time.Second, 10*time.Millisecond)
+	s.SetSearchPath(ctx, []string{"default.svc.cluster.local"}, nil)
+	require.Eventually(t, resolvConfIs("nameserver 10.0.0.10\nsearch default.svc.cluster.local\n"), 5*time.Second, 10*time.Millisecond)
+
+	// The resolver that the TUN device redirects the namespace's DNS requests to answers from the cluster
+	mu.Lock()
+	addr := resolverAddr
+	mu.Unlock()
+	require.NotNil(t, addr)
+	c := dns.Client{Net: "udp", Timeout: 2 * time.Second}
+	m := new(dns.Msg)
+	m.SetQuestion("echo.default.", dns.TypeA)
+	r, _, err := c.ExchangeContext(ctx, m, addr.String())
+	require.NoError(t, err)
+	require.Len(t, r.Answer, 1)
+	require.IsType(t, &dns.A{}, r.Answer[0])
+	assert.Equal(t, "10.1.0.1", r.Answer[0].(*dns.A).A.String())
+
+	cancel()
+	require.NoError(t, <-errs)
+	mu.Lock()
+	assert.Nil(t, resolverAddr, "the DNS configuration must be reset when the server ends")
+	mu.Unlock()
+}
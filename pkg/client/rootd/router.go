@@ -16,6 +16,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/buffer"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/icmp"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/ip"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/netstack"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/tcp"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/udp"
 )
@@ -23,6 +24,18 @@ import (
 func (s *session) routerWorker(c context.Context) error {
 	dlog.Debug(c, "TUN read loop starting")
 
+	if client.GetConfig(c).Network.TCPStack == client.GVisorTCPStack {
+		dlog.Info(c, "Using the gVisor TCP/IP stack")
+		ns, err := netstack.NewStack(c, s.dev, s.mtu, func(c context.Context, id tunnel.ConnID) (tunnel.Stream, error) {
+			return s.streamCreator(id)(c)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create gVisor TCP/IP stack: %w", err)
+		}
+		s.netStack = ns
+		defer ns.Close()
+	}
+
 	// bufCh is just a small buffer to enable better parallel processing between
 	// the actual TUN reader loop and the packet handlers.
 	bufCh := make(chan *buffer.Data, 100)
@@ -76,6 +89,14 @@ func (s *session) handlePacket(c context.Context, data *buffer.Data) {
 		return
 	}
 
+	if len(data.Buf()) > s.mtu && mustNotFragment(ipHdr) {
+		// Tell the sender to lower its path MTU. IPv4 packets that may be fragmented are reassembled
+		// and tunneled just like any other packet.
+		dlog.Tracef(c, "Packet from %s to %s exceeds MTU %d", ipHdr.Source(), ipHdr.Destination(), s.mtu)
+		reply(icmp.PacketTooBigPacket(ipHdr, s.mtu))
+		return
+	}
+
 	if ipHdr.Version() == ipv4.Version {
 		v4Hdr := ipHdr.(ip.V4Header)
 		if v4Hdr.Flags()&ipv4.MoreFragments != 0 || v4Hdr.FragmentOffset() != 0 {
@@ -111,10 +132,14 @@ func (s *session) handlePacket(c context.Context, data *buffer.Data) {
 		}
 		data = nil
 		s.udp(c, dg)
-	case ipproto.ICMP:
-	case ipproto.ICMPV6:
+	case ipproto.ICMP, ipproto.ICMPV6:
 		pkt := icmp.PacketFromData(ipHdr, data)
-		dlog.Tracef(c, "<- TUN %s", pkt)
+		if !icmp.IsEchoRequest(pkt) {
+			dlog.Tracef(c, "<- TUN %s", pkt)
+			return
+		}
+		data = nil
+		s.echo(c, pkt)
 	default:
 		// An L4 protocol that we don't handle.
 		dlog.Tracef(c, "Unhandled protocol %d", ipHdr.L4Protocol())
@@ -122,11 +147,25 @@ func (s *session) handlePacket(c context.Context, data *buffer.Data) {
 	}
 }
 
+// mustNotFragment returns true for IPv6 packets, which are never fragmented by routers, and for IPv4
+// packets that have the "don't fragment" flag set.
+func mustNotFragment(ipHdr ip.Header) bool {
+	if ipHdr.Version() == ipv4.Version {
+		return ipHdr.(ip.V4Header).Flags()&ipv4.DontFragment != 0
+	}
+	return true
+}
+
 type vifWriter struct {
 	*vif.Device
+	mtu int
 }
 
 func (w vifWriter) Write(ctx context.Context, pkt ip.Packet) (err error) {
+	if tp, ok := pkt.(tcp.Packet); ok {
+		// Segments sent by the host must fit in the MTU too.
+		tcp.ClampMSS(tp, w.mtu)
+	}
 	d := pkt.Data()
 	l := len(d.Buf())
 	dlog.Tracef(ctx, "-> TUN %s, len %d", pkt, l)
@@ -153,6 +192,17 @@ func (s *session) tcp(c context.Context, pkt tcp.Packet) {
 	tcpHdr := pkt.Header()
 	connID := tunnel.NewConnID(ipproto.TCP, ipHdr.Source(), ipHdr.Destination(), tcpHdr.SourcePort(), tcpHdr.DestinationPort())
 	dlog.Tracef(c, "<- TUN %s", pkt)
+	if tcpHdr.SYN() && tcp.ClampMSS(pkt, s.mtu) {
+		dlog.Tracef(c, "   CON %s, maximum segment size clamped to MTU %d", connID, s.mtu)
+	}
+	if s.netStack != nil {
+		// Ignore TCP packets intended for the DNS resolver, just like the legacy handler does
+		if !(tcpHdr.SYN() && s.isForDNS(ipHdr.Destination(), tcpHdr.DestinationPort())) {
+			s.netStack.HandlePacket(c, pkt.Data())
+		}
+		pkt.Release()
+		return
+	}
 	if !tcpHdr.SYN() {
 		// Only a SYN packet can create a new connection. For all other packets, the connection must already exist
 		wf := s.handlers.Get(connID)
@@ -172,7 +222,7 @@ func (s *session) tcp(c context.Context, pkt tcp.Packet) {
 	}
 
 	wf, _, err := s.handlers.GetOrCreate(c, connID, func(c context.Context, remove func()) (tunnel.Handler, error) {
-		return tcp.NewHandler(s.streamCreator(connID), &s.closing, vifWriter{s.dev}, connID, remove, s.rndSource), nil
+		return tcp.NewHandler(s.streamCreator(connID), &s.closing, vifWriter{Device: s.dev, mtu: s.mtu}, connID, remove, s.rndSource), nil
 	})
 	if err != nil {
 		dlog.Error(c, err)
@@ -190,7 +240,7 @@ func (s *session) udp(c context.Context, dg udp.Datagram) {
 	udpHdr := dg.Header()
 	connID := tunnel.NewConnID(ipproto.UDP, ipHdr.Source(), ipHdr.Destination(), udpHdr.SourcePort(), udpHdr.DestinationPort())
 	uh, _, err := s.handlers.GetOrCreate(c, connID, func(c context.Context, remove func()) (tunnel.Handler, error) {
-		w := vifWriter{s.dev}
+		w := vifWriter{Device: s.dev, mtu: s.mtu}
 		if s.isForDNS(ipHdr.Destination(), udpHdr.DestinationPort()) {
 			return udp.NewDnsInterceptor(w, connID, remove, s.dnsLocalAddr)
 		}
@@ -207,6 +257,31 @@ func (s *session) udp(c context.Context, dg udp.Datagram) {
 	uh.(udp.DatagramHandler).HandleDatagram(c, dg)
 }
 
+func (s *session) echo(c context.Context, pkt icmp.Packet) {
+	ipHdr := pkt.IPHeader()
+	connID := tunnel.NewConnID(ipHdr.L4Protocol(), ipHdr.Source(), ipHdr.Destination(), pkt.Header().Identifier(), 0)
+	eh, _, err := s.handlers.GetOrCreate(c, connID, func(c context.Context, remove func()) (tunnel.Handler, error) {
+		stream, err := s.streamCreator(connID)(c)
+		if err != nil {
+			return nil, err
+		}
+		if stream.PeerVersion() < tunnel.EchoVersion {
+			_ = stream.CloseSend(c)
+			return nil, fmt.Errorf("the traffic-manager doesn't support ICMP echo, tunnel version %d", stream.PeerVersion())
+		}
+		return icmp.NewEchoHandler(stream, vifWriter{Device: s.dev, mtu: s.mtu}, connID, remove), nil
+	})
+	if err != nil {
+		dlog.Error(c, err)
+		if _, err = s.dev.WritePacket(icmp.DestinationUnreachablePacket(ipHdr, icmp.HostUnreachable).Data(), 0); err != nil {
+			dlog.Errorf(c, "TUN write failed: %v", err)
+		}
+		pkt.Release()
+		return
+	}
+	eh.(icmp.EchoHandler).HandleEcho(c, pkt)
+}
+
 func (s *session) streamCreator(id tunnel.ConnID) tcp.StreamCreator {
 	return func(c context.Context) (tunnel.Stream, error) {
 		dlog.Debugf(c, "Opening tunnel for id %s", id)
@@ -181,9 +181,7 @@ func (d *service) GetClusterSubnets(ctx context.Context, _ *empty.Empty) (*rpc.C
 				}
 				return err
 			}
-			if mgrInfo.ServiceSubnet != nil {
-				svcSubnets = append(svcSubnets, mgrInfo.ServiceSubnet)
-			}
+			svcSubnets = append(svcSubnets, serviceSubnets(mgrInfo)...)
 			podSubnets = append(podSubnets, mgrInfo.PodSubnets...)
 		}
 	})
@@ -36,6 +36,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/buffer"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/netstack"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/routing"
 )
 
@@ -75,6 +76,14 @@ type session struct {
 	// dev is the TUN device that gets configured with the subnets found in the cluster
 	dev *vif.Device
 
+	// networkNamespace is the name of the network namespace that dev was moved into, or empty when
+	// dev lives in the host's network namespace.
+	networkNamespace string
+
+	// mtu is the effective MTU. Larger packets that are read from dev are rejected, and the maximum
+	// segment size of TCP SYN packets is clamped so that segments fit.
+	mtu int
+
 	// clientConn is the connection that uses the connector's socket
 	clientConn *grpc.ClientConn
 
@@ -119,9 +128,16 @@ type session struct {
 	//   2 = closed
 	closing int32
 
+	// capturing is 1 while a packet capture is in progress. Only one capture at a time is permitted.
+	capturing int32
+
 	// session contains the manager session
 	session *manager.SessionInfo
 
+	// netStack terminates TCP connections instead of the TCP handlers when the gVisor TCP stack is configured.
+	// It's set before the router starts to process packets.
+	netStack *netstack.Stack
+
 	// rndSource is the source for the random number generator in the TCP handlers
 	rndSource rand.Source
 
@@ -213,7 +229,12 @@ func newSession(c context.Context, scout *scout.Reporter, mi *rpc.OutboundInfo)
 		return nil, err
 	}
 
-	dev, err := vif.OpenTun(c)
+	var dev *vif.Device
+	if mi.NetworkNamespace != "" {
+		dev, err = vif.OpenTunInNamespace(c, mi.NetworkNamespace)
+	} else {
+		dev, err = vif.OpenTun(c)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -222,6 +243,8 @@ func newSession(c context.Context, scout *scout.Reporter, mi *rpc.OutboundInfo)
 		cancel:            func() {},
 		scout:             scout,
 		dev:               dev,
+		networkNamespace:  mi.NetworkNamespace,
+		mtu:               effectiveMTU(c, mi.ApiServerIps, routing.GetRoute),
 		handlers:          tunnel.NewPool(),
 		fragmentMap:       make(map[uint16][]*buffer.Data),
 		rndSource:         rand.NewSource(time.Now().UnixNano()),
@@ -236,6 +259,57 @@ func newSession(c context.Context, scout *scout.Reporter, mi *rpc.OutboundInfo)
 	return s, nil
 }
 
+// minMTU is the smallest MTU that every IPv4 host must accept (RFC 791).
+const minMTU = 576
+
+// effectiveMTU returns the configured MTU or, if none is configured, the smallest MTU of the
+// interfaces that route to the given API server IPs, since that is where the tunnel to the cluster
+// goes. The default MTU is used when no such route is found. The MTU of the TUN device is left as
+// is, and is instead enforced by the router. This works on all platforms, and for all packets,
+// regardless of whether they originate from the host or are forwarded to the device.
+func effectiveMTU(c context.Context, apiServerIPs [][]byte, getRoute func(context.Context, *net.IPNet) (routing.Route, error)) int {
+	mtu := client.GetConfig(c).Network.MTU
+	if mtu == 0 {
+		mtu = routeMTU(c, apiServerIPs, getRoute)
+		if mtu == 0 {
+			return buffer.DataPool.MTU
+		}
+		dlog.Infof(c, "Using MTU %d of the route to the API server", mtu)
+	}
+	switch {
+	case mtu > buffer.DataPool.MTU:
+		dlog.Warnf(c, "MTU %d is larger than the maximum %d", mtu, buffer.DataPool.MTU)
+		mtu = buffer.DataPool.MTU
+	case mtu < minMTU:
+		dlog.Warnf(c, "MTU %d is smaller than the minimum %d", mtu, minMTU)
+		mtu = minMTU
+	}
+	return mtu
+}
+
+// routeMTU returns the smallest MTU of the interfaces that route to the given IPs, or zero when
+// no route is found.
+func routeMTU(c context.Context, ips [][]byte, getRoute func(context.Context, *net.IPNet) (routing.Route, error)) int {
+	mtu := 0
+	for _, ipBytes := range ips {
+		ip := net.IP(ipBytes)
+		bits := 128
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+			bits = 32
+		}
+		r, err := getRoute(c, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		if err != nil {
+			dlog.Warnf(c, "unable to get route to API server %s: %v", ip, err)
+			continue
+		}
+		if r.Interface != nil && r.Interface.MTU > 0 && (mtu == 0 || r.Interface.MTU < mtu) {
+			mtu = r.Interface.MTU
+		}
+	}
+	return mtu
+}
+
 // clusterLookup sends a LookupHost request to the traffic-manager and returns the result
 func (s *session) clusterLookup(ctx context.Context, key string) ([][]byte, error) {
 	dlog.Debugf(ctx, "LookupHost %q", key)
@@ -255,8 +329,10 @@ func (s *session) clusterLookup(ctx context.Context, key string) ([][]byte, erro
 
 func (s *session) getInfo() *rpc.OutboundInfo {
 	info := rpc.OutboundInfo{
-		Session: s.session,
-		Dns:     s.dnsServer.GetConfig(),
+		Session:          s.session,
+		Dns:              s.dnsServer.GetConfig(),
+		NetworkNamespace: s.networkNamespace,
+		Mtu:              int32(s.mtu),
 	}
 	if s.dnsLocalAddr != nil {
 		info.Dns.RemoteIp = s.dnsLocalAddr.IP
@@ -284,6 +360,10 @@ func (s *session) configureDNS(dnsIP net.IP, dnsLocalAddr *net.UDPAddr) {
 }
 
 func (s *session) reconcileStaticRoutes(ctx context.Context) error {
+	if s.networkNamespace != "" {
+		// The device is the only way out of the namespace, so there's nothing to route around it.
+		return nil
+	}
 	desired := []routing.Route{}
 
 	// We're not going to add static routes unless they're actually needed
@@ -374,8 +454,20 @@ func (s *session) refreshSubnets(ctx context.Context) error {
 func (s *session) watchClusterInfo(ctx context.Context, cfgComplete chan<- struct{}) {
 	backoff := 100 * time.Millisecond
 
+	// The revision of the last received cluster info. Passing it when the watch is resumed
+	// avoids receiving (and applying) the same cluster info again.
+	var revision uint64
+	sinceSupported := true
 	for ctx.Err() == nil {
-		infoStream, err := s.managerClient.WatchClusterInfo(ctx, s.session)
+		var infoStream interface {
+			Recv() (*manager.ClusterInfo, error)
+		}
+		var err error
+		if sinceSupported {
+			infoStream, err = s.managerClient.WatchClusterInfoSince(ctx, &manager.WatchSinceRequest{Session: s.session, SinceRevision: revision})
+		} else {
+			infoStream, err = s.managerClient.WatchClusterInfo(ctx, s.session)
+		}
 		if err != nil {
 			err = fmt.Errorf("error when calling WatchClusterInfo: %w", err)
 			dlog.Warn(ctx, err)
@@ -393,12 +485,19 @@ func (s *session) watchClusterInfo(ctx context.Context, cfgComplete chan<- struc
 					case codes.Unavailable:
 						// Abrupt shutdown. This is nothing that the session should survive
 						dlog.Errorf(ctx, "WatchClusterInfo recv: Unavailable: %v", gErr.Message())
+					case codes.Unimplemented:
+						// Older traffic-manager. Fall back to always receiving the initial cluster info.
+						if sinceSupported {
+							dlog.Debug(ctx, "WatchClusterInfoSince is not implemented by the traffic-manager")
+							sinceSupported = false
+						}
 					}
 				} else {
 					dlog.Errorf(ctx, "WatchClusterInfo recv: %v", err)
 				}
 				break
 			}
+			revision = mgrInfo.Revision
 			if cfgComplete != nil {
 				s.checkConnectivity(ctx, mgrInfo)
 				if ctx.Err() != nil {
@@ -433,10 +532,11 @@ func (s *session) watchClusterInfo(ctx context.Context, cfgComplete chan<- struc
 func (s *session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInfo) {
 	dlog.Debugf(ctx, "WatchClusterInfo update")
 
-	subnets := make([]*net.IPNet, 0, 1+len(mgrInfo.PodSubnets))
+	svcSubnets := serviceSubnets(mgrInfo)
+	subnets := make([]*net.IPNet, 0, len(svcSubnets)+len(mgrInfo.PodSubnets))
 	if s.proxyCluster {
-		if mgrInfo.ServiceSubnet != nil {
-			cidr := iputil.IPNetFromRPC(mgrInfo.ServiceSubnet)
+		for _, sn := range svcSubnets {
+			cidr := iputil.IPNetFromRPC(sn)
 			dlog.Infof(ctx, "Adding service subnet %s", cidr)
 			subnets = append(subnets, cidr)
 		}
@@ -454,8 +554,22 @@ func (s *session) onClusterInfo(ctx context.Context, mgrInfo *manager.ClusterInf
 	}
 }
 
+// serviceSubnets returns the service subnets of the given ClusterInfo. A traffic-manager that predates
+// dual-stack support will only provide the single ServiceSubnet.
+func serviceSubnets(mgrInfo *manager.ClusterInfo) []*manager.IPNet {
+	if len(mgrInfo.ServiceSubnets) > 0 {
+		return mgrInfo.ServiceSubnets
+	}
+	if mgrInfo.ServiceSubnet != nil {
+		return []*manager.IPNet{mgrInfo.ServiceSubnet}
+	}
+	return nil
+}
+
 func (s *session) checkConnectivity(ctx context.Context, info *manager.ClusterInfo) {
-	if info.ManagerPodIp == nil {
+	if info.ManagerPodIp == nil || s.networkNamespace != "" {
+		// Whether the host can reach the pods is irrelevant to a network namespace that
+		// can only reach them through the device.
 		return
 	}
 	ip := net.IP(info.ManagerPodIp).String()
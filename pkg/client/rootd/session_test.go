@@ -0,0 +1,63 @@
+package rootd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/routing"
+)
+
+func TestEffectiveMTU(t *testing.T) {
+	// routes maps the IPs of the API server to the MTU of the interface that routes to them
+	routes := map[string]int{
+		"192.168.1.10": 1500,
+		"10.8.0.1":     1380,
+		"fd00::1":      1280,
+		"192.168.1.11": 9000,
+	}
+	getRoute := func(_ context.Context, n *net.IPNet) (routing.Route, error) {
+		mtu, ok := routes[n.IP.String()]
+		if !ok {
+			return routing.Route{}, errors.New("no route")
+		}
+		return routing.Route{RoutedNet: n, Interface: &net.Interface{Name: "eth0", MTU: mtu}}, nil
+	}
+	ips := func(ss ...string) [][]byte {
+		bs := make([][]byte, len(ss))
+		for i, s := range ss {
+			bs[i] = net.ParseIP(s)
+		}
+		return bs
+	}
+
+	tests := []struct {
+		name      string
+		configMTU int
+		apiServer [][]byte
+		want      int
+	}{
+		{"no API server", 0, nil, 1500},
+		{"no route", 0, ips("172.16.0.1"), 1500},
+		{"route", 0, ips("10.8.0.1"), 1380},
+		{"smallest route", 0, ips("192.168.1.10", "10.8.0.1", "fd00::1"), 1280},
+		{"skip unrouted", 0, ips("172.16.0.1", "10.8.0.1"), 1380},
+		{"route above maximum", 0, ips("192.168.1.11"), 1500},
+		{"configured", 1400, ips("10.8.0.1"), 1400},
+		{"configured above maximum", 9000, nil, 1500},
+		{"configured below minimum", 100, nil, minMTU},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := client.GetDefaultConfig()
+			cfg.Network.MTU = tt.configMTU
+			ctx := client.WithConfig(dlog.NewTestContext(t, false), &cfg)
+			assert.Equal(t, tt.want, effectiveMTU(ctx, tt.apiServer, getRoute))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TCPStack specifies the TCP/IP stack that the root daemon uses to terminate the TCP connections
+// that are routed to the TUN device.
+type TCPStack int
+
+var tsNames = [...]string{"legacy", "gvisor"}
+
+const (
+	// LegacyTCPStack is the TCP state machine of the vif/tcp package (this is the default)
+	LegacyTCPStack TCPStack = iota
+
+	// GVisorTCPStack is gVisor's netstack
+	GVisorTCPStack
+)
+
+func (ts TCPStack) String() string {
+	return tsNames[ts]
+}
+
+func NewTCPStack(s string) (TCPStack, error) {
+	for i, n := range tsNames {
+		if s == n {
+			return TCPStack(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid TCPStack: %q", s)
+}
+
+func (ts TCPStack) MarshalYAML() (any, error) {
+	return ts.String(), nil
+}
+
+func (ts *TCPStack) UnmarshalYAML(node *yaml.Node) (err error) {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	if s == "" {
+		*ts = LegacyTCPStack
+		return nil
+	}
+	*ts, err = NewTCPStack(s)
+	return err
+}
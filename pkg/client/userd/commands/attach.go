@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/cli/cliutil"
+)
+
+type attachCommand struct {
+	args    interceptArgs
+	command *cobra.Command
+}
+
+func (c *attachCommand) group() string {
+	return "Traffic Commands"
+}
+
+func (c *attachCommand) cobraCommand(ctx context.Context) *cobra.Command {
+	if c.command != nil {
+		return c.command
+	}
+
+	var port int
+	c.command = &cobra.Command{
+		Use:   "attach [flags] <workload>",
+		Args:  cobra.ExactArgs(1),
+		Short: "Attach to an intercept that is owned by another client",
+		Long: `Attach to an active intercept of the given workload that is owned by another client.
+
+The attached client gets the environment and the volume mounts of the intercept, but the intercepted traffic
+stays with the owner until the owner hands it over using "telepresence handover". Use "telepresence leave"
+to detach.`,
+		RunE: func(cmd *cobra.Command, positional []string) error {
+			c.args.agentName = positional[0]
+			return c.attach(cmd, int32(port))
+		},
+		Annotations: map[string]string{
+			CommandRequiresSession: "",
+		},
+	}
+	flags := c.command.Flags()
+	flags.IntVarP(&port, "port", "p", client.GetConfig(ctx).Intercept.DefaultPort,
+		"Local port that receives the traffic if the owner hands the intercept over to this client")
+	flags.StringVarP(&c.args.namespace, "namespace", "n", "", "If present, the namespace scope for this CLI request")
+	flags.StringVarP(&c.args.mount, "mount", "", "true", ``+
+		`The absolute path for the root directory where volumes will be mounted, $TELEPRESENCE_ROOT. Use "true" to `+
+		`have Telepresence pick a random mount point (default). Use "false" to disable filesystem mounting entirely.`)
+	flags.StringVarP(&c.args.envFile, "env-file", "e", "", ``+
+		`Also emit the remote environment to an env file in Docker Compose format. `+
+		`See https://docs.docker.com/compose/env-file/ for more information on the limitations of this format.`)
+	flags.StringVarP(&c.args.envJSON, "env-json", "j", "", `Also emit the remote environment to a file as a JSON blob.`)
+	return c.command
+}
+
+func (*attachCommand) init(_ context.Context) {}
+
+func (c *attachCommand) attach(cmd *cobra.Command, port int32) error {
+	ctx := cmd.Context()
+	session := GetSession(ctx)
+	if session == nil {
+		return errors.New("no session found")
+	}
+
+	is := &interceptState{args: c.args}
+	var volumeMountProblem error
+	mountPoint := ""
+	if doMount, err := strconv.ParseBool(c.args.mount); doMount || err != nil {
+		if volumeMountProblem = checkMountCapability(ctx); volumeMountProblem == nil {
+			if mountPoint, _, err = is.getMountPoint(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	ii, err := session.AttachIntercept(ctx, c.args.agentName, c.args.namespace, port, mountPoint)
+	if err != nil {
+		return err
+	}
+
+	is.env = ii.Environment
+	if is.env == nil {
+		is.env = make(map[string]string)
+	}
+	is.env["TELEPRESENCE_INTERCEPT_ID"] = ii.Id
+	is.env["TELEPRESENCE_ROOT"] = ii.ClientMountPoint
+	if c.args.envFile != "" {
+		if err = is.writeEnvFile(); err != nil {
+			return err
+		}
+	}
+	if c.args.envJSON != "" {
+		if err = is.writeEnvJSON(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), cliutil.DescribeIntercepts([]*manager.InterceptInfo{ii}, volumeMountProblem, false))
+	return nil
+}
+
+type handoverCommand struct {
+	command *cobra.Command
+}
+
+func (c *handoverCommand) group() string {
+	return "Traffic Commands"
+}
+
+func (c *handoverCommand) cobraCommand(_ context.Context) *cobra.Command {
+	if c.command != nil {
+		return c.command
+	}
+	c.command = &cobra.Command{
+		Use:   "handover <intercept_name> [<client>]",
+		Args:  cobra.RangeArgs(1, 2),
+		Short: "Hand the traffic of an intercept over to an attached client",
+		Long: `Hand the traffic of an intercept over to a client that has attached to it using "telepresence attach".
+
+The client is identified by its name or session ID, as shown by "telepresence list". When no client is
+given, the traffic is routed back to this client. The intercept remains owned by this client.`,
+		RunE: func(cmd *cobra.Command, positional []string) error {
+			session := GetSession(cmd.Context())
+			if session == nil {
+				return errors.New("no session found")
+			}
+			target := ""
+			if len(positional) > 1 {
+				target = positional[1]
+			}
+			ii, err := session.HandoverIntercept(cmd.Context(), positional[0], target)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), cliutil.DescribeIntercepts([]*manager.InterceptInfo{ii}, nil, false))
+			return nil
+		},
+		Annotations: map[string]string{
+			CommandRequiresSession: "",
+		},
+	}
+	return c.command
+}
+
+func (*handoverCommand) init(_ context.Context) {}
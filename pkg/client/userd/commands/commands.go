@@ -25,6 +25,10 @@ type command interface {
 func commands() []command {
 	return []command{
 		&interceptCommand{},
+		&attachCommand{},
+		&handoverCommand{},
+		&runJobCommand{},
+		&injectCommand{},
 		&traceCommand{},
 		&pushTracesCommand{},
 	}
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
+)
+
+type injectCommand struct {
+	namespace string
+	kind      string
+	input     string
+	dryRun    bool
+	command   *cobra.Command
+}
+
+func (c *injectCommand) group() string {
+	return "Traffic Commands"
+}
+
+func (c *injectCommand) cobraCommand(_ context.Context) *cobra.Command {
+	if c.command != nil {
+		return c.command
+	}
+	c.command = &cobra.Command{
+		Use:   "inject --dry-run [flags] <workload>",
+		Args:  cobra.MaximumNArgs(1),
+		Short: "Show how the traffic-agent would be injected into a workload",
+		Long: `Show the agent config that the traffic-manager would generate for a workload, and the JSON patch that
+its agent injector would apply to the pods of the workload. Nothing is changed in the cluster.
+
+The workload is read from the cluster unless --input is used, in which case it is read from a YAML or JSON file.
+The services of the workload are always read from the cluster.`,
+		RunE: func(cmd *cobra.Command, positional []string) error {
+			name := ""
+			if len(positional) > 0 {
+				name = positional[0]
+			}
+			return c.inject(cmd, name)
+		},
+		Annotations: map[string]string{
+			CommandRequiresSession: "",
+		},
+	}
+	flags := c.command.Flags()
+	flags.BoolVar(&c.dryRun, "dry-run", false, "Only show the agent config and the pod patch. Currently required")
+	flags.StringVarP(&c.namespace, "namespace", "n", "", "If present, the namespace scope for this CLI request")
+	flags.StringVar(&c.kind, "kind", "", `The kind of the workload, e.g. "Deployment". Found automatically when empty`)
+	flags.StringVarP(&c.input, "input", "f", "", "Read the workload from this YAML or JSON file instead of from the cluster")
+	return c.command
+}
+
+func (*injectCommand) init(_ context.Context) {}
+
+func (c *injectCommand) inject(cmd *cobra.Command, name string) error {
+	if !c.dryRun {
+		return errcat.User.New("the traffic-agent is injected by the traffic-manager, only --dry-run is supported")
+	}
+	ctx := cmd.Context()
+	session := GetSession(ctx)
+	if session == nil {
+		return errors.New("no session found")
+	}
+
+	var workload []byte
+	if c.input != "" {
+		input := c.input
+		if !filepath.IsAbs(input) {
+			input = filepath.Join(GetCwd(ctx), input)
+		}
+		var err error
+		if workload, err = os.ReadFile(input); err != nil {
+			return errcat.User.New(err)
+		}
+	} else if name == "" {
+		return errcat.User.New("a workload name or --input is required")
+	}
+
+	rsp, err := session.DryRunInject(ctx, name, c.namespace, c.kind, workload)
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "# Agent config\n%s\n# Pod patch\n%s\n", rsp.Config, rsp.Patch)
+	return nil
+}
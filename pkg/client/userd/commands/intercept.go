@@ -74,6 +74,19 @@ func (cmd *interceptCommand) cobraCommand(ctx context.Context) *cobra.Command {
 
 	flags.StringVar(&cmd.args.serviceName, "service", "", "Name of service to intercept. If not provided, we will try to auto-detect one")
 
+	flags.StringVar(&cmd.args.podName, "pod", "", ``+
+		`Name of the pod to intercept. Only the traffic to that pod is intercepted, and the other replicas of the `+
+		`workload keep serving their traffic`)
+
+	flags.IntVar(&cmd.args.replicas, "replicas", 0, ``+
+		`Number of replicas to intercept. The only value supported is 1, in which case the traffic-manager `+
+		`chooses the pod to intercept. All replicas are intercepted by default`)
+
+	flags.BoolVar(&cmd.args.wait, "wait", false, ``+
+		`If the workload is already intercepted, queue this intercept and wait until it becomes active. `+
+		`The queue position is shown by "telepresence list". An intercept without this flag is rejected while `+
+		`other intercepts are queued for the workload`)
+
 	flags.BoolVarP(&cmd.args.localOnly, "local-only", "l", false, ``+
 		`Declare a local-only intercept for the purpose of getting direct outbound access to the intercept's namespace`)
 
@@ -165,6 +178,9 @@ func (c *interceptCommand) init(ctx context.Context) {
 			if cmd.Flag("mount").Changed {
 				return errcat.User.New("a local-only intercept cannot have mounts")
 			}
+			if args.podName != "" || args.replicas != 0 {
+				return errcat.User.New("a local-only intercept cannot target pods")
+			}
 			if cmd.Flag("preview-url").Changed && args.previewEnabled {
 				return errcat.User.New("a local-only intercept cannot be previewed")
 			}
@@ -176,6 +192,9 @@ func (c *interceptCommand) init(ctx context.Context) {
 					args.name += "-" + args.namespace
 				}
 			}
+			if args.replicas < 0 || args.replicas > 1 {
+				return errcat.User.New("--replicas must be 1. Use --pod to intercept a specific pod")
+			}
 		}
 		args.mountSet = cmd.Flag("mount").Changed
 		if args.dockerRun {
@@ -326,6 +345,9 @@ type interceptArgs struct {
 	port        string // --port // only valid if !localOnly
 	serviceName string // --service // only valid if !localOnly
 	localOnly   bool   // --local-only
+	wait        bool   // --wait
+	podName     string // --pod // only valid if !localOnly
+	replicas    int    // --replicas // only valid if !localOnly
 
 	previewEnabled bool                 // --preview-url // only valid if !localOnly
 	previewSpec    *manager.PreviewSpec // --preview-url-* // only valid if !localOnly
@@ -568,6 +590,9 @@ func (is *interceptState) createRequest(ctx context.Context) (*connector.CreateI
 
 	spec.Agent = is.args.agentName
 	spec.TargetHost = "127.0.0.1"
+	spec.PodName = is.args.podName
+	spec.Replicas = int32(is.args.replicas)
+	ir.Wait = is.args.wait
 
 	// Parse port into spec based on how it's formatted
 	var err error
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/proc"
+)
+
+type runJobCommand struct {
+	args      interceptArgs
+	kind      string
+	container string
+	command   *cobra.Command
+}
+
+func (c *runJobCommand) group() string {
+	return "Traffic Commands"
+}
+
+func (c *runJobCommand) cobraCommand(_ context.Context) *cobra.Command {
+	if c.command != nil {
+		return c.command
+	}
+	c.command = &cobra.Command{
+		Use:   "run-job [flags] <job|cronjob> -- <command with arguments...>",
+		Args:  cobra.MinimumNArgs(2),
+		Short: "Run a Job or CronJob locally using its cluster environment",
+		Long: `Run a command locally with the environment and volume mounts of a container in a Job or CronJob.
+
+A copy of the Job, or of the job template of the CronJob, is created with a traffic-agent injected into its pod.
+The containers of the copy do nothing, the agent only provides their environment and volumes. The copy is
+deleted when the command ends.`,
+		RunE: func(cmd *cobra.Command, positional []string) error {
+			c.args.name = positional[0]
+			c.args.cmdline = positional[1:]
+			return c.runJob(cmd)
+		},
+		Annotations: map[string]string{
+			CommandRequiresSession: "",
+		},
+	}
+	flags := c.command.Flags()
+	flags.StringVarP(&c.args.namespace, "namespace", "n", "", "If present, the namespace scope for this CLI request")
+	flags.StringVar(&c.kind, "kind", "", `The kind of the workload, "Job" or "CronJob". Both are tried when empty`)
+	flags.StringVarP(&c.container, "container", "c", "", "The container to use. Required when the pod has more than one container")
+	flags.StringVarP(&c.args.mount, "mount", "", "true", ``+
+		`The absolute path for the root directory where volumes will be mounted, $TELEPRESENCE_ROOT. Use "true" to `+
+		`have Telepresence pick a random mount point (default). Use "false" to disable filesystem mounting entirely.`)
+	flags.StringVarP(&c.args.envFile, "env-file", "e", "", ``+
+		`Also emit the remote environment to an env file in Docker Compose format. `+
+		`See https://docs.docker.com/compose/env-file/ for more information on the limitations of this format.`)
+	flags.StringVarP(&c.args.envJSON, "env-json", "j", "", `Also emit the remote environment to a file as a JSON blob.`)
+	return c.command
+}
+
+func (*runJobCommand) init(_ context.Context) {}
+
+func (c *runJobCommand) runJob(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+	session := GetSession(ctx)
+	if session == nil {
+		return errors.New("no session found")
+	}
+
+	is := &interceptState{args: c.args}
+	mountPoint := ""
+	if doMount, err := strconv.ParseBool(c.args.mount); doMount || err != nil {
+		if err = checkMountCapability(ctx); err != nil {
+			dlog.Warnf(ctx, "remote volumes will not be mounted: %v", err)
+		} else if mountPoint, _, err = is.getMountPoint(ctx); err != nil {
+			return err
+		}
+	}
+
+	jobName, env, err := session.RunJob(ctx, c.args.name, c.args.namespace, c.kind, c.container, mountPoint)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// The job must be deleted even when the user hits <ctrl>-C
+		if err := session.StopJob(dcontext.WithoutCancel(ctx), jobName); err != nil {
+			dlog.Errorf(ctx, "unable to stop job %s: %v", jobName, err)
+		}
+	}()
+
+	is.env = env
+	if c.args.envFile != "" {
+		if err = is.writeEnvFile(); err != nil {
+			return err
+		}
+	}
+	if c.args.envJSON != "" {
+		if err = is.writeEnvJSON(); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pc, err := proc.Start(ctx, is.env, c.args.cmdline[0], c.args.cmdline[1:]...)
+	if err != nil {
+		dlog.Errorf(ctx, "error starting job process: %v", err)
+		return err
+	}
+
+	// The external command will not output anything to the logs. An error here
+	// is likely caused by the user hitting <ctrl>-C to terminate the process.
+	if err = proc.Wait(ctx, cancel, pc); err != nil {
+		return errcat.NoDaemonLogs.New(err)
+	}
+	return nil
+}
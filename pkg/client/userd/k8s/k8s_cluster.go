@@ -10,6 +10,7 @@ import (
 
 	"github.com/blang/semver"
 	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/datawire/dlib/dlog"
@@ -30,6 +31,9 @@ type Cluster struct {
 	// Main
 	ki kubernetes.Interface
 
+	// Used for workloads declared as custom resources, such as Argo Rollouts
+	di dynamic.Interface
+
 	// Current Namespace snapshot, get set by namespace Watcher.
 	// The boolean value indicates if this client is allowed to
 	// watch services and retrieve workloads in the namespace
@@ -126,7 +130,12 @@ func NewCluster(c context.Context, kubeFlags *Config, namespaces []string) (*Clu
 	if err != nil {
 		return nil, err
 	}
+	di, err := dynamic.NewForConfig(rs)
+	if err != nil {
+		return nil, err
+	}
 	c = k8sapi.WithK8sInterface(c, cs)
+	c = k8sapi.WithDynamicInterface(c, di)
 
 	if len(namespaces) == 1 && namespaces[0] == "all" {
 		namespaces = nil
@@ -138,6 +147,7 @@ func NewCluster(c context.Context, kubeFlags *Config, namespaces []string) (*Clu
 		Config:           kubeFlags,
 		mappedNamespaces: namespaces,
 		ki:               cs,
+		di:               di,
 	}
 
 	timedC, cancel := client.GetConfig(c).Timeouts.TimeoutContext(c, client.TimeoutClusterConnect)
@@ -182,5 +192,5 @@ func (kc *Cluster) GetClusterId(ctx context.Context) string {
 }
 
 func (kc *Cluster) WithK8sInterface(c context.Context) context.Context {
-	return k8sapi.WithK8sInterface(c, kc.ki)
+	return k8sapi.WithDynamicInterface(k8sapi.WithK8sInterface(c, kc.ki), kc.di)
 }
@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// httpConnect reads an HTTP CONNECT request from the given connection and returns the connection to the
+// requested destination. Other methods are rejected, because the proxy only provides tunnels.
+func (s *Server) httpConnect(ctx context.Context, conn *bufferedConn) (net.Conn, error) {
+	rq, err := http.ReadRequest(conn.r)
+	if err != nil {
+		httpReply(conn, http.StatusBadRequest)
+		return nil, fmt.Errorf("failed to read HTTP request: %w", err)
+	}
+	if rq.Method != http.MethodConnect {
+		httpReply(conn, http.StatusMethodNotAllowed)
+		return nil, fmt.Errorf("unsupported HTTP method %s", rq.Method)
+	}
+	host, ps, err := net.SplitHostPort(rq.Host)
+	if err != nil {
+		httpReply(conn, http.StatusBadRequest)
+		return nil, fmt.Errorf("invalid CONNECT host %q: %w", rq.Host, err)
+	}
+	port, err := strconv.ParseUint(ps, 10, 16)
+	if err != nil {
+		httpReply(conn, http.StatusBadRequest)
+		return nil, fmt.Errorf("invalid CONNECT port %q: %w", ps, err)
+	}
+
+	remote, err := s.connect(ctx, conn, host, uint16(port))
+	if err != nil {
+		code := http.StatusBadGateway
+		var re *resolveError
+		if errors.As(err, &re) {
+			code = http.StatusNotFound
+		}
+		httpReply(conn, code)
+		return nil, err
+	}
+	if _, err = fmt.Fprintf(conn, "HTTP/%d.%d 200 Connection established\r\n\r\n", rq.ProtoMajor, rq.ProtoMinor); err != nil {
+		_ = remote.Close()
+		return nil, err
+	}
+	return remote, nil
+}
+
+func httpReply(conn net.Conn, code int) {
+	_, _ = fmt.Fprintf(conn, "HTTP/1.1 %d %s\r\nContent-Length: 0\r\nConnection: close\r\n\r\n", code, http.StatusText(code))
+}
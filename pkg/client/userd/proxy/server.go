@@ -0,0 +1,162 @@
+// Package proxy implements a SOCKS5 and HTTP CONNECT proxy that gives processes on the workstation access
+// to the cluster without the need for a TUN device, routes, or changes to the DNS configuration. Both
+// protocols are served on the same port, and the protocol is determined by the first byte that the
+// client sends.
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// Resolver resolves the given host name into IP addresses.
+type Resolver func(ctx context.Context, host string) ([]net.IP, error)
+
+// Dialer opens a connection from the given source to the given destination. The connection must remain
+// open until it is closed or the context is cancelled.
+type Dialer func(ctx context.Context, src, dst *net.TCPAddr) (net.Conn, error)
+
+// Server is a SOCKS5 and HTTP CONNECT proxy server.
+type Server struct {
+	resolve Resolver
+	dial    Dialer
+}
+
+// NewServer returns a Server that uses the given Resolver to resolve host names and the given Dialer
+// to connect to the resolved addresses.
+func NewServer(resolve Resolver, dial Dialer) *Server {
+	return &Server{resolve: resolve, dial: dial}
+}
+
+// Serve accepts connections on the given listener until the context is cancelled. The listener is closed
+// when Serve returns.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	go func() {
+		<-ctx.Done()
+		_ = l.Close()
+	}()
+	dlog.Infof(ctx, "Proxy listening on %s", l.Addr())
+
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("proxy failed to accept connection: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.handle(ctx, conn)
+		}()
+	}
+}
+
+// bufferedConn is a net.Conn that reads through a bufio.Reader, so that bytes that were buffered
+// while reading the handshake aren't lost.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	bc := &bufferedConn{Conn: conn, r: bufio.NewReader(conn)}
+	first, err := bc.r.Peek(1)
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			dlog.Errorf(ctx, "proxy failed to read from %s: %v", conn.RemoteAddr(), err)
+		}
+		return
+	}
+
+	var remote net.Conn
+	if first[0] == socksVersion {
+		remote, err = s.socks5(ctx, bc)
+	} else {
+		remote, err = s.httpConnect(ctx, bc)
+	}
+	if err != nil {
+		dlog.Debugf(ctx, "proxy connection from %s failed: %v", conn.RemoteAddr(), err)
+		return
+	}
+	defer remote.Close()
+	pipe(bc, remote)
+}
+
+// connect resolves the given host and dials the resulting address on the given port.
+func (s *Server) connect(ctx context.Context, conn net.Conn, host string, port uint16) (net.Conn, error) {
+	ip := iputil.Parse(host)
+	if ip == nil {
+		ips, err := s.resolve(ctx, host)
+		if err != nil {
+			return nil, &resolveError{host: host, err: err}
+		}
+		if len(ips) == 0 {
+			return nil, &resolveError{host: host, err: errors.New("no such host")}
+		}
+		ip = ips[0]
+	}
+	src, _ := conn.RemoteAddr().(*net.TCPAddr)
+	if src == nil {
+		src = &net.TCPAddr{IP: net.IP{127, 0, 0, 1}}
+	}
+	dst := &net.TCPAddr{IP: ip, Port: int(port)}
+	dlog.Debugf(ctx, "proxy connecting %s to %s", src, net.JoinHostPort(host, strconv.Itoa(int(port))))
+	remote, err := s.dial(ctx, src, dst)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", dst, err)
+	}
+	return remote, nil
+}
+
+type resolveError struct {
+	host string
+	err  error
+}
+
+func (e *resolveError) Error() string {
+	return fmt.Sprintf("unable to resolve %q: %v", e.host, e.err)
+}
+
+func (e *resolveError) Unwrap() error {
+	return e.err
+}
+
+// pipe copies data in both directions between the two connections until one of them is closed, and
+// then closes both.
+func pipe(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	cp := func(dst, src net.Conn) {
+		_, _ = io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go cp(a, b)
+	go cp(b, a)
+	<-done
+	_ = a.Close()
+	_ = b.Close()
+	<-done
+}
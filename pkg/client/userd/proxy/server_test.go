@@ -0,0 +1,208 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	xproxy "golang.org/x/net/proxy"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+// startEcho starts a TCP server on the loopback interface that echoes everything that it receives.
+func startEcho(t *testing.T) *net.TCPAddr {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr)
+}
+
+// startProxy starts a proxy server that resolves "echo.cluster" to the loopback address and dials
+// the destination directly. It returns the address of the proxy, and a function that returns the
+// destination of the last dial.
+func startProxy(t *testing.T) (string, func() *net.TCPAddr) {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	dials := make(chan *net.TCPAddr, 10)
+	resolve := func(_ context.Context, host string) ([]net.IP, error) {
+		if host == "echo.cluster" {
+			return []net.IP{{127, 0, 0, 1}}, nil
+		}
+		return nil, nil
+	}
+	dial := func(ctx context.Context, _, dst *net.TCPAddr) (net.Conn, error) {
+		dials <- dst
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", dst.String())
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(resolve, dial).Serve(ctx, l)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+	return l.Addr().String(), func() *net.TCPAddr {
+		select {
+		case dst := <-dials:
+			return dst
+		default:
+			return nil
+		}
+	}
+}
+
+func assertEcho(t *testing.T, conn net.Conn) {
+	t.Helper()
+	msg := []byte("hello proxy")
+	_, err := conn.Write(msg)
+	require.NoError(t, err)
+	reply := make([]byte, len(msg))
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, msg, reply)
+}
+
+func TestServer_SOCKS5(t *testing.T) {
+	echo := startEcho(t)
+	proxyAddr, lastDial := startProxy(t)
+	d, err := xproxy.SOCKS5("tcp", proxyAddr, nil, xproxy.Direct)
+	require.NoError(t, err)
+
+	t.Run("ip", func(t *testing.T) {
+		conn, err := d.Dial("tcp", echo.String())
+		require.NoError(t, err)
+		defer conn.Close()
+		assertEcho(t, conn)
+		assert.Equal(t, echo.String(), lastDial().String())
+	})
+
+	t.Run("domain", func(t *testing.T) {
+		conn, err := d.Dial("tcp", fmt.Sprintf("echo.cluster:%d", echo.Port))
+		require.NoError(t, err)
+		defer conn.Close()
+		assertEcho(t, conn)
+		assert.Equal(t, echo.String(), lastDial().String())
+	})
+
+	t.Run("unknown domain", func(t *testing.T) {
+		_, err := d.Dial("tcp", fmt.Sprintf("unknown.cluster:%d", echo.Port))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "host unreachable")
+		assert.Nil(t, lastDial())
+	})
+}
+
+func TestServer_SOCKS5_IPv6(t *testing.T) {
+	proxyAddr, lastDial := startProxy(t)
+	conn, err := net.Dial("tcp", proxyAddr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte{socksVersion, 1, socksMethodNoAuth})
+	require.NoError(t, err)
+	reply := make([]byte, 2)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{socksVersion, socksMethodNoAuth}, reply)
+
+	// The dial to the unused port on the IPv6 loopback is expected to fail, but the address must be
+	// correctly parsed.
+	rq := []byte{socksVersion, socksCmdConnect, 0, socksAddrIPv6}
+	rq = append(rq, net.IPv6loopback...)
+	rq = append(rq, 0, 1)
+	_, err = conn.Write(rq)
+	require.NoError(t, err)
+	reply = make([]byte, 10)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, byte(socksGeneralFailure), reply[1])
+	assert.Equal(t, "[::1]:1", lastDial().String())
+}
+
+func TestServer_SOCKS5_unsupportedCommand(t *testing.T) {
+	proxyAddr, lastDial := startProxy(t)
+	conn, err := net.Dial("tcp", proxyAddr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Greeting followed by a BIND request
+	_, err = conn.Write([]byte{
+		socksVersion, 1, socksMethodNoAuth,
+		socksVersion, 2, 0, socksAddrIPv4, 127, 0, 0, 1, 0, 80,
+	})
+	require.NoError(t, err)
+	reply := make([]byte, 12)
+	_, err = io.ReadFull(conn, reply)
+	require.NoError(t, err)
+	assert.Equal(t, byte(socksCommandNotSupported), reply[3])
+	assert.Nil(t, lastDial())
+}
+
+func TestServer_HTTPConnect(t *testing.T) {
+	echo := startEcho(t)
+	proxyAddr, lastDial := startProxy(t)
+
+	connect := func(t *testing.T, target string) (net.Conn, *http.Response) {
+		conn, err := net.Dial("tcp", proxyAddr)
+		require.NoError(t, err)
+		_, err = fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", target, target)
+		require.NoError(t, err)
+		rsp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		require.NoError(t, err)
+		return conn, rsp
+	}
+
+	t.Run("domain", func(t *testing.T) {
+		conn, rsp := connect(t, fmt.Sprintf("echo.cluster:%d", echo.Port))
+		defer conn.Close()
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+		assertEcho(t, conn)
+		assert.Equal(t, echo.String(), lastDial().String())
+	})
+
+	t.Run("unknown domain", func(t *testing.T) {
+		conn, rsp := connect(t, "unknown.cluster:80")
+		defer conn.Close()
+		assert.Equal(t, http.StatusNotFound, rsp.StatusCode)
+		assert.Nil(t, lastDial())
+	})
+
+	t.Run("missing port", func(t *testing.T) {
+		conn, rsp := connect(t, "echo.cluster")
+		defer conn.Close()
+		assert.Equal(t, http.StatusBadRequest, rsp.StatusCode)
+	})
+
+	t.Run("not connect", func(t *testing.T) {
+		conn, err := net.Dial("tcp", proxyAddr)
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = fmt.Fprintf(conn, "GET http://echo.cluster/ HTTP/1.1\r\nHost: echo.cluster\r\n\r\n")
+		require.NoError(t, err)
+		rsp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusMethodNotAllowed, rsp.StatusCode)
+	})
+}
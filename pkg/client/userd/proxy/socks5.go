@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// SOCKS5 protocol constants. See https://www.rfc-editor.org/rfc/rfc1928
+const (
+	socksVersion = 5
+
+	socksMethodNoAuth       = 0
+	socksMethodNoAcceptable = 0xff
+
+	socksCmdConnect = 1
+
+	socksAddrIPv4   = 1
+	socksAddrDomain = 3
+	socksAddrIPv6   = 4
+
+	socksSucceeded               = 0
+	socksGeneralFailure          = 1
+	socksHostUnreachable         = 4
+	socksCommandNotSupported     = 7
+	socksAddressTypeNotSupported = 8
+)
+
+// socks5 performs the SOCKS5 handshake on the given connection and returns the connection to the
+// requested destination. Only the CONNECT command without authentication is supported.
+func (s *Server) socks5(ctx context.Context, conn net.Conn) (net.Conn, error) {
+	// Method selection: VER, NMETHODS, METHODS...
+	hdr := make([]byte, 2)
+	if _, err := io.ReadFull(conn, hdr); err != nil {
+		return nil, err
+	}
+	methods := make([]byte, hdr[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return nil, err
+	}
+	method := byte(socksMethodNoAcceptable)
+	for _, m := range methods {
+		if m == socksMethodNoAuth {
+			method = socksMethodNoAuth
+			break
+		}
+	}
+	if _, err := conn.Write([]byte{socksVersion, method}); err != nil {
+		return nil, err
+	}
+	if method == socksMethodNoAcceptable {
+		return nil, errors.New("SOCKS5 client doesn't accept unauthenticated connections")
+	}
+
+	// Request: VER, CMD, RSV, ATYP, DST.ADDR, DST.PORT
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return nil, err
+	}
+	if req[0] != socksVersion {
+		return nil, fmt.Errorf("unsupported SOCKS version %d", req[0])
+	}
+	var host string
+	switch req[3] {
+	case socksAddrIPv4, socksAddrIPv6:
+		ip := make(net.IP, net.IPv4len)
+		if req[3] == socksAddrIPv6 {
+			ip = make(net.IP, net.IPv6len)
+		}
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return nil, err
+		}
+		host = ip.String()
+	case socksAddrDomain:
+		n := make([]byte, 1)
+		if _, err := io.ReadFull(conn, n); err != nil {
+			return nil, err
+		}
+		name := make([]byte, n[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return nil, err
+		}
+		host = string(name)
+	default:
+		_ = socksReply(conn, socksAddressTypeNotSupported)
+		return nil, fmt.Errorf("unsupported SOCKS5 address type %d", req[3])
+	}
+	pb := make([]byte, 2)
+	if _, err := io.ReadFull(conn, pb); err != nil {
+		return nil, err
+	}
+	port := binary.BigEndian.Uint16(pb)
+
+	if req[1] != socksCmdConnect {
+		_ = socksReply(conn, socksCommandNotSupported)
+		return nil, fmt.Errorf("unsupported SOCKS5 command %d", req[1])
+	}
+
+	remote, err := s.connect(ctx, conn, host, port)
+	if err != nil {
+		rep := byte(socksGeneralFailure)
+		var re *resolveError
+		if errors.As(err, &re) {
+			rep = socksHostUnreachable
+		}
+		_ = socksReply(conn, rep)
+		return nil, err
+	}
+	if err = socksReply(conn, socksSucceeded); err != nil {
+		_ = remote.Close()
+		return nil, err
+	}
+	return remote, nil
+}
+
+// socksReply writes a reply with the given code. The bound address is always reported as 0.0.0.0:0
+// since it has no meaning for a connection that is tunneled to the cluster.
+func socksReply(conn net.Conn, rep byte) error {
+	_, err := conn.Write([]byte{socksVersion, rep, 0, socksAddrIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
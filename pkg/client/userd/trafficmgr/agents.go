@@ -19,6 +19,7 @@ import (
 	rpc "github.com/telepresenceio/telepresence/rpc/v2/connector"
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/watchstate"
 )
 
 // getCurrentAgents returns a copy of the current agent snapshot
@@ -99,7 +100,23 @@ func (tm *TrafficManager) notifyAgentWatchers(ctx context.Context, agents []*man
 	}
 }
 
-func (tm *TrafficManager) watchAgentsNS(ctx context.Context) error {
+// agentsWatch is the state of the agent watcher. It is retained when the stream is interrupted, so
+// that the watch can be resumed using deltas.
+type agentsWatch struct {
+	// namespaces are the namespaces of the last watch. A watch of other namespaces can't be resumed.
+	namespaces []string
+
+	// agents are the agents of the last received snapshot, keyed by session ID.
+	agents map[string]*manager.AgentInfo
+
+	// revision is the revision of the last received snapshot.
+	revision uint64
+
+	// sinceSupported is false when the traffic-manager doesn't implement WatchAgentsSince.
+	sinceSupported bool
+}
+
+func (tm *TrafficManager) watchAgentsNS(ctx context.Context, aw *agentsWatch) error {
 	// Cancel this watcher whenever the set of active namespaces change
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -127,6 +144,27 @@ func (tm *TrafficManager) watchAgentsNS(ctx context.Context) error {
 		}
 	}
 
+	if !stringSlicesEqual(aw.namespaces, nss) {
+		aw.namespaces = nss
+		aw.agents = nil
+		aw.revision = 0
+	}
+	if aw.sinceSupported {
+		err := tm.watchAgentsSince(ctx, aw, opts)
+		if status.Code(err) != codes.Unimplemented {
+			if ctx.Err() == nil && err != nil && !errors.Is(err, io.EOF) {
+				dlog.Errorf(ctx, "manager.WatchAgentsSince recv: %v", err)
+			} else {
+				err = nil
+			}
+			tm.setCurrentAgents(ctx, nil)
+			return err
+		}
+		// Older traffic-manager. Fall back to complete snapshots.
+		dlog.Debug(ctx, "WatchAgentsSince is not implemented by the traffic-manager")
+		aw.sinceSupported = false
+	}
+
 	wm := "WatchAgentsNS"
 	stream, err := tm.managerClient.WatchAgentsNS(ctx, &manager.AgentsRequest{
 		Session:    tm.session(),
@@ -165,6 +203,43 @@ func (tm *TrafficManager) watchAgentsNS(ctx context.Context) error {
 	return nil
 }
 
+// watchAgentsSince watches the agents in the namespaces of the given agentsWatch, and resumes from
+// its revision.
+func (tm *TrafficManager) watchAgentsSince(ctx context.Context, aw *agentsWatch, opts []grpc.CallOption) error {
+	stream, err := tm.managerClient.WatchAgentsSince(ctx, &manager.WatchSinceRequest{
+		Session:       tm.session(),
+		SinceRevision: aw.revision,
+		Namespaces:    aw.namespaces,
+	}, opts...)
+	if err != nil {
+		return err
+	}
+	for ctx.Err() == nil {
+		snapshot, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		aw.agents = watchstate.ApplyAgents(aw.agents, snapshot)
+		aw.revision = snapshot.Revision
+		agents := watchstate.SortedAgents(aw.agents)
+		tm.setCurrentAgents(ctx, agents)
+		tm.notifyAgentWatchers(ctx, agents)
+	}
+	return nil
+}
+
+func stringSlicesEqual(as, bs []string) bool {
+	if len(as) != len(bs) {
+		return false
+	}
+	for i, a := range as {
+		if a != bs[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (tm *TrafficManager) watchAgents(ctx context.Context, opts []grpc.CallOption) error {
 	stream, err := tm.managerClient.WatchAgents(ctx, tm.session(), opts...)
 	if err != nil {
@@ -184,8 +259,9 @@ func (tm *TrafficManager) watchAgents(ctx context.Context, opts []grpc.CallOptio
 
 func (tm *TrafficManager) agentInfoWatcher(ctx context.Context) error {
 	backoff := 100 * time.Millisecond
+	aw := &agentsWatch{sinceSupported: true}
 	for ctx.Err() == nil {
-		if err := tm.watchAgentsNS(ctx); err != nil {
+		if err := tm.watchAgentsNS(ctx, aw); err != nil {
 			dlog.Error(ctx, err)
 			dtime.SleepWithContext(ctx, backoff)
 			backoff *= 2
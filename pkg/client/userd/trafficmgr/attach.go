@@ -0,0 +1,92 @@
+package trafficmgr
+
+import (
+	"context"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
+)
+
+// AttachIntercept attaches this client to the active intercept of the given workload. The intercept is owned by
+// another client. Once attached, the intercept will show up in the intercept snapshots from the traffic-manager, and
+// its remote volumes will be mounted on the given mountPoint unless it is empty. The given targetPort is the local
+// port that receives the traffic if the owner hands over the intercept to this client.
+func (tm *TrafficManager) AttachIntercept(ctx context.Context, workload, namespace string, targetPort int32, mountPoint string) (*manager.InterceptInfo, error) {
+	ns := tm.ActualNamespace(namespace)
+	if ns == "" {
+		return nil, errcat.User.Newf("namespace %q is not accessible", namespace)
+	}
+
+	key := workload + "." + ns
+	if mountPoint != "" {
+		// The intercept snapshot that contains this client as attached may arrive before the
+		// AttachIntercept call returns, so the mount point is registered up front and claimed
+		// by the worker that starts the mounts.
+		if prev, loaded := tm.mountPoints.Load(mountPoint); loaded {
+			return nil, errcat.User.Newf("mount point %s is already in use by intercept %s", mountPoint, prev.(string))
+		}
+		tm.pendingAttachMounts.Store(key, mountPoint)
+	}
+
+	ii, err := tm.managerClient.AttachIntercept(ctx, &manager.AttachInterceptRequest{
+		Session:    tm.session(),
+		Agent:      workload,
+		Namespace:  ns,
+		TargetPort: targetPort,
+	})
+	if err != nil {
+		tm.pendingAttachMounts.Delete(key)
+		return nil, err
+	}
+	dlog.Debugf(ctx, "attached to intercept %s", ii.Id)
+	if mountPoint != "" && ii.SftpPort > 0 {
+		ii.ClientMountPoint = mountPoint
+	}
+	return ii, nil
+}
+
+// claimAttachMount registers the pending mount point of an intercept that this client has attached to.
+func (tm *TrafficManager) claimAttachMount(ii *manager.InterceptInfo) {
+	if ii.ClientSession.SessionId == tm.session().SessionId {
+		return
+	}
+	if mp, loaded := tm.pendingAttachMounts.LoadAndDelete(ii.Spec.Agent + "." + ii.Spec.Namespace); loaded {
+		tm.mountPoints.Store(mp, ii.Spec.Name)
+	}
+}
+
+// HandoverIntercept hands the traffic of the intercept with the given name over to the attached client that has
+// the given name or session ID. An empty client routes the traffic back to this client.
+func (tm *TrafficManager) HandoverIntercept(ctx context.Context, name, client string) (*manager.InterceptInfo, error) {
+	var ii *manager.InterceptInfo
+	for _, cept := range tm.getCurrentIntercepts() {
+		if cept.Spec.Name == name && cept.ClientSession.SessionId == tm.session().SessionId {
+			ii = cept
+			break
+		}
+	}
+	if ii == nil {
+		return nil, errcat.User.Newf("this client has no intercept named %q", name)
+	}
+
+	sessionID := ""
+	if client != "" {
+		for _, ac := range ii.AttachedClients {
+			if ac.Client == client || ac.Session.SessionId == client {
+				if sessionID != "" {
+					return nil, errcat.User.Newf("more than one client named %q is attached to intercept %q, please use the session ID", client, name)
+				}
+				sessionID = ac.Session.SessionId
+			}
+		}
+		if sessionID == "" {
+			return nil, errcat.User.Newf("no client %q is attached to intercept %q", client, name)
+		}
+	}
+	return tm.managerClient.HandoverIntercept(ctx, &manager.HandoverInterceptRequest{
+		Session:   tm.session(),
+		Name:      name,
+		SessionId: sessionID,
+	})
+}
@@ -0,0 +1,25 @@
+package trafficmgr
+
+import (
+	"context"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
+)
+
+// DryRunInject asks the traffic-manager for the agent config and the pod patch that its agent injector would
+// produce for the workload with the given name, namespace, and optional kind. The given YAML or JSON encoded
+// workload is used instead of the workload in the cluster when it isn't empty. Nothing is changed in the cluster.
+func (tm *TrafficManager) DryRunInject(ctx context.Context, name, namespace, kind string, workload []byte) (*manager.DryRunInjectResponse, error) {
+	ns := tm.ActualNamespace(namespace)
+	if ns == "" {
+		return nil, errcat.User.Newf("namespace %q is not accessible", namespace)
+	}
+	return tm.managerClient.DryRunInject(ctx, &manager.DryRunInjectRequest{
+		Session:   tm.session(),
+		Name:      name,
+		Namespace: ns,
+		Kind:      kind,
+		Workload:  workload,
+	})
+}
@@ -44,6 +44,7 @@ import (
 	"github.com/telepresenceio/telepresence/v2/pkg/matcher"
 	"github.com/telepresenceio/telepresence/v2/pkg/proc"
 	"github.com/telepresenceio/telepresence/v2/pkg/restapi"
+	"github.com/telepresenceio/telepresence/v2/pkg/watchstate"
 )
 
 type forwardKey struct {
@@ -131,33 +132,41 @@ func (lpf livePortForwards) cancelUnwanted(ctx context.Context) {
 func (tm *TrafficManager) reconcileMountPoints(ctx context.Context, existingIntercepts map[string]struct{}) {
 	var mountsToDelete []any
 	tm.mountPoints.Range(func(key, value any) bool {
-		if _, ok := existingIntercepts[value.(string)]; !ok {
-			mountsToDelete = append(mountsToDelete, key)
+		name := value.(string)
+		if _, ok := existingIntercepts[name]; !ok {
+			if _, isJobRun := tm.jobRuns.Load(name); !isJobRun {
+				mountsToDelete = append(mountsToDelete, key)
+			}
 		}
 		return true
 	})
 
 	for _, key := range mountsToDelete {
-		if _, loaded := tm.mountPoints.LoadAndDelete(key); loaded {
-			// Execute the removal in a separate go-routine so that we don't hang the daemon in case
-			// the removal hangs on a "resource busy".
-			go func(mountPoint string) {
-				if runtime.GOOS == "darwin" {
-					//  macFUSE will sometimes not unmount in a timely manner so we do this to avoid "resource busy" and
-					//  "Device not configured" errors.
-					_ = proc.CommandContext(ctx, "umount", mountPoint).Run()
-				}
-				err := os.Remove(mountPoint)
-				switch {
-				case err == nil:
-					dlog.Infof(ctx, "Removed file system mount %q", mountPoint)
-				case os.IsNotExist(err):
-					dlog.Infof(ctx, "File system mount %q no longer exists", mountPoint)
-				default:
-					dlog.Errorf(ctx, "Failed to remove mount point %q: %v", mountPoint, err)
-				}
-			}(key.(string))
-		}
+		tm.removeMountPoint(ctx, key.(string))
+	}
+}
+
+// removeMountPoint forgets the given mount point and removes it from the file system.
+func (tm *TrafficManager) removeMountPoint(ctx context.Context, mountPoint string) {
+	if _, loaded := tm.mountPoints.LoadAndDelete(mountPoint); loaded {
+		// Execute the removal in a separate go-routine so that we don't hang the daemon in case
+		// the removal hangs on a "resource busy".
+		go func() {
+			if runtime.GOOS == "darwin" {
+				//  macFUSE will sometimes not unmount in a timely manner so we do this to avoid "resource busy" and
+				//  "Device not configured" errors.
+				_ = proc.CommandContext(ctx, "umount", mountPoint).Run()
+			}
+			err := os.Remove(mountPoint)
+			switch {
+			case err == nil:
+				dlog.Infof(ctx, "Removed file system mount %q", mountPoint)
+			case os.IsNotExist(err):
+				dlog.Infof(ctx, "File system mount %q no longer exists", mountPoint)
+			default:
+				dlog.Errorf(ctx, "Failed to remove mount point %q: %v", mountPoint, err)
+			}
+		}()
 	}
 }
 
@@ -171,8 +180,22 @@ func (tm *TrafficManager) workerPortForwardIntercepts(ctx context.Context) error
 	//     management on top anyway, so dgroup wouldn't actually save us any complexity.
 	portForwards := newPortForwards()
 	backoff := 100 * time.Millisecond
+
+	// The intercepts and revision of the last received snapshot. They are retained when the
+	// stream is interrupted, so that the watch can be resumed using deltas.
+	var current map[string]*manager.InterceptInfo
+	var revision uint64
+	sinceSupported := true
 	for ctx.Err() == nil {
-		stream, err := tm.managerClient.WatchIntercepts(ctx, tm.session())
+		var stream interface {
+			Recv() (*manager.InterceptInfoSnapshot, error)
+		}
+		var err error
+		if sinceSupported {
+			stream, err = tm.managerClient.WatchInterceptsSince(ctx, &manager.WatchSinceRequest{Session: tm.session(), SinceRevision: revision})
+		} else {
+			stream, err = tm.managerClient.WatchIntercepts(ctx, tm.session())
+		}
 		if err != nil {
 			err = fmt.Errorf("manager.WatchIntercepts dial: %w", err)
 		}
@@ -183,6 +206,13 @@ func (tm *TrafficManager) workerPortForwardIntercepts(ctx context.Context) error
 
 			if err != nil {
 				if ctx.Err() == nil {
+					if sinceSupported && grpcStatus.Code(err) == grpcCodes.Unimplemented {
+						// Older traffic-manager. Fall back to complete snapshots.
+						dlog.Debug(ctx, "WatchInterceptsSince is not implemented by the traffic-manager")
+						sinceSupported = false
+						err = nil
+						break
+					}
 					if !errors.Is(err, io.EOF) {
 						err = fmt.Errorf("manager.WatchIntercepts recv: %w", err)
 					}
@@ -191,7 +221,9 @@ func (tm *TrafficManager) workerPortForwardIntercepts(ctx context.Context) error
 				// context is cancelled. Continue as if we had an empty snapshot. This
 				// will ensure that volume mounts are cancelled correctly.
 			} else {
-				intercepts = snapshot.Intercepts
+				current = watchstate.ApplyIntercepts(current, snapshot)
+				revision = snapshot.Revision
+				intercepts = watchstate.SortedIntercepts(current)
 			}
 			tm.setCurrentIntercepts(ctx, intercepts)
 
@@ -207,7 +239,7 @@ func (tm *TrafficManager) workerPortForwardIntercepts(ctx context.Context) error
 				switch intercept.Disposition {
 				case manager.InterceptDispositionType_ACTIVE:
 					// do nothing
-				case manager.InterceptDispositionType_WAITING:
+				case manager.InterceptDispositionType_WAITING, manager.InterceptDispositionType_QUEUED:
 					continue
 				default:
 					iceptError = fmt.Errorf("intercept in error state %v: %v", intercept.Disposition, intercept.Message)
@@ -230,8 +262,10 @@ func (tm *TrafficManager) workerPortForwardIntercepts(ctx context.Context) error
 				}
 				if iceptError == nil {
 					namespaces[intercept.Spec.Namespace] = struct{}{}
-					if tm.isPodDaemon {
+					if tm.isPodDaemon || tm.proxyListener != nil {
 						intercept.SftpPort = 0 // disable mount point logic
+					} else {
+						tm.claimAttachMount(intercept)
 					}
 					portForwards.start(ctx, tm, intercept)
 				}
@@ -243,7 +277,7 @@ func (tm *TrafficManager) workerPortForwardIntercepts(ctx context.Context) error
 			}
 		}
 
-		if ctx.Err() == nil {
+		if ctx.Err() == nil && err != nil {
 			dlog.Errorf(ctx, "reading port-forwards from manager: %v", err)
 			dtime.SleepWithContext(ctx, backoff)
 			backoff *= 2
@@ -561,7 +595,14 @@ func (tm *TrafficManager) AddIntercept(c context.Context, ir *rpc.CreateIntercep
 	tos := &client.GetConfig(c).Timeouts
 	spec.RoundtripLatency = int64(tos.Get(client.TimeoutRoundtripLatency)) * 2 // Account for extra hop
 	spec.DialTimeout = int64(tos.Get(client.TimeoutEndpointDial))
-	c, cancel := tos.TimeoutContext(c, client.TimeoutIntercept)
+	var cancel context.CancelFunc
+	if ir.Wait {
+		// A queued intercept may have to wait for an unknown amount of time before it
+		// becomes active, so the intercept timeout doesn't apply.
+		c, cancel = context.WithCancel(c)
+	} else {
+		c, cancel = tos.TimeoutContext(c, client.TimeoutIntercept)
+	}
 	defer cancel()
 
 	// The agent is in place and the traffic-manager has acknowledged the creation of the intercept. It
@@ -579,6 +620,7 @@ func (tm *TrafficManager) AddIntercept(c context.Context, ir *rpc.CreateIntercep
 		Session:       tm.session(),
 		InterceptSpec: spec,
 		ApiKey:        svcProps.apiKey,
+		Wait:          ir.Wait,
 	})
 	if err != nil {
 		dlog.Debugf(c, "manager responded to CreateIntercept with error %v", err)
@@ -593,6 +635,9 @@ func (tm *TrafficManager) AddIntercept(c context.Context, ir *rpc.CreateIntercep
 	}
 
 	dlog.Debugf(c, "created intercept %s", ii.Spec.Name)
+	if ii.Disposition == manager.InterceptDispositionType_QUEUED {
+		dlog.Infof(c, "intercept %s: %s", ii.Spec.Name, ii.Message)
+	}
 
 	success := false
 	defer func() {
@@ -609,7 +654,7 @@ func (tm *TrafficManager) AddIntercept(c context.Context, ir *rpc.CreateIntercep
 		}
 	}()
 
-	// Wait for the intercept to transition from WAITING or NO_AGENT to ACTIVE. This
+	// Wait for the intercept to transition from QUEUED, WAITING, or NO_AGENT to ACTIVE. This
 	// might result in more than one event.
 	for {
 		select {
@@ -819,6 +864,16 @@ func (tm *TrafficManager) removeIntercept(c context.Context, ii *manager.Interce
 		}
 	}
 
+	if ii.ClientSession.SessionId != tm.session().SessionId {
+		// This client is attached to an intercept owned by another client
+		dlog.Debugf(c, "telling manager to detach from intercept %s", name)
+		_, err := tm.managerClient.DetachIntercept(c, &manager.DetachInterceptRequest{
+			Session:     tm.session(),
+			InterceptId: ii.Id,
+		})
+		return err
+	}
+
 	dlog.Debugf(c, "telling manager to remove intercept %s", name)
 	_, err := tm.managerClient.RemoveIntercept(c, &manager.RemoveInterceptRequest2{
 		Session: tm.session(),
@@ -867,7 +922,7 @@ func (tm *TrafficManager) InterceptsForWorkload(workloadName, namespace string)
 	return wlis
 }
 
-// ClearIntercepts removes all intercepts
+// ClearIntercepts removes all intercepts and stops all jobs that were started using RunJob
 func (tm *TrafficManager) ClearIntercepts(c context.Context) error {
 	for _, cept := range tm.getCurrentIntercepts() {
 		dlog.Debugf(c, "Clearing intercept %s", cept.Spec.Name)
@@ -876,6 +931,7 @@ func (tm *TrafficManager) ClearIntercepts(c context.Context) error {
 			return err
 		}
 	}
+	tm.stopJobRuns(c)
 	return nil
 }
 
@@ -0,0 +1,85 @@
+package trafficmgr
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/userd/proxy"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// checkProxyAddress returns an error unless the host of the given proxy address is "localhost" or a loopback IP, or
+// allowRemote is true. The proxy doesn't authenticate its clients, so when it listens on another address, everyone
+// that can reach that address can also reach the cluster.
+func checkProxyAddress(address string, allowRemote bool) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errcat.User.Newf("invalid proxy address %s: %w", address, err)
+	}
+	if allowRemote || host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return errcat.User.Newf(
+		"proxy address %s is not a loopback address, and the proxy doesn't authenticate its clients. "+
+			"Use --proxy-allow-remote to listen on it anyway", address)
+}
+
+// proxyAddress returns the address of the SOCKS5 and HTTP CONNECT proxy, or an empty string when the
+// session isn't connected in proxy mode.
+func (tm *TrafficManager) proxyAddress() string {
+	if tm.proxyListener == nil {
+		return ""
+	}
+	return tm.proxyListener.Addr().String()
+}
+
+// serveProxy serves SOCKS5 and HTTP CONNECT requests on the proxy listener until the context is cancelled.
+func (tm *TrafficManager) serveProxy(ctx context.Context) error {
+	return proxy.NewServer(tm.proxyLookup, tm.proxyDial).Serve(ctx, tm.proxyListener)
+}
+
+// proxyLookup resolves the given host using the traffic-manager.
+func (tm *TrafficManager) proxyLookup(ctx context.Context, host string) ([]net.IP, error) {
+	r, err := tm.managerClient.LookupHost(ctx, &manager.LookupHostRequest{
+		Session: tm.session(),
+		Host:    host,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return iputil.IPsFromBytesSlice(r.Ips), nil
+}
+
+// proxyDial opens a tunnel.Stream to the given destination through the traffic-manager and returns
+// a net.Conn that is connected to it, once the peer of the stream has dialed the destination.
+func (tm *TrafficManager) proxyDial(ctx context.Context, src, dst *net.TCPAddr) (net.Conn, error) {
+	id := tunnel.NewConnID(ipproto.TCP, src.IP, dst.IP, uint16(src.Port), uint16(dst.Port))
+	ms, err := tm.managerClient.Tunnel(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("call to manager.Tunnel() failed. Id %s: %w", id, err)
+	}
+	tc := client.GetConfig(ctx).Timeouts
+	s, err := tunnel.NewClientStream(ctx, ms, id, tm.session().SessionId, tc.Get(client.TimeoutRoundtripLatency), tc.Get(client.TimeoutEndpointDial))
+	if err != nil {
+		return nil, err
+	}
+	if err = tunnel.AwaitDial(ctx, s); err != nil {
+		_ = s.CloseSend(ctx)
+		return nil, err
+	}
+	dlog.Debugf(ctx, "   CONN %s, proxy connection established", id)
+	conn, peer := net.Pipe()
+	d := tunnel.NewConnEndpoint(s, peer)
+	d.Start(ctx)
+	return conn, nil
+}
@@ -0,0 +1,36 @@
+package trafficmgr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckProxyAddress(t *testing.T) {
+	tests := []struct {
+		address     string
+		allowRemote bool
+		ok          bool
+	}{
+		{"localhost:1080", false, true},
+		{"127.0.0.1:1080", false, true},
+		{"127.0.0.2:1080", false, true},
+		{"[::1]:1080", false, true},
+		{":1080", false, false},
+		{"0.0.0.0:1080", false, false},
+		{"[::]:1080", false, false},
+		{"192.168.1.10:1080", false, false},
+		{"example.com:1080", false, false},
+		{":1080", true, true},
+		{"192.168.1.10:1080", true, true},
+		{"localhost", false, false},
+	}
+	for _, tt := range tests {
+		err := checkProxyAddress(tt.address, tt.allowRemote)
+		if tt.ok {
+			assert.NoError(t, err, tt.address)
+		} else {
+			assert.Error(t, err, tt.address)
+		}
+	}
+}
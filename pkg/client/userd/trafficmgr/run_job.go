@@ -0,0 +1,259 @@
+package trafficmgr
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+
+	"github.com/datawire/dlib/dcontext"
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
+	"github.com/telepresenceio/telepresence/v2/pkg/client"
+	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
+	"github.com/telepresenceio/telepresence/v2/pkg/k8sapi"
+)
+
+// jobRun is a Job that was created by RunJob.
+type jobRun struct {
+	job        k8sapi.Workload
+	mountPoint string
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// RunJob creates a copy of the given Job, or of the job template of the given CronJob, and injects a
+// traffic-agent into its pod. The app containers of the copy are suspended. The agent of the copy is
+// used to obtain the environment of the given container and to mount its volumes on the given mountPoint
+// unless it is empty. The name of the created Job is returned together with the environment. The Job
+// is deleted by StopJob.
+func (tm *TrafficManager) RunJob(ctx context.Context, name, namespace, kind, container, mountPoint string) (string, map[string]string, error) {
+	ns := tm.ActualNamespace(namespace)
+	if ns == "" {
+		return "", nil, errcat.User.Newf("namespace %q is not accessible", namespace)
+	}
+	ctx = tm.WithK8sInterface(ctx)
+	spec, err := jobSpec(ctx, name, ns, kind)
+	if err != nil {
+		return "", nil, err
+	}
+	// The app containers must exist so that the agent can provide their environment and mounts, but
+	// they must not do any work, so they use the pause image.
+	pauseImage := client.GetConfig(ctx).Images.PauseImage(ctx)
+	job, err := k8sapi.GetK8sInterface(ctx).BatchV1().Jobs(ns).Create(ctx, suspendedJob(name, ns, pauseImage, spec), meta.CreateOptions{})
+	if err != nil {
+		return "", nil, err
+	}
+	jr := &jobRun{job: k8sapi.Job(job)}
+	jobName := job.Name
+	dlog.Infof(ctx, "Created Job %s.%s", jobName, ns)
+	success := false
+	defer func() {
+		if !success {
+			if err := jr.job.Delete(dcontext.WithoutCancel(ctx)); err != nil {
+				dlog.Errorf(ctx, "unable to delete Job %s.%s: %v", jobName, ns, err)
+			}
+		}
+	}()
+
+	ai, err := tm.waitForAgent(ctx, jobName, ns)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(ai.Containers) == 0 {
+		return "", nil, errcat.User.Newf("the traffic-agent of Job %s.%s doesn't report any containers. Please upgrade the traffic-manager", jobName, ns)
+	}
+	if container == "" {
+		if len(ai.Containers) > 1 {
+			return "", nil, errcat.User.Newf("the Job %s.%s has more than one container, please use --container to select one of %s",
+				name, ns, containerNames(ai.Containers))
+		}
+		for cn := range ai.Containers {
+			container = cn
+		}
+	}
+	ci, ok := ai.Containers[container]
+	if !ok {
+		return "", nil, errcat.User.Newf("the Job %s.%s has no container named %q, please select one of %s",
+			name, ns, container, containerNames(ai.Containers))
+	}
+
+	env := make(map[string]string, len(ci.Environment)+1)
+	for k, v := range ci.Environment {
+		env[k] = v
+	}
+	runCtx, cancel := context.WithCancel(dcontext.WithoutCancel(ctx))
+	jr.cancel = cancel
+	if mountPoint != "" && ai.SftpPort > 0 {
+		if prev, loaded := tm.mountPoints.LoadOrStore(mountPoint, jobName); loaded {
+			cancel()
+			return "", nil, errcat.User.Newf("mount point %s is already in use by %s", mountPoint, prev.(string))
+		}
+		jr.mountPoint = mountPoint
+		env["TELEPRESENCE_ROOT"] = mountPoint
+	}
+	tm.jobRuns.Store(jobName, jr)
+	if jr.mountPoint != "" {
+		tm.startForwards(runCtx, &jr.wg, forwardKey{Name: jobName, PodIP: ai.PodIp}, ai.SftpPort, ci.MountPoint, nil)
+	}
+	success = true
+	return jobName, env, nil
+}
+
+// StopJob stops the mounts of a Job that was created by RunJob, deletes the Job, and removes its entry
+// from the agents ConfigMap.
+func (tm *TrafficManager) StopJob(ctx context.Context, name string) error {
+	v, loaded := tm.jobRuns.LoadAndDelete(name)
+	if !loaded {
+		return errcat.User.Newf("no job named %q was started by this client", name)
+	}
+	jr := v.(*jobRun)
+	jr.cancel()
+	jr.wg.Wait()
+	if jr.mountPoint != "" {
+		tm.removeMountPoint(ctx, jr.mountPoint)
+	}
+	ns := jr.job.GetNamespace()
+	dlog.Infof(ctx, "Deleting Job %s.%s", name, ns)
+	ctx = tm.WithK8sInterface(ctx)
+	if err := jr.job.Delete(ctx); err != nil && !k8sErrors.IsNotFound(err) {
+		return err
+	}
+	if err := removeAgentConfig(ctx, name, ns); err != nil {
+		if !k8sErrors.IsForbidden(err) {
+			return err
+		}
+		// Clients aren't necessarily allowed to modify the agents ConfigMap.
+		dlog.Warnf(ctx, "unable to remove %s from ConfigMap %s.%s: %v", name, agentconfig.ConfigMap, ns, err)
+	}
+	return nil
+}
+
+// removeAgentConfig removes the entry for the given workload from the agents ConfigMap in the given
+// namespace. The entry is created by the traffic-manager when the agent is injected, but since the
+// workload is gone, nothing else will ever remove it.
+func removeAgentConfig(ctx context.Context, name, namespace string) error {
+	api := k8sapi.GetK8sInterface(ctx).CoreV1().ConfigMaps(namespace)
+	cm, err := api.Get(ctx, agentconfig.ConfigMap, meta.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if _, ok := cm.Data[name]; !ok {
+		return nil
+	}
+	dlog.Debugf(ctx, "Deleting %s from ConfigMap %s.%s", name, agentconfig.ConfigMap, namespace)
+	delete(cm.Data, name)
+	_, err = api.Update(ctx, cm, meta.UpdateOptions{})
+	return err
+}
+
+// stopJobRuns stops all jobs that were created by RunJob.
+func (tm *TrafficManager) stopJobRuns(ctx context.Context) {
+	tm.jobRuns.Range(func(key, _ any) bool {
+		if err := tm.StopJob(ctx, key.(string)); err != nil {
+			dlog.Error(ctx, err)
+		}
+		return true
+	})
+}
+
+// jobSpec returns the spec of the given Job, or the spec of the job template of the given CronJob. Both
+// kinds are tried when kind is empty.
+func jobSpec(ctx context.Context, name, namespace, kind string) (*batch.JobSpec, error) {
+	bi := k8sapi.GetK8sInterface(ctx).BatchV1()
+	switch kind {
+	case "", "Job":
+		j, err := bi.Jobs(namespace).Get(ctx, name, meta.GetOptions{})
+		if err == nil {
+			return &j.Spec, nil
+		}
+		if kind != "" || !k8sErrors.IsNotFound(err) {
+			return nil, err
+		}
+		fallthrough
+	case "CronJob":
+		cj, err := bi.CronJobs(namespace).Get(ctx, name, meta.GetOptions{})
+		if err != nil {
+			if kind == "" && k8sErrors.IsNotFound(err) {
+				return nil, errcat.User.Newf("no Job or CronJob named %s.%s found", name, namespace)
+			}
+			return nil, err
+		}
+		return &cj.Spec.JobTemplate.Spec, nil
+	default:
+		return nil, errcat.User.Newf("unsupported kind %q, must be Job or CronJob", kind)
+	}
+}
+
+// suspendedJob returns a Job that uses a copy of the pod template in the given spec. The traffic-agent is
+// injected into the pod of the Job, and the app containers are replaced with containers that run the given
+// pause image.
+func suspendedJob(name, namespace, pauseImage string, spec *batch.JobSpec) *batch.Job {
+	tpl := spec.Template.DeepCopy()
+
+	// Leave room for the suffix, the name is also used as a label value
+	prefix := name
+	if len(prefix) > 50 {
+		prefix = strings.TrimRight(prefix[:50], "-.")
+	}
+	jobName := prefix + "-tp-" + rand.String(5)
+
+	// The labels of the template are replaced, because Services and controllers that select the pods of
+	// the original Job must not select the pods of this one.
+	tpl.Labels = map[string]string{agentmap.RunJobAnnotation: jobName}
+	if tpl.Annotations == nil {
+		tpl.Annotations = make(map[string]string)
+	}
+	tpl.Annotations[agentconfig.InjectAnnotation] = "enabled"
+	tpl.Annotations[agentmap.RunJobAnnotation] = name
+
+	// The init containers might have side effects, such as database migrations, so they are not run
+	tpl.Spec.InitContainers = nil
+	for i := range tpl.Spec.Containers {
+		cn := &tpl.Spec.Containers[i]
+		cn.Image = pauseImage
+		cn.Command = nil
+		cn.Args = nil
+		cn.LivenessProbe = nil
+		cn.ReadinessProbe = nil
+		cn.StartupProbe = nil
+		cn.Lifecycle = nil
+	}
+	tpl.Spec.RestartPolicy = core.RestartPolicyNever
+
+	backoffLimit := int32(0)
+	return &batch.Job{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      jobName,
+			Namespace: namespace,
+			Labels:    tpl.Labels,
+			Annotations: map[string]string{
+				agentmap.RunJobAnnotation: name,
+			},
+		},
+		Spec: batch.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template:     *tpl,
+		},
+	}
+}
+
+func containerNames(m map[string]*manager.AgentInfo_ContainerInfo) string {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
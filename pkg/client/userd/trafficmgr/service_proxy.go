@@ -9,11 +9,13 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	empty "google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/datawire/dlib/dlog"
 	managerrpc "github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/install"
 )
 
 // mgrProxy implements rpc.ManagerServer, but just proxies all requests through a rpc.ManagerClient.
@@ -61,6 +63,38 @@ func (p *mgrProxy) GetIntercept(ctx context.Context, arg *managerrpc.GetIntercep
 	return client.GetIntercept(ctx, arg, callOptions...)
 }
 
+func (p *mgrProxy) AttachIntercept(ctx context.Context, arg *managerrpc.AttachInterceptRequest) (*managerrpc.InterceptInfo, error) {
+	client, callOptions, err := p.get()
+	if err != nil {
+		return nil, err
+	}
+	return client.AttachIntercept(ctx, arg, callOptions...)
+}
+
+func (p *mgrProxy) DetachIntercept(ctx context.Context, arg *managerrpc.DetachInterceptRequest) (*empty.Empty, error) {
+	client, callOptions, err := p.get()
+	if err != nil {
+		return nil, err
+	}
+	return client.DetachIntercept(ctx, arg, callOptions...)
+}
+
+func (p *mgrProxy) HandoverIntercept(ctx context.Context, arg *managerrpc.HandoverInterceptRequest) (*managerrpc.InterceptInfo, error) {
+	client, callOptions, err := p.get()
+	if err != nil {
+		return nil, err
+	}
+	return client.HandoverIntercept(ctx, arg, callOptions...)
+}
+
+func (p *mgrProxy) DryRunInject(ctx context.Context, arg *managerrpc.DryRunInjectRequest) (*managerrpc.DryRunInjectResponse, error) {
+	client, callOptions, err := p.get()
+	if err != nil {
+		return nil, err
+	}
+	return client.DryRunInject(ctx, arg, callOptions...)
+}
+
 func (p *mgrProxy) Version(ctx context.Context, arg *empty.Empty) (*managerrpc.VersionInfo2, error) {
 	client, callOptions, err := p.get()
 	if err != nil {
@@ -157,6 +191,29 @@ func (p *mgrProxy) WatchAgentsNS(arg *managerrpc.AgentsRequest, srv managerrpc.M
 	}
 }
 
+func (p *mgrProxy) WatchAgentsSince(arg *managerrpc.WatchSinceRequest, srv managerrpc.Manager_WatchAgentsSinceServer) error {
+	client, callOptions, err := p.get()
+	if err != nil {
+		return err
+	}
+	cli, err := client.WatchAgentsSince(srv.Context(), arg, callOptions...)
+	if err != nil {
+		return err
+	}
+	for {
+		snapshot, err := cli.Recv()
+		if err != nil {
+			if err == io.EOF || srv.Context().Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err = srv.Send(snapshot); err != nil {
+			return err
+		}
+	}
+}
+
 func (p *mgrProxy) WatchIntercepts(arg *managerrpc.SessionInfo, srv managerrpc.Manager_WatchInterceptsServer) error {
 	client, callOptions, err := p.get()
 	if err != nil {
@@ -180,6 +237,29 @@ func (p *mgrProxy) WatchIntercepts(arg *managerrpc.SessionInfo, srv managerrpc.M
 	}
 }
 
+func (p *mgrProxy) WatchInterceptsSince(arg *managerrpc.WatchSinceRequest, srv managerrpc.Manager_WatchInterceptsSinceServer) error {
+	client, callOptions, err := p.get()
+	if err != nil {
+		return err
+	}
+	cli, err := client.WatchInterceptsSince(srv.Context(), arg, callOptions...)
+	if err != nil {
+		return err
+	}
+	for {
+		snapshot, err := cli.Recv()
+		if err != nil {
+			if err == io.EOF || srv.Context().Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err = srv.Send(snapshot); err != nil {
+			return err
+		}
+	}
+}
+
 func (p *mgrProxy) PrepareIntercept(_ context.Context, _ *managerrpc.CreateInterceptRequest) (*managerrpc.PreparedIntercept, error) {
 	return nil, errors.New("must call connector.CanIntercept instead of manager.CreateIntercept")
 }
@@ -364,6 +444,29 @@ func (p *mgrProxy) WatchClusterInfo(arg *managerrpc.SessionInfo, srv managerrpc.
 	}
 }
 
+func (p *mgrProxy) WatchClusterInfoSince(arg *managerrpc.WatchSinceRequest, srv managerrpc.Manager_WatchClusterInfoSinceServer) error {
+	client, callOptions, err := p.get()
+	if err != nil {
+		return err
+	}
+	cli, err := client.WatchClusterInfoSince(srv.Context(), arg, callOptions...)
+	if err != nil {
+		return err
+	}
+	for {
+		info, err := cli.Recv()
+		if err != nil {
+			if err == io.EOF || srv.Context().Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err = srv.Send(info); err != nil {
+			return err
+		}
+	}
+}
+
 func (p *mgrProxy) SetLogLevel(ctx context.Context, request *managerrpc.LogLevelRequest) (*empty.Empty, error) {
 	client, callOptions, err := p.get()
 	if err != nil {
@@ -379,3 +482,38 @@ func (p *mgrProxy) GetLogs(context.Context, *managerrpc.GetLogsRequest) (*manage
 func (p *mgrProxy) WatchLogLevel(*empty.Empty, managerrpc.Manager_WatchLogLevelServer) error {
 	return status.Error(codes.Unimplemented, "must call manager.WatchLogLevel from an agent (intercepted Pod), not from a client (workstation)")
 }
+
+// withAdminToken propagates the admin token of the incoming request, if any, to the
+// outgoing request.
+func withAdminToken(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ts := md.Get(install.AdminTokenHeader); len(ts) > 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, install.AdminTokenHeader, ts[0])
+		}
+	}
+	return ctx
+}
+
+func (p *mgrProxy) ListClientSessions(ctx context.Context, arg *empty.Empty) (*managerrpc.ClientSessionSnapshot, error) {
+	client, callOptions, err := p.get()
+	if err != nil {
+		return nil, err
+	}
+	return client.ListClientSessions(withAdminToken(ctx), arg, callOptions...)
+}
+
+func (p *mgrProxy) AdminRemoveIntercept(ctx context.Context, arg *managerrpc.AdminRemoveInterceptRequest) (*empty.Empty, error) {
+	client, callOptions, err := p.get()
+	if err != nil {
+		return nil, err
+	}
+	return client.AdminRemoveIntercept(withAdminToken(ctx), arg, callOptions...)
+}
+
+func (p *mgrProxy) EvictSession(ctx context.Context, arg *managerrpc.SessionInfo) (*empty.Empty, error) {
+	client, callOptions, err := p.get()
+	if err != nil {
+		return nil, err
+	}
+	return client.EvictSession(withAdminToken(ctx), arg, callOptions...)
+}
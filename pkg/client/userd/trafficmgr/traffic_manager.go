@@ -38,6 +38,7 @@ import (
 	"github.com/telepresenceio/telepresence/rpc/v2/manager"
 	"github.com/telepresenceio/telepresence/v2/pkg/a8rcloud"
 	"github.com/telepresenceio/telepresence/v2/pkg/agentconfig"
+	"github.com/telepresenceio/telepresence/v2/pkg/agentmap"
 	"github.com/telepresenceio/telepresence/v2/pkg/client"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/errcat"
 	"github.com/telepresenceio/telepresence/v2/pkg/client/scout"
@@ -77,6 +78,11 @@ type Session interface {
 	IngressInfos(c context.Context) ([]*manager.IngressInfo, error)
 	ClearIntercepts(context.Context) error
 	RemoveIntercept(context.Context, string) error
+	AttachIntercept(ctx context.Context, workload, namespace string, targetPort int32, mountPoint string) (*manager.InterceptInfo, error)
+	HandoverIntercept(ctx context.Context, name, client string) (*manager.InterceptInfo, error)
+	RunJob(ctx context.Context, name, namespace, kind, container, mountPoint string) (string, map[string]string, error)
+	StopJob(ctx context.Context, name string) error
+	DryRunInject(ctx context.Context, name, namespace, kind string, workload []byte) (*manager.DryRunInjectResponse, error)
 	Run(context.Context) error
 	Uninstall(context.Context, *rpc.UninstallRequest) (*rpc.UninstallResult, error)
 	UpdateStatus(context.Context, *rpc.ConnectRequest) *rpc.ConnectInfo
@@ -132,15 +138,29 @@ type TrafficManager struct {
 	// search paths are propagated to the rootDaemon
 	rootDaemon daemon.DaemonClient
 
+	// proxyListener is only set when the session was connected in proxy mode, i.e. without a root daemon.
+	proxyListener net.Listener
+
+	// networkNamespace is the name of the network namespace that the root daemon's TUN device lives in. It's
+	// only set when the session was connected with a network namespace.
+	networkNamespace string
+
 	sessionInfo *manager.SessionInfo // sessionInfo returned by the traffic-manager
 
 	// Map of desired mount points for intercepts
 	mountPoints sync.Map
 
+	// Map of mount points for intercepts that this client is attaching to, keyed by
+	// <workload>.<namespace>. They move to mountPoints once the intercept is seen.
+	pendingAttachMounts sync.Map
+
 	// Map of mutexes, so that we don't create and delete
 	// mount points concurrently
 	mountMutexes sync.Map
 
+	// Map of *jobRun keyed by the name of the Job that RunJob created
+	jobRuns sync.Map
+
 	wlWatcher *workloadsAndServicesWatcher
 
 	insLock sync.Mutex
@@ -194,12 +214,24 @@ type interceptResult struct {
 // TODO: Change to released version
 var firstAgentConfigMapVersion = semver.MustParse("2.6.0-alpha.64")
 
+// networkNamespaceName is the name of the network namespace that is created when connecting with --netns.
+const networkNamespaceName = "telepresence"
+
 func NewSession(c context.Context, sr *scout.Reporter, cr *rpc.ConnectRequest, svc Service, extraServices []SessionService) (context.Context, Session, *connector.ConnectInfo) {
 	dlog.Info(c, "-- Starting new session")
 	sr.Report(c, "connect")
 
+	if cr.ProxyAddress != "" {
+		if err := checkProxyAddress(cr.ProxyAddress, cr.ProxyAllowRemote); err != nil {
+			return c, nil, connectError(rpc.ConnectInfo_DAEMON_FAILED, err)
+		}
+	}
+
+	// The pod daemon, and a session that is connected in proxy mode, don't use a root daemon.
+	useRootDaemon := !(cr.IsPodDaemon || cr.ProxyAddress != "")
+
 	var rootDaemon daemon.DaemonClient
-	if !cr.IsPodDaemon {
+	if useRootDaemon {
 		var err error
 		rootDaemon, err = svc.RootDaemonClient(c)
 		if err != nil {
@@ -250,7 +282,10 @@ func NewSession(c context.Context, sr *scout.Reporter, cr *rpc.ConnectRequest, s
 	svc.SetManagerClient(tmgr.managerClient, opts...)
 
 	// Tell daemon what it needs to know in order to establish outbound traffic to the cluster
-	if !cr.IsPodDaemon {
+	if useRootDaemon {
+		if cr.Netns {
+			tmgr.networkNamespace = networkNamespaceName
+		}
 		oi := tmgr.getOutboundInfo(c)
 
 		dlog.Debug(c, "Connecting to root daemon")
@@ -279,22 +314,32 @@ func NewSession(c context.Context, sr *scout.Reporter, cr *rpc.ConnectRequest, s
 				return c, nil, connectError(rpc.ConnectInfo_DAEMON_FAILED, fmt.Errorf("failed to disconnect from the root daemon: %w", err))
 			}
 		}
+		tmgr.networkNamespace = rootStatus.OutboundConfig.NetworkNamespace
 		dlog.Debug(c, "Connected to root daemon")
 		tmgr.AddNamespaceListener(tmgr.updateDaemonNamespaces)
 	}
 
+	if cr.ProxyAddress != "" {
+		if tmgr.proxyListener, err = net.Listen("tcp", cr.ProxyAddress); err != nil {
+			return c, nil, connectError(rpc.ConnectInfo_DAEMON_FAILED, errcat.User.Newf("unable to listen on proxy address %s: %w", cr.ProxyAddress, err))
+		}
+		dlog.Infof(c, "Connected in proxy mode, listening on %s", tmgr.proxyListener.Addr())
+	}
+
 	// Collect data on how long connection time took
 	dlog.Debug(c, "Finished connecting to traffic manager")
 	sr.Report(c, "finished_connecting_traffic_manager", scout.Entry{
 		Key: "connect_duration", Value: time.Since(connectStart).Seconds()})
 
 	ret := &rpc.ConnectInfo{
-		Error:          rpc.ConnectInfo_UNSPECIFIED,
-		ClusterContext: cluster.Config.Context,
-		ClusterServer:  cluster.Config.Server,
-		ClusterId:      cluster.GetClusterId(c),
-		SessionInfo:    tmgr.session(),
-		Intercepts:     &manager.InterceptInfoSnapshot{Intercepts: tmgr.getCurrentIntercepts()},
+		Error:            rpc.ConnectInfo_UNSPECIFIED,
+		ClusterContext:   cluster.Config.Context,
+		ClusterServer:    cluster.Config.Server,
+		ClusterId:        cluster.GetClusterId(c),
+		SessionInfo:      tmgr.session(),
+		Intercepts:       &manager.InterceptInfoSnapshot{Intercepts: tmgr.getCurrentIntercepts()},
+		ProxyAddress:     tmgr.proxyAddress(),
+		NetworkNamespace: tmgr.networkNamespace,
 	}
 	c = WithSession(c, tmgr)
 	return c, tmgr, ret
@@ -535,6 +580,10 @@ func (tm *TrafficManager) setInterceptedNamespaces(c context.Context, intercepte
 // send it to the DNS-resolver in the daemon.
 func (tm *TrafficManager) updateDaemonNamespaces(c context.Context) {
 	tm.wlWatcher.setNamespacesToWatch(c, tm.GetCurrentNamespaces(true))
+	if tm.rootDaemon == nil {
+		// There's no DNS-resolver when the session is connected in proxy mode.
+		return
+	}
 
 	tm.insLock.Lock()
 	namespaces := make([]string, 0, len(tm.interceptedNamespaces)+len(tm.localIntercepts))
@@ -575,6 +624,9 @@ func (tm *TrafficManager) Run(c context.Context) error {
 	g.Go("intercept-port-forward", tm.workerPortForwardIntercepts)
 	g.Go("agent-watcher", tm.agentInfoWatcher)
 	g.Go("dial-request-watcher", tm.dialRequestWatcher)
+	if tm.proxyListener != nil {
+		g.Go("proxy", tm.serveProxy)
+	}
 	for _, svc := range tm.sessionServices {
 		func(svc SessionService) {
 			dlog.Infof(c, "Starting additional session service %s", svc.Name())
@@ -634,8 +686,11 @@ func (tm *TrafficManager) getInfosForWorkloads(
 			if wlInfo.InterceptInfos, ok = iMap[name]; !ok && filter <= rpc.ListRequest_INTERCEPTS {
 				continue
 			}
-			if wlInfo.AgentInfo, ok = aMap[name]; !ok && filter <= rpc.ListRequest_INSTALLED_AGENTS {
-				continue
+			if wlInfo.AgentInfo, ok = aMap[name]; !ok {
+				if filter <= rpc.ListRequest_INSTALLED_AGENTS {
+					continue
+				}
+				wlInfo.AgentInjectionError = workload.GetAnnotations()[agentmap.InjectionErrorAnnotation]
 			}
 			wiMap[workload.GetUID()] = wlInfo
 		}
@@ -892,12 +947,14 @@ func (tm *TrafficManager) UpdateStatus(c context.Context, cr *rpc.ConnectRequest
 func (tm *TrafficManager) Status(c context.Context) *rpc.ConnectInfo {
 	cfg := tm.Config
 	ret := &rpc.ConnectInfo{
-		Error:          rpc.ConnectInfo_ALREADY_CONNECTED,
-		ClusterContext: cfg.Context,
-		ClusterServer:  cfg.Server,
-		ClusterId:      tm.GetClusterId(c),
-		SessionInfo:    tm.session(),
-		Intercepts:     &manager.InterceptInfoSnapshot{Intercepts: tm.getCurrentIntercepts()},
+		Error:            rpc.ConnectInfo_ALREADY_CONNECTED,
+		ClusterContext:   cfg.Context,
+		ClusterServer:    cfg.Server,
+		ClusterId:        tm.GetClusterId(c),
+		SessionInfo:      tm.session(),
+		Intercepts:       &manager.InterceptInfoSnapshot{Intercepts: tm.getCurrentIntercepts()},
+		ProxyAddress:     tm.proxyAddress(),
+		NetworkNamespace: tm.networkNamespace,
 	}
 	return ret
 }
@@ -1114,6 +1171,7 @@ func (tm *TrafficManager) getOutboundInfo(ctx context.Context) *daemon.OutboundI
 	// the cluster, since an open tunnel to the traffic-manager (via the API server) is itself required
 	// to communicate with the cluster.
 	neverProxy := []*manager.IPNet{}
+	var apiServerIPs [][]byte
 	url, err := url.Parse(tm.Server)
 	if err != nil {
 		// This really shouldn't happen as we are connected to the server
@@ -1138,6 +1196,7 @@ func (tm *TrafficManager) getOutboundInfo(ctx context.Context) *daemon.OutboundI
 			}
 			ipnet := &net.IPNet{IP: ip, Mask: mask}
 			neverProxy = append(neverProxy, iputil.IPNetToRPC(ipnet))
+			apiServerIPs = append(apiServerIPs, ip)
 		}
 	}
 	for _, np := range tm.NeverProxy {
@@ -1146,6 +1205,8 @@ func (tm *TrafficManager) getOutboundInfo(ctx context.Context) *daemon.OutboundI
 	info := &daemon.OutboundInfo{
 		Session:           tm.sessionInfo,
 		NeverProxySubnets: neverProxy,
+		NetworkNamespace:  tm.networkNamespace,
+		ApiServerIps:      apiServerIPs,
 	}
 
 	if tm.DNS != nil {
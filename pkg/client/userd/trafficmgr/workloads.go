@@ -10,6 +10,7 @@ import (
 	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -24,16 +25,21 @@ type workloadsAndServicesWatcher struct {
 	nsWatchers  map[string]*namespacedWASWatcher
 	nsListeners []func()
 	cond        sync.Cond
+
+	rolloutsOnce      sync.Once
+	rolloutsSupported bool
 }
 
 const deployments = 0
 const replicasets = 1
 const statefulsets = 2
+const daemonsets = 3
+const rollouts = 4
 
 // namespacedWASWatcher is watches Workloads And Services (WAS) for a namespace
 type namespacedWASWatcher struct {
 	svcWatcher *k8sapi.Watcher
-	wlWatchers [3]*k8sapi.Watcher
+	wlWatchers [5]*k8sapi.Watcher // the rollouts watcher is nil unless the cluster serves Argo Rollouts
 }
 
 // svcEquals compare only the Service fields that are of interest to Telepresence. They are
@@ -67,7 +73,7 @@ nextMP:
 	return true
 }
 
-// workloadEquals compare only the workload (Deployment, ResourceSet, or StatefulSet) fields that are of interest to Telepresence. They are
+// workloadEquals compare only the workload (Deployment, ResourceSet, StatefulSet, DaemonSet, or Rollout) fields that are of interest to Telepresence. They are
 //
 //   - UID
 //   - Name
@@ -139,32 +145,43 @@ func workloadEquals(oa, ob runtime.Object) bool {
 	return true
 }
 
-func newNamespaceWatcher(c context.Context, namespace string, cond *sync.Cond) *namespacedWASWatcher {
+func newNamespaceWatcher(c context.Context, namespace string, cond *sync.Cond, withRollouts bool) *namespacedWASWatcher {
 	ki := k8sapi.GetK8sInterface(c)
 	appsGetter := ki.AppsV1().RESTClient()
 	w := &namespacedWASWatcher{
 		svcWatcher: k8sapi.NewWatcher("services", namespace, ki.CoreV1().RESTClient(), &core.Service{}, cond, svcEquals),
-		wlWatchers: [3]*k8sapi.Watcher{
+		wlWatchers: [5]*k8sapi.Watcher{
 			k8sapi.NewWatcher("deployments", namespace, appsGetter, &apps.Deployment{}, cond, workloadEquals),
 			k8sapi.NewWatcher("replicasets", namespace, appsGetter, &apps.ReplicaSet{}, cond, workloadEquals),
 			k8sapi.NewWatcher("statefulsets", namespace, appsGetter, &apps.StatefulSet{}, cond, workloadEquals),
+			k8sapi.NewWatcher("daemonsets", namespace, appsGetter, &apps.DaemonSet{}, cond, workloadEquals),
 		},
 	}
+	if withRollouts {
+		w.wlWatchers[rollouts] = k8sapi.NewDynamicWatcher(k8sapi.RolloutResource, namespace, k8sapi.GetDynamicInterface(c), cond, workloadEquals)
+	}
 	return w
 }
 
 func (nw *namespacedWASWatcher) cancel() {
 	nw.svcWatcher.Cancel()
 	for _, w := range nw.wlWatchers {
-		w.Cancel()
+		if w != nil {
+			w.Cancel()
+		}
 	}
 }
 
 func (nw *namespacedWASWatcher) hasSynced() bool {
-	return nw.svcWatcher.HasSynced() &&
-		nw.wlWatchers[0].HasSynced() &&
-		nw.wlWatchers[1].HasSynced() &&
-		nw.wlWatchers[2].HasSynced()
+	if !nw.svcWatcher.HasSynced() {
+		return false
+	}
+	for _, w := range nw.wlWatchers {
+		if w != nil && !w.HasSynced() {
+			return false
+		}
+	}
+	return true
 }
 
 func newWASWatcher() *workloadsAndServicesWatcher {
@@ -269,8 +286,17 @@ func (w *workloadsAndServicesWatcher) setNamespacesToWatch(c context.Context, ns
 	w.Unlock()
 }
 
+// hasRollouts returns true if the cluster serves Argo Rollouts. The check is made once.
+func (w *workloadsAndServicesWatcher) hasRollouts(c context.Context) bool {
+	w.rolloutsOnce.Do(func() {
+		w.rolloutsSupported = k8sapi.RolloutsSupported(c)
+		dlog.Debugf(c, "Argo Rollouts supported: %t", w.rolloutsSupported)
+	})
+	return w.rolloutsSupported
+}
+
 func (w *workloadsAndServicesWatcher) addNSLocked(c context.Context, ns string) *namespacedWASWatcher {
-	nw := newNamespaceWatcher(c, ns, &w.cond)
+	nw := newNamespaceWatcher(c, ns, &w.cond, w.hasRollouts(c))
 	w.nsWatchers[ns] = nw
 	for _, l := range w.nsListeners {
 		nw.svcWatcher.AddStateListener(&k8sapi.StateListener{Cb: l})
@@ -355,6 +381,9 @@ func (nw *namespacedWASWatcher) findMatchingWorkloads(c context.Context, svc *co
 
 	var allWls []k8sapi.Workload
 	for i, wlw := range nw.wlWatchers {
+		if wlw == nil {
+			continue
+		}
 		for _, o := range wlw.List(c) {
 			var wl k8sapi.Workload
 			switch i {
@@ -364,6 +393,10 @@ func (nw *namespacedWASWatcher) findMatchingWorkloads(c context.Context, svc *co
 				wl = k8sapi.ReplicaSet(o.(*apps.ReplicaSet))
 			case statefulsets:
 				wl = k8sapi.StatefulSet(o.(*apps.StatefulSet))
+			case daemonsets:
+				wl = k8sapi.DaemonSet(o.(*apps.DaemonSet))
+			case rollouts:
+				wl = k8sapi.Rollout(o.(*unstructured.Unstructured))
 			}
 			if selector.Matches(labels.Set(wl.GetLabels())) {
 				owl, err := nw.maybeReplaceWithOwner(c, wl)
@@ -386,7 +419,7 @@ func (nw *namespacedWASWatcher) findMatchingWorkloads(c context.Context, svc *co
 func (nw *namespacedWASWatcher) maybeReplaceWithOwner(c context.Context, wl k8sapi.Workload) (k8sapi.Workload, error) {
 	var err error
 	for _, or := range wl.GetOwnerReferences() {
-		if or.Controller != nil && *or.Controller && or.Kind == "Deployment" {
+		if or.Controller != nil && *or.Controller && (or.Kind == "Deployment" || or.Kind == "Rollout") {
 			// Chances are that the owner's labels doesn't match, but we really want the owner anyway.
 			wl, err = nw.replaceWithOwner(c, wl, or.Kind, or.Name)
 			break
@@ -396,19 +429,38 @@ func (nw *namespacedWASWatcher) maybeReplaceWithOwner(c context.Context, wl k8sa
 }
 
 func (nw *namespacedWASWatcher) replaceWithOwner(c context.Context, wl k8sapi.Workload, kind, name string) (k8sapi.Workload, error) {
-	od, found, err := nw.wlWatchers[deployments].Get(c, &apps.Deployment{
-		ObjectMeta: meta.ObjectMeta{
-			Name:      name,
-			Namespace: wl.GetNamespace(),
-		},
-	})
+	var od any
+	var found bool
+	var err error
+	if kind == "Rollout" {
+		rw := nw.wlWatchers[rollouts]
+		if rw == nil {
+			// Argo Rollouts are not served by the cluster, so keep the ReplicaSet.
+			return wl, nil
+		}
+		ro := &unstructured.Unstructured{}
+		ro.SetName(name)
+		ro.SetNamespace(wl.GetNamespace())
+		od, found, err = rw.Get(c, ro)
+	} else {
+		od, found, err = nw.wlWatchers[deployments].Get(c, &apps.Deployment{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      name,
+				Namespace: wl.GetNamespace(),
+			},
+		})
+	}
 	switch {
 	case err != nil:
 		return nil, fmt.Errorf("get %s owner %s for %s %s.%s: %v",
 			kind, name, wl.GetKind(), wl.GetName(), wl.GetNamespace(), err)
 	case found:
 		dlog.Debugf(c, "replacing %s %s.%s, with owner %s %s", wl.GetKind(), wl.GetName(), wl.GetNamespace(), kind, name)
-		return k8sapi.Deployment(od.(*apps.Deployment)), nil
+		owl, err := k8sapi.WrapWorkload(od.(runtime.Object))
+		if err != nil {
+			return nil, err
+		}
+		return owl, nil
 	default:
 		return nil, fmt.Errorf("get %s owner %s for %s %s.%s: not found", kind, name, wl.GetKind(), wl.GetName(), wl.GetNamespace())
 	}
@@ -112,9 +112,14 @@ func (f *interceptor) SetIntercepting(intercept *manager.InterceptInfo) {
 			dlog.Debugf(f.lCtx, "Forward target changed from %s:%d to intercept %s", f.targetHost, f.targetPort, iceptInfo(intercept))
 		} else {
 			if f.intercept.Id == intercept.Id {
-				return
+				if f.intercept.RoutedSessionId == intercept.RoutedSessionId {
+					return
+				}
+				dlog.Debugf(f.lCtx, "Forward target of intercept %s changed from session %q to session %q",
+					iceptInfo(intercept), f.intercept.RoutedSessionId, intercept.RoutedSessionId)
+			} else {
+				dlog.Debugf(f.lCtx, "Forward target changed from intercept %s to intercept %q", iceptInfo(f.intercept), iceptInfo(intercept))
 			}
-			dlog.Debugf(f.lCtx, "Forward target changed from intercept %s to intercept %q", iceptInfo(f.intercept), iceptInfo(intercept))
 		}
 	}
 
@@ -125,3 +130,17 @@ func (f *interceptor) SetIntercepting(intercept *manager.InterceptInfo) {
 	f.tCtx, f.tCancel = context.WithCancel(f.lCtx)
 	f.intercept = intercept
 }
+
+// routeTarget returns the ID of the client session that receives the traffic of the given intercept,
+// and the port on that client's workstation that the traffic is sent to. This is the owner of the
+// intercept unless the owner has handed the routing over to one of the attached clients.
+func routeTarget(ii *manager.InterceptInfo) (string, uint16) {
+	if rs := ii.RoutedSessionId; rs != "" {
+		for _, ac := range ii.AttachedClients {
+			if ac.Session.SessionId == rs {
+				return rs, uint16(ac.TargetPort)
+			}
+		}
+	}
+	return ii.ClientSession.SessionId, uint16(ii.Spec.TargetPort)
+}
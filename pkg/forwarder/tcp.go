@@ -167,8 +167,9 @@ func (f *interceptor) interceptConn(ctx context.Context, conn net.Conn, iCept *m
 	}
 
 	spec := iCept.Spec
+	clientSessionID, targetPort := routeTarget(iCept)
 	destIp := iputil.Parse(spec.TargetHost)
-	id := tunnel.NewConnID(tunnel.IPProto(addr.Network()), srcIp, destIp, srcPort, uint16(spec.TargetPort))
+	id := tunnel.NewConnID(tunnel.IPProto(addr.Network()), srcIp, destIp, srcPort, targetPort)
 	tracing.RecordConnID(span, id.String())
 
 	ms, err := f.manager.Tunnel(ctx)
@@ -180,7 +181,7 @@ func (f *interceptor) interceptConn(ctx context.Context, conn net.Conn, iCept *m
 	if err != nil {
 		return err
 	}
-	if err = s.Send(ctx, tunnel.SessionMessage(iCept.ClientSession.SessionId)); err != nil {
+	if err = s.Send(ctx, tunnel.SessionMessage(clientSessionID)); err != nil {
 		return fmt.Errorf("unable to send client session id. Id %s: %v", id, err)
 	}
 	d := tunnel.NewConnEndpoint(s, conn)
@@ -208,7 +208,8 @@ func (f *udp) interceptConn(ctx context.Context, conn *net.UDPConn, iCept *manag
 	tracing.RecordInterceptInfo(span, iCept)
 
 	spec := iCept.Spec
-	dest := &net.UDPAddr{IP: iputil.Parse(spec.TargetHost), Port: int(spec.TargetPort)}
+	clientSessionID, targetPort := routeTarget(iCept)
+	dest := &net.UDPAddr{IP: iputil.Parse(spec.TargetHost), Port: int(targetPort)}
 
 	dlog.Infof(ctx, "Forwarding udp from %s to %s %s", conn.LocalAddr(), spec.Client, dest)
 	defer dlog.Infof(ctx, "Done forwarding udp from %s to %s %s", conn.LocalAddr(), spec.Client, dest)
@@ -221,7 +222,7 @@ func (f *udp) interceptConn(ctx context.Context, conn *net.UDPConn, iCept *manag
 		if err != nil {
 			return nil, err
 		}
-		if err = s.Send(ctx, tunnel.SessionMessage(iCept.ClientSession.SessionId)); err != nil {
+		if err = s.Send(ctx, tunnel.SessionMessage(clientSessionID)); err != nil {
 			return nil, fmt.Errorf("unable to send client session id. Id %s: %v", id, err)
 		}
 		return s, nil
@@ -14,4 +14,7 @@ const (
 	MutatorWebhookPortHTTPS   = 8443
 	MutatorWebhookTLSName     = "mutator-webhook-tls"
 	TelAppMountPoint          = "/tel_app_mounts"
+	AdminTokenSecretName      = "traffic-manager-admin"
+	AdminTokenSecretKey       = "token"
+	AdminTokenHeader          = "x-telepresence-admin-token"
 )
@@ -0,0 +1,224 @@
+package k8sapi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// RolloutResource is the resource of an Argo Rollout.
+var RolloutResource = schema.GroupVersionResource{
+	Group:    "argoproj.io",
+	Version:  "v1alpha1",
+	Resource: "rollouts",
+}
+
+// RolloutsSupported returns true if the cluster serves Argo Rollouts and the context carries
+// a dynamic interface that can be used to access them.
+func RolloutsSupported(c context.Context) bool {
+	if GetDynamicInterface(c) == nil {
+		return false
+	}
+	rl, err := GetK8sInterface(c).Discovery().ServerResourcesForGroupVersion(RolloutResource.GroupVersion().String())
+	if err != nil {
+		return false
+	}
+	for _, r := range rl.APIResources {
+		if r.Name == RolloutResource.Resource {
+			return true
+		}
+	}
+	return false
+}
+
+func GetRollout(c context.Context, name, namespace string) (Workload, error) {
+	ri, err := rollouts(c, namespace)
+	if err != nil {
+		return nil, err
+	}
+	u, err := ri.Get(c, name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return Rollout(u), nil
+}
+
+// Rollouts returns all Argo Rollouts found in the given Namespace. An empty slice is returned
+// if the context doesn't carry a dynamic interface.
+func Rollouts(c context.Context, namespace string, labelSelector labels.Set) ([]Workload, error) {
+	ri, err := rollouts(c, namespace)
+	if err != nil {
+		// No dynamic interface, so no rollouts.
+		return nil, nil
+	}
+	ls, err := ri.List(c, listOptions(labelSelector))
+	if err != nil {
+		return nil, err
+	}
+	is := ls.Items
+	os := make([]Workload, len(is))
+	for i := range is {
+		os[i] = Rollout(&is[i])
+	}
+	return os, nil
+}
+
+// Rollout returns a Workload for the given unstructured Argo Rollout. Rollouts that use a
+// workloadRef instead of a template will have an empty pod template.
+func Rollout(u *unstructured.Unstructured) Workload {
+	ro := &rollout{Unstructured: u}
+	ro.decodeTemplate()
+	return ro
+}
+
+// RolloutImpl casts the given Object as an *unstructured.Unstructured Argo Rollout and returns
+// it together with a status flag indicating whether the cast was possible
+func RolloutImpl(o Object) (*unstructured.Unstructured, bool) {
+	if s, ok := o.(*rollout); ok {
+		return s.Unstructured, true
+	}
+	return nil, false
+}
+
+type rollout struct {
+	*unstructured.Unstructured
+	template core.PodTemplateSpec
+}
+
+func rollouts(c context.Context, namespace string) (dynamic.ResourceInterface, error) {
+	di := GetDynamicInterface(c)
+	if di == nil {
+		return nil, UnsupportedWorkloadKindError("Rollout")
+	}
+	return di.Resource(RolloutResource).Namespace(namespace), nil
+}
+
+// ki returns the dynamic interface for the rollout's namespace. The rollout might have been
+// decoded from an object that wasn't obtained from the cluster, so the context isn't guaranteed
+// to carry a dynamic interface.
+func (o *rollout) ki(c context.Context) (dynamic.ResourceInterface, error) {
+	return rollouts(c, o.GetNamespace())
+}
+
+func (o *rollout) decodeTemplate() {
+	o.template = core.PodTemplateSpec{}
+	if tm, ok, _ := unstructured.NestedMap(o.Object, "spec", "template"); ok {
+		_ = runtime.DefaultUnstructuredConverter.FromUnstructured(tm, &o.template)
+	}
+}
+
+func (o *rollout) setUnstructured(u *unstructured.Unstructured) {
+	o.Unstructured = u
+	o.decodeTemplate()
+}
+
+func (o *rollout) GetKind() string {
+	return "Rollout"
+}
+
+func (o *rollout) Delete(c context.Context) error {
+	ri, err := o.ki(c)
+	if err != nil {
+		return err
+	}
+	return ri.Delete(c, o.GetName(), meta.DeleteOptions{})
+}
+
+func (o *rollout) GetPodTemplate() *core.PodTemplateSpec {
+	return &o.template
+}
+
+// Patch patches the rollout. Strategic merge patches aren't supported for custom resources, so
+// they are applied as JSON merge patches.
+func (o *rollout) Patch(c context.Context, pt types.PatchType, data []byte, subresources ...string) error {
+	if pt == types.StrategicMergePatchType {
+		pt = types.MergePatchType
+	}
+	ri, err := o.ki(c)
+	if err != nil {
+		return err
+	}
+	u, err := ri.Patch(c, o.GetName(), pt, data, meta.PatchOptions{}, subresources...)
+	if err == nil {
+		o.setUnstructured(u)
+	}
+	return err
+}
+
+func (o *rollout) Refresh(c context.Context) error {
+	ri, err := o.ki(c)
+	if err != nil {
+		return err
+	}
+	u, err := ri.Get(c, o.GetName(), meta.GetOptions{})
+	if err == nil {
+		o.setUnstructured(u)
+	}
+	return err
+}
+
+func (o *rollout) Replicas() int {
+	return int(o.statusInt("replicas"))
+}
+
+func (o *rollout) Selector() (labels.Selector, error) {
+	sm, ok, err := unstructured.NestedMap(o.Object, "spec", "selector")
+	if err != nil {
+		return nil, fmt.Errorf("rollout %s.%s has an invalid selector: %w", o.GetName(), o.GetNamespace(), err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("rollout %s.%s has no selector", o.GetName(), o.GetNamespace())
+	}
+	var ls meta.LabelSelector
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(sm, &ls); err != nil {
+		return nil, fmt.Errorf("rollout %s.%s has an invalid selector: %w", o.GetName(), o.GetNamespace(), err)
+	}
+	return meta.LabelSelectorAsSelector(&ls)
+}
+
+// Update updates the rollout. Modifications made to the pod template are included.
+func (o *rollout) Update(c context.Context) error {
+	if len(o.template.Spec.Containers) > 0 {
+		tm, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&o.template)
+		if err != nil {
+			return err
+		}
+		if err = unstructured.SetNestedMap(o.Object, tm, "spec", "template"); err != nil {
+			return err
+		}
+	}
+	ri, err := o.ki(c)
+	if err != nil {
+		return err
+	}
+	u, err := ri.Update(c, o.Unstructured, meta.UpdateOptions{})
+	if err == nil {
+		o.setUnstructured(u)
+	}
+	return err
+}
+
+func (o *rollout) Updated(origGeneration int64) bool {
+	// The observedGeneration of a Rollout is a string.
+	og, _, _ := unstructured.NestedFieldNoCopy(o.Object, "status", "observedGeneration")
+	replicas := o.statusInt("replicas")
+	applied := o.GetGeneration() >= origGeneration &&
+		fmt.Sprint(og) == strconv.FormatInt(o.GetGeneration(), 10) &&
+		o.statusInt("updatedReplicas") == replicas &&
+		o.statusInt("availableReplicas") == replicas
+	return applied
+}
+
+func (o *rollout) statusInt(field string) int64 {
+	v, _, _ := unstructured.NestedInt64(o.Object, "status", field)
+	return v
+}
@@ -0,0 +1,34 @@
+package k8sapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRollout_Selector(t *testing.T) {
+	newRollout := func(spec map[string]any) Workload {
+		return Rollout(&unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "argoproj.io/v1alpha1",
+			"kind":       "Rollout",
+			"metadata":   map[string]any{"name": "echo", "namespace": "default"},
+			"spec":       spec,
+		}})
+	}
+
+	sel, err := newRollout(map[string]any{
+		"selector": map[string]any{"matchLabels": map[string]any{"app": "echo"}},
+	}).Selector()
+	require.NoError(t, err)
+	assert.Equal(t, "app=echo", sel.String())
+
+	sel, err = newRollout(map[string]any{}).Selector()
+	assert.EqualError(t, err, "rollout echo.default has no selector")
+	assert.Nil(t, sel)
+
+	sel, err = newRollout(map[string]any{"selector": "app=echo"}).Selector()
+	assert.ErrorContains(t, err, "rollout echo.default has an invalid selector")
+	assert.Nil(t, sel)
+}
@@ -11,9 +11,12 @@ import (
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 
 	"github.com/datawire/dlib/dlog"
@@ -32,6 +35,7 @@ type Watcher struct {
 	resource       string
 	namespace      string
 	getter         cache.Getter
+	dynamic        dynamic.NamespaceableResourceInterface
 	objType        runtime.Object
 	cond           *sync.Cond
 	controller     cache.Controller
@@ -65,6 +69,17 @@ func newListerWatcher(c context.Context, getter cache.Getter, resource, namespac
 	return &cache.ListWatch{ListFunc: listFunc, WatchFunc: watchFunc}
 }
 
+func newDynamicListerWatcher(c context.Context, ri dynamic.NamespaceableResourceInterface, namespace string) cache.ListerWatcher {
+	listFunc := func(options meta.ListOptions) (runtime.Object, error) {
+		return ri.Namespace(namespace).List(c, options)
+	}
+	watchFunc := func(options meta.ListOptions) (watch.Interface, error) {
+		options.Watch = true
+		return ri.Namespace(namespace).Watch(c, options)
+	}
+	return &cache.ListWatch{ListFunc: listFunc, WatchFunc: watchFunc}
+}
+
 func NewWatcher(resource, namespace string, getter cache.Getter, objType runtime.Object, cond *sync.Cond, equals func(runtime.Object, runtime.Object) bool) *Watcher {
 	return &Watcher{
 		resource:  resource,
@@ -76,6 +91,19 @@ func NewWatcher(resource, namespace string, getter cache.Getter, objType runtime
 	}
 }
 
+// NewDynamicWatcher creates a Watcher that uses the given dynamic.Interface to watch the given resource. This
+// is used for custom resources. The objects that the watcher produces are of type *unstructured.Unstructured.
+func NewDynamicWatcher(gvr schema.GroupVersionResource, namespace string, di dynamic.Interface, cond *sync.Cond, equals func(runtime.Object, runtime.Object) bool) *Watcher {
+	return &Watcher{
+		resource:  gvr.Resource,
+		namespace: namespace,
+		equals:    equals,
+		dynamic:   di.Resource(gvr),
+		objType:   &unstructured.Unstructured{},
+		cond:      cond,
+	}
+}
+
 // AddStateListener adds a listener function that will be called when the watcher
 // changes its state (starts or is cancelled)
 func (w *Watcher) AddStateListener(l *StateListener) {
@@ -210,9 +238,15 @@ func (w *Watcher) startLocked(c context.Context, ready *sync.WaitGroup) context.
 	// Just creating an informer won't do, because then we cannot set the WatchErrorHandler of
 	// its Config. So we create it from a Config instead, which actually plays out well because
 	// we get immediate access to the Process function and can skip the ResourceEventHandlerFuncs
+	var lw cache.ListerWatcher
+	if w.dynamic != nil {
+		lw = newDynamicListerWatcher(c, w.dynamic, w.namespace)
+	} else {
+		lw = newListerWatcher(c, w.getter, w.resource, w.namespace)
+	}
 	config := cache.Config{
 		Queue:         fifo,
-		ListerWatcher: newListerWatcher(c, w.getter, w.resource, w.namespace),
+		ListerWatcher: lw,
 		Process: func(obj any) error {
 			return w.process(c, obj.(cache.Deltas), eventCh)
 		},
@@ -2,16 +2,20 @@ package k8sapi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	errors2 "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	typedApps "k8s.io/client-go/kubernetes/typed/apps/v1"
+	typedBatch "k8s.io/client-go/kubernetes/typed/batch/v1"
 )
 
 type Workload interface {
@@ -33,8 +37,10 @@ func (u UnsupportedWorkloadKindError) Error() string {
 //   1. Deployments
 //   2. ReplicaSets
 //   3. StatefulSets
+//   4. DaemonSets
+//   5. Rollouts (only when the context carries a dynamic interface)
 //
-// The first match is returned.
+// The first match is returned. Jobs are never searched for, they must be requested explicitly.
 func GetWorkload(c context.Context, name, namespace, workloadKind string) (obj Workload, err error) {
 	switch workloadKind {
 	case "Deployment":
@@ -43,12 +49,23 @@ func GetWorkload(c context.Context, name, namespace, workloadKind string) (obj W
 		obj, err = GetReplicaSet(c, name, namespace)
 	case "StatefulSet":
 		obj, err = GetStatefulSet(c, name, namespace)
+	case "DaemonSet":
+		obj, err = GetDaemonSet(c, name, namespace)
+	case "Rollout":
+		obj, err = GetRollout(c, name, namespace)
+	case "Job":
+		obj, err = GetJob(c, name, namespace)
 	case "":
-		for _, wk := range []string{"Deployment", "ReplicaSet", "StatefulSet"} {
+		wks := []string{"Deployment", "ReplicaSet", "StatefulSet", "DaemonSet"}
+		if GetDynamicInterface(c) != nil {
+			wks = append(wks, "Rollout")
+		}
+		for _, wk := range wks {
 			if obj, err = GetWorkload(c, name, namespace, wk); err == nil {
 				return obj, nil
 			}
-			if !errors2.IsNotFound(err) {
+			var uwkErr UnsupportedWorkloadKindError
+			if !(errors2.IsNotFound(err) || errors.As(err, &uwkErr)) {
 				return nil, err
 			}
 		}
@@ -67,6 +84,15 @@ func WrapWorkload(workload runtime.Object) (Workload, error) {
 		return ReplicaSet(workload), nil
 	case *apps.StatefulSet:
 		return StatefulSet(workload), nil
+	case *apps.DaemonSet:
+		return DaemonSet(workload), nil
+	case *batch.Job:
+		return Job(workload), nil
+	case *unstructured.Unstructured:
+		if workload.GetKind() == "Rollout" {
+			return Rollout(workload), nil
+		}
+		return nil, fmt.Errorf("unsupported workload kind %q", workload.GetKind())
 	default:
 		return nil, fmt.Errorf("unsupported workload type %T", workload)
 	}
@@ -177,6 +203,62 @@ func StatefulSetImpl(o Object) (*apps.StatefulSet, bool) {
 	return nil, false
 }
 
+func GetDaemonSet(c context.Context, name, namespace string) (Workload, error) {
+	d, err := daemonSets(c, namespace).Get(c, name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &daemonSet{d}, nil
+}
+
+// DaemonSets returns all daemon sets found in the given Namespace
+func DaemonSets(c context.Context, namespace string, labelSelector labels.Set) ([]Workload, error) {
+	ls, err := daemonSets(c, namespace).List(c, listOptions(labelSelector))
+	if err != nil {
+		return nil, err
+	}
+	is := ls.Items
+	os := make([]Workload, len(is))
+	for i := range is {
+		os[i] = DaemonSet(&is[i])
+	}
+	return os, nil
+}
+
+func DaemonSet(d *apps.DaemonSet) Workload {
+	return &daemonSet{d}
+}
+
+// DaemonSetImpl casts the given Object as an *apps.DaemonSet and returns
+// it together with a status flag indicating whether the cast was possible
+func DaemonSetImpl(o Object) (*apps.DaemonSet, bool) {
+	if s, ok := o.(*daemonSet); ok {
+		return s.DaemonSet, true
+	}
+	return nil, false
+}
+
+func GetJob(c context.Context, name, namespace string) (Workload, error) {
+	d, err := jobs(c, namespace).Get(c, name, meta.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &job{d}, nil
+}
+
+func Job(d *batch.Job) Workload {
+	return &job{d}
+}
+
+// JobImpl casts the given Object as an *batch.Job and returns
+// it together with a status flag indicating whether the cast was possible
+func JobImpl(o Object) (*batch.Job, bool) {
+	if s, ok := o.(*job); ok {
+		return s.Job, true
+	}
+	return nil, false
+}
+
 type deployment struct {
 	*apps.Deployment
 }
@@ -371,3 +453,132 @@ func (o *statefulSet) Updated(origGeneration int64) bool {
 		o.Status.CurrentReplicas == o.Status.Replicas
 	return applied
 }
+
+type daemonSet struct {
+	*apps.DaemonSet
+}
+
+func daemonSets(c context.Context, namespace string) typedApps.DaemonSetInterface {
+	return GetK8sInterface(c).AppsV1().DaemonSets(namespace)
+}
+
+func (o *daemonSet) ki(c context.Context) typedApps.DaemonSetInterface {
+	return daemonSets(c, o.Namespace)
+}
+
+func (o *daemonSet) GetKind() string {
+	return "DaemonSet"
+}
+
+func (o *daemonSet) Delete(c context.Context) error {
+	return o.ki(c).Delete(c, o.Name, meta.DeleteOptions{})
+}
+
+func (o *daemonSet) GetPodTemplate() *core.PodTemplateSpec {
+	return &o.Spec.Template
+}
+
+func (o *daemonSet) Patch(c context.Context, pt types.PatchType, data []byte, subresources ...string) error {
+	d, err := o.ki(c).Patch(c, o.Name, pt, data, meta.PatchOptions{}, subresources...)
+	if err == nil {
+		o.DaemonSet = d
+	}
+	return err
+}
+
+func (o *daemonSet) Refresh(c context.Context) error {
+	d, err := o.ki(c).Get(c, o.Name, meta.GetOptions{})
+	if err == nil {
+		o.DaemonSet = d
+	}
+	return err
+}
+
+// Replicas returns the number of nodes that are running the daemon pod.
+func (o *daemonSet) Replicas() int {
+	return int(o.Status.CurrentNumberScheduled)
+}
+
+func (o *daemonSet) Selector() (labels.Selector, error) {
+	return meta.LabelSelectorAsSelector(o.Spec.Selector)
+}
+
+func (o *daemonSet) Update(c context.Context) error {
+	d, err := o.ki(c).Update(c, o.DaemonSet, meta.UpdateOptions{})
+	if err == nil {
+		o.DaemonSet = d
+	}
+	return err
+}
+
+func (o *daemonSet) Updated(origGeneration int64) bool {
+	applied := o.ObjectMeta.Generation >= origGeneration &&
+		o.Status.ObservedGeneration == o.ObjectMeta.Generation &&
+		o.Status.UpdatedNumberScheduled == o.Status.DesiredNumberScheduled &&
+		o.Status.NumberAvailable == o.Status.DesiredNumberScheduled
+	return applied
+}
+
+type job struct {
+	*batch.Job
+}
+
+func jobs(c context.Context, namespace string) typedBatch.JobInterface {
+	return GetK8sInterface(c).BatchV1().Jobs(namespace)
+}
+
+func (o *job) ki(c context.Context) typedBatch.JobInterface {
+	return jobs(c, o.Namespace)
+}
+
+func (o *job) GetKind() string {
+	return "Job"
+}
+
+func (o *job) Delete(c context.Context) error {
+	// The pods of the job must be deleted too.
+	pp := meta.DeletePropagationBackground
+	return o.ki(c).Delete(c, o.Name, meta.DeleteOptions{PropagationPolicy: &pp})
+}
+
+func (o *job) GetPodTemplate() *core.PodTemplateSpec {
+	return &o.Spec.Template
+}
+
+func (o *job) Patch(c context.Context, pt types.PatchType, data []byte, subresources ...string) error {
+	d, err := o.ki(c).Patch(c, o.Name, pt, data, meta.PatchOptions{}, subresources...)
+	if err == nil {
+		o.Job = d
+	}
+	return err
+}
+
+func (o *job) Refresh(c context.Context) error {
+	d, err := o.ki(c).Get(c, o.Name, meta.GetOptions{})
+	if err == nil {
+		o.Job = d
+	}
+	return err
+}
+
+func (o *job) Replicas() int {
+	return int(o.Status.Active)
+}
+
+func (o *job) Selector() (labels.Selector, error) {
+	return meta.LabelSelectorAsSelector(o.Spec.Selector)
+}
+
+func (o *job) Update(c context.Context) error {
+	d, err := o.ki(c).Update(c, o.Job, meta.UpdateOptions{})
+	if err == nil {
+		o.Job = d
+	}
+	return err
+}
+
+// Updated returns true when the job has been updated. The pod template of a job is immutable, so
+// it is considered updated once it has active pods.
+func (o *job) Updated(origGeneration int64) bool {
+	return o.ObjectMeta.Generation >= origGeneration && o.Status.Active > 0
+}
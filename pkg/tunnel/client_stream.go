@@ -43,3 +43,40 @@ type clientStream struct {
 func (s *clientStream) CloseSend(_ context.Context) error {
 	return s.grpcStream.(GRPClientCStream).CloseSend()
 }
+
+// AwaitDial waits for the peer of the given stream to report the outcome of its dial. The context passed to
+// the stream's Receive is cancelled when AwaitDial returns, so that a Receive that honors it doesn't outlive
+// a timeout.
+func AwaitDial(ctx context.Context, stream Stream) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		m   Message
+		err error
+	}
+	rc := make(chan result, 1)
+	go func() {
+		m, err := stream.Receive(ctx)
+		rc <- result{m: m, err: err}
+	}()
+
+	timer := time.NewTimer(stream.DialTimeout() + stream.RoundtripLatency())
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return errors.New("timeout while waiting for dial")
+	case r := <-rc:
+		switch {
+		case r.err != nil:
+			return fmt.Errorf("failed to receive dial response: %w", r.err)
+		case r.m.Code() == DialOK:
+			return nil
+		case r.m.Code() == DialReject:
+			return errors.New("dial rejected")
+		default:
+			return fmt.Errorf("unexpected dial response %s", r.m)
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 // reading or writing any messages. The dialer is normally closed by one of the peers.
 const tcpConnTTL = 2 * time.Hour // Default tcp_keepalive_time on Linux
 const udpConnTTL = 1 * time.Minute
+const echoConnTTL = 1 * time.Minute
 const partlyClosedDuration = 5 * time.Second
 
 const (
@@ -48,6 +49,9 @@ type dialer struct {
 // The handler remains active until it's been idle for idleDuration, at which time it will automatically close
 // and call the release function it got from the tunnel.Pool to ensure that it gets properly released.
 func NewDialer(stream Stream) Endpoint {
+	if p := stream.ID().Protocol(); p == ipproto.ICMP || p == ipproto.ICMPV6 {
+		return newEchoDialer(stream)
+	}
 	return NewConnEndpoint(stream, nil)
 }
 
@@ -0,0 +1,233 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+)
+
+// The echoDialer is an Endpoint that sends the EchoRequest messages that arrive on a stream as ICMP echo
+// requests to the stream's destination, and dispatches the ICMP echo replies as EchoReply messages.
+type echoDialer struct {
+	TimedHandler
+	stream Stream
+	conn   *icmp.PacketConn
+
+	// datagram is true when conn is an unprivileged ICMP socket, false when it is a raw socket
+	datagram  bool
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func newEchoDialer(stream Stream) Endpoint {
+	return &echoDialer{
+		TimedHandler: NewTimedHandler(stream.ID(), echoConnTTL, nil),
+		stream:       stream,
+		done:         make(chan struct{}),
+	}
+}
+
+// listenEcho opens a socket for ICMP messages. An unprivileged ICMP socket is used when the group of the
+// process is included in the net.ipv4.ping_group_range sysctl. A raw socket, which requires the NET_RAW
+// capability, is used otherwise.
+func listenEcho(id ConnID) (conn *icmp.PacketConn, datagram bool, err error) {
+	network, rawNetwork, address := "udp4", "ip4:icmp", "0.0.0.0"
+	if !id.IsIPv4() {
+		network, rawNetwork, address = "udp6", "ip6:ipv6-icmp", "::"
+	}
+	if conn, err = icmp.ListenPacket(network, address); err == nil {
+		return conn, true, nil
+	}
+	if conn, rawErr := icmp.ListenPacket(rawNetwork, address); rawErr == nil {
+		return conn, false, nil
+	}
+	return nil, false, err
+}
+
+func (h *echoDialer) Start(ctx context.Context) {
+	go func() {
+		defer close(h.done)
+		id := h.stream.ID()
+		conn, datagram, err := listenEcho(id)
+		if err != nil {
+			dlog.Errorf(ctx, "!! CONN %s, failed to open ICMP socket: %v", id, err)
+			if err = h.stream.Send(ctx, NewMessage(DialReject, nil)); err != nil {
+				dlog.Errorf(ctx, "!! CONN %s, failed to send DialReject: %v", id, err)
+			}
+			return
+		}
+		if err = h.stream.Send(ctx, NewMessage(DialOK, nil)); err != nil {
+			_ = conn.Close()
+			dlog.Errorf(ctx, "!! CONN %s, failed to send DialOK: %v", id, err)
+			return
+		}
+		h.conn = conn
+		h.datagram = datagram
+
+		// Set up the idle timer to close and release this endpoint when it's been idle for a while.
+		h.TimedHandler.Start(ctx)
+
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+		go h.connToStreamLoop(ctx, &wg)
+		go h.streamToConnLoop(ctx, &wg)
+		wg.Wait()
+		h.Stop(ctx)
+	}()
+}
+
+func (h *echoDialer) Done() <-chan struct{} {
+	return h.done
+}
+
+// Stop will close the underlying ICMP socket
+func (h *echoDialer) Stop(ctx context.Context) {
+	h.closeOnce.Do(func() {
+		dlog.Debugf(ctx, "   CONN %s closing ICMP socket", h.stream.ID())
+		_ = h.conn.Close()
+	})
+}
+
+func (h *echoDialer) destination() net.Addr {
+	ip := h.stream.ID().Destination()
+	if h.datagram {
+		return &net.UDPAddr{IP: ip}
+	}
+	return &net.IPAddr{IP: ip}
+}
+
+func (h *echoDialer) connToStreamLoop(ctx context.Context, wg *sync.WaitGroup) {
+	id := h.stream.ID()
+	outgoing := make(chan Message, 5)
+	defer func() {
+		close(outgoing)
+		wg.Done()
+	}()
+	WriteLoop(ctx, h.stream, outgoing)
+
+	proto := ipproto.ICMP
+	replyType := icmp.Type(ipv4.ICMPTypeEchoReply)
+	if !id.IsIPv4() {
+		proto = ipproto.ICMPV6
+		replyType = ipv6.ICMPTypeEchoReply
+	}
+	dst := id.Destination()
+	buf := make([]byte, 0x10000)
+	for {
+		n, peer, err := h.conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				dlog.Errorf(ctx, "!! CONN %s, read from ICMP socket failed: %v", id, err)
+			}
+			return
+		}
+		if !dst.Equal(addrIP(peer)) {
+			continue
+		}
+		rm, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil || rm.Type != replyType {
+			continue
+		}
+		echo, ok := rm.Body.(*icmp.Echo)
+		// A raw socket receives all ICMP messages, so the identifier must be checked. The kernel assigns the
+		// identifier of an unprivileged ICMP socket and only delivers the replies that match it.
+		if !ok || !(h.datagram || echo.ID == int(id.SourcePort())) {
+			continue
+		}
+		dlog.Tracef(ctx, "<- CONN %s, echo reply seq %d", id, echo.Seq)
+		if !h.ResetIdle() {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case outgoing <- EchoMessage(EchoReply, uint16(echo.Seq), echo.Data):
+		}
+	}
+}
+
+func (h *echoDialer) streamToConnLoop(ctx context.Context, wg *sync.WaitGroup) {
+	var endReason string
+	id := h.stream.ID()
+	defer func() {
+		wg.Done()
+		h.Stop(ctx)
+		dlog.Debugf(ctx, "   CONN %s stream-to-conn loop ended because %s", id, endReason)
+	}()
+
+	requestType := icmp.Type(ipv4.ICMPTypeEcho)
+	if !id.IsIPv4() {
+		requestType = ipv6.ICMPTypeEchoRequest
+	}
+	dst := h.destination()
+	incoming, errCh := ReadLoop(ctx, h.stream)
+	for {
+		select {
+		case <-ctx.Done():
+			endReason = ctx.Err().Error()
+			return
+		case <-h.Idle():
+			endReason = "it was idle for too long"
+			return
+		case err := <-errCh:
+			dlog.Error(ctx, err)
+		case m := <-incoming:
+			if m == nil {
+				endReason = "there was no more input"
+				return
+			}
+			if !h.ResetIdle() {
+				endReason = "it was idle for too long"
+				return
+			}
+			switch m.Code() {
+			case EchoRequest:
+			case Disconnect:
+				endReason = "the peer disconnected"
+				return
+			case KeepAlive:
+				continue
+			default:
+				dlog.Errorf(ctx, "!! CONN %s: unhandled echo message: %s", id, m)
+				continue
+			}
+			seq, data, err := GetEcho(m)
+			if err != nil {
+				dlog.Errorf(ctx, "!! CONN %s: %v", id, err)
+				continue
+			}
+			// The checksum is computed by the kernel for ICMPv6, and by Marshal for ICMPv4.
+			wm := icmp.Message{Type: requestType, Body: &icmp.Echo{ID: int(id.SourcePort()), Seq: int(seq), Data: data}}
+			b, err := wm.Marshal(nil)
+			if err != nil {
+				dlog.Errorf(ctx, "!! CONN %s: %v", id, err)
+				continue
+			}
+			if _, err = h.conn.WriteTo(b, dst); err != nil {
+				endReason = fmt.Sprintf("a write error occurred: %v", err)
+				return
+			}
+			dlog.Tracef(ctx, "-> CONN %s, echo request seq %d", id, seq)
+		}
+	}
+}
+
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	default:
+		return nil
+	}
+}
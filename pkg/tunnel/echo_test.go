@@ -0,0 +1,67 @@
+package tunnel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+func TestEchoMessage(t *testing.T) {
+	m := EchoMessage(EchoRequest, 4711, []byte("ping data"))
+	assert.Equal(t, EchoRequest, m.Code())
+	seq, data, err := GetEcho(m)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(4711), seq)
+	assert.Equal(t, []byte("ping data"), data)
+
+	_, _, err = GetEcho(NewMessage(EchoReply, []byte{1}))
+	assert.Error(t, err)
+}
+
+func TestEchoDialer(t *testing.T) {
+	ctx, cancel := testContext(t, 10*time.Second)
+	defer cancel()
+
+	tunnel := newBidi(10, ctx.Done())
+	id := NewConnID(ipproto.ICMP, iputil.Parse("127.0.0.2"), iputil.Parse("127.0.0.1"), 0x1234, 0)
+	go func() {
+		server, err := NewServerStream(ctx, tunnel.serverSide())
+		if !assert.NoError(t, err) {
+			return
+		}
+		d := NewDialer(server)
+		d.Start(ctx)
+		<-d.Done()
+	}()
+
+	client, err := NewClientStream(ctx, tunnel.clientSide(), id, uuid.New().String(), time.Second, time.Second)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, EchoVersion, client.PeerVersion())
+	defer func() {
+		_ = client.CloseSend(ctx)
+	}()
+
+	m, err := client.Receive(ctx)
+	require.NoError(t, err)
+	if m.Code() == DialReject {
+		t.Skip("not permitted to open an ICMP socket")
+	}
+	require.Equal(t, DialOK, m.Code())
+
+	for seq := uint16(1); seq <= 3; seq++ {
+		require.NoError(t, client.Send(ctx, EchoMessage(EchoRequest, seq, []byte("ping data"))))
+		m, err = client.Receive(ctx)
+		require.NoError(t, err)
+		require.Equal(t, EchoReply, m.Code())
+		rs, data, err := GetEcho(m)
+		require.NoError(t, err)
+		assert.Equal(t, seq, rs)
+		assert.Equal(t, []byte("ping data"), data)
+	}
+}
@@ -22,6 +22,8 @@ const (
 	Disconnect
 	KeepAlive
 	Session
+	EchoRequest
+	EchoReply
 )
 
 func (c MessageCode) String() string {
@@ -42,6 +44,10 @@ func (c MessageCode) String() string {
 		return "KEEP_ALIVE"
 	case Session:
 		return "SESSION"
+	case EchoRequest:
+		return "ECHO_REQUEST"
+	case EchoReply:
+		return "ECHO_REPLY"
 	default:
 		return fmt.Sprintf("** unknown control code: %d **", c)
 	}
@@ -124,6 +130,24 @@ func GetSession(m Message) string {
 	return string(m.Payload())
 }
 
+// EchoMessage returns an EchoRequest or EchoReply message with the given sequence number and data.
+func EchoMessage(code MessageCode, seq uint16, data []byte) Message {
+	m := makeMessage(code, 2+len(data))
+	pl := m.Payload()
+	binary.BigEndian.PutUint16(pl, seq)
+	copy(pl[2:], data)
+	return m
+}
+
+// GetEcho returns the sequence number and data of an EchoRequest or EchoReply message.
+func GetEcho(m Message) (uint16, []byte, error) {
+	pl := m.Payload()
+	if len(pl) < 2 {
+		return 0, nil, errors.New("malformed Echo message")
+	}
+	return binary.BigEndian.Uint16(pl), pl[2:], nil
+}
+
 func makeMessage(code MessageCode, payloadLength int) msg {
 	m := make(msg, 1+payloadLength)
 	m[0] = byte(code)
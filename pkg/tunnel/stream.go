@@ -15,7 +15,11 @@ import (
 // Version
 //   0 which didn't report versions and didn't do synchronization
 //   1 used MuxTunnel instead of one tunnel per connection.
-const Version = uint16(2)
+//   2 didn't support ICMP echo.
+const Version = uint16(3)
+
+// EchoVersion is the first Version that supports the EchoRequest and EchoReply messages.
+const EchoVersion = uint16(3)
 
 // Endpoint is an endpoint for a Stream such as a Dialer or a bidirectional pipe.
 type Endpoint interface {
@@ -328,3 +328,30 @@ func TestStream_Xfer(t *testing.T) {
 		errs = requireNoErrs(t, errs)
 	})
 }
+
+// blockingStream is a Stream whose Receive blocks until its context is cancelled.
+type blockingStream struct {
+	Stream
+	returned chan struct{}
+}
+
+func (s *blockingStream) DialTimeout() time.Duration      { return 10 * time.Millisecond }
+func (s *blockingStream) RoundtripLatency() time.Duration { return 10 * time.Millisecond }
+
+func (s *blockingStream) Receive(ctx context.Context) (Message, error) {
+	<-ctx.Done()
+	close(s.returned)
+	return nil, ctx.Err()
+}
+
+func TestAwaitDial_Timeout(t *testing.T) {
+	ctx, cancel := testContext(t, 10*time.Second)
+	defer cancel()
+	s := &blockingStream{returned: make(chan struct{})}
+	require.ErrorContains(t, AwaitDial(ctx, s), "timeout while waiting for dial")
+	select {
+	case <-s.returned:
+	case <-ctx.Done():
+		t.Fatal("Receive was not cancelled when AwaitDial returned")
+	}
+}
@@ -14,6 +14,13 @@ func OpenTun(ctx context.Context) (*Device, error) {
 	return openTun(ctx)
 }
 
+// OpenTunInNamespace is like OpenTun, but the device is moved into a new network namespace with the
+// given name, where it becomes the default route. The namespace is deleted when the device is closed.
+// This is only supported on Linux.
+func OpenTunInNamespace(ctx context.Context, netns string) (*Device, error) {
+	return openTunInNamespace(ctx, netns)
+}
+
 // AddSubnet adds a subnet to this TUN device and creates a route for that subnet which
 // is associated with the device (removing the device will automatically remove the route).
 func (t *Device) AddSubnet(ctx context.Context, subnet *net.IPNet) error {
@@ -44,10 +51,31 @@ func (t *Device) Name() string {
 	return t.name
 }
 
+// PacketCapture is a function that receives the packets that are read from, or written to, a Device.
+// The data is only valid during the call, so a function that retains it must make a copy.
+type PacketCapture func(data []byte)
+
+// SetPacketCapture installs a PacketCapture that will receive all packets that are read from, or
+// written to, this device. The current PacketCapture, if any, is removed when nil is passed.
+func (t *Device) SetPacketCapture(pc PacketCapture) {
+	t.capture.Store(pc)
+}
+
+func (t *Device) packetCapture() PacketCapture {
+	pc, _ := t.capture.Load().(PacketCapture)
+	return pc
+}
+
 // ReadPacket reads as many bytes as possible into the given buffer.Data and returns the
 // number of bytes actually read
 func (t *Device) ReadPacket(into *buffer.Data) (int, error) {
-	return t.readPacket(into)
+	n, err := t.readPacket(into)
+	if n > 0 {
+		if pc := t.packetCapture(); pc != nil {
+			pc(into.Buf()[:n])
+		}
+	}
+	return n, err
 }
 
 // SetDNS sets the DNS configuration for the device on the windows platform
@@ -58,6 +86,13 @@ func (t *Device) SetDNS(ctx context.Context, server net.IP, domains []string) (e
 // WritePacket writes bytes from the buffer.Data starting at offset and returns the number of bytes
 // actually written.
 func (t *Device) WritePacket(from *buffer.Data, offset int) (int, error) {
+	if offset == 0 {
+		// A non-zero offset means that this is the continuation of a partial write, and the
+		// packet has already been captured.
+		if pc := t.packetCapture(); pc != nil {
+			pc(from.Buf())
+		}
+	}
 	return t.writePacket(from, offset)
 }
 
@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"runtime"
+	"sync/atomic"
 	"unsafe"
 
 	"golang.org/x/net/ipv4"
@@ -23,7 +24,12 @@ const uTunControlName = "com.apple.net.utun_control"
 
 type Device struct {
 	*os.File
-	name string
+	name    string
+	capture atomic.Value // PacketCapture
+}
+
+func openTunInNamespace(_ context.Context, _ string) (*Device, error) {
+	return nil, errors.New("network namespaces are only supported on Linux")
 }
 
 func openTun(_ context.Context) (*Device, error) {
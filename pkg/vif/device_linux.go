@@ -5,12 +5,16 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"sync/atomic"
 	"unsafe"
 
 	"golang.org/x/sys/unix"
 
 	"github.com/datawire/dlib/dexec"
+	"github.com/datawire/dlib/dlog"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/buffer"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/routing"
 )
@@ -19,8 +23,10 @@ const devicePath = "/dev/net/tun"
 
 type Device struct {
 	*os.File
-	name  string
-	index int32
+	name    string
+	index   int32
+	netns   string       // name of the network namespace that the device was moved into, if any
+	capture atomic.Value // PacketCapture
 }
 
 func openTun(_ context.Context) (*Device, error) {
@@ -92,12 +98,131 @@ func openTun(_ context.Context) (*Device, error) {
 	return &Device{File: os.NewFile(uintptr(fd), devicePath), name: name, index: index}, nil
 }
 
+// openTunInNamespace creates a new network namespace with the given name, and moves a new TUN device into
+// it. The device becomes the default route of the namespace, so the namespace cannot reach anything but
+// what the device provides. The namespace also gets a resolv.conf of its own, which is bind mounted over
+// /etc/resolv.conf by "ip netns exec", so that its processes never use the DNS configuration of the host.
+func openTunInNamespace(ctx context.Context, netns string) (*Device, error) {
+	// A namespace that was left behind by a daemon that didn't terminate properly is replaced.
+	_ = deleteNamespace(ctx, netns)
+	if err := dexec.CommandContext(ctx, "ip", "netns", "add", netns).Run(); err != nil {
+		return nil, fmt.Errorf("failed to create network namespace %s: %w", netns, err)
+	}
+	t, err := openTun(ctx)
+	if err == nil {
+		if err = t.moveToNamespace(ctx, netns); err != nil {
+			_ = t.File.Close()
+		}
+	}
+	if err != nil {
+		_ = deleteNamespace(ctx, netns)
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *Device) moveToNamespace(ctx context.Context, netns string) error {
+	if err := os.MkdirAll(filepath.Dir(NamespaceResolvConf(netns)), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(NamespaceResolvConf(netns), nil, 0644); err != nil {
+		return err
+	}
+	if err := dexec.CommandContext(ctx, "ip", "link", "set", t.name, "netns", netns).Run(); err != nil {
+		return fmt.Errorf("failed to move %s into network namespace %s: %w", t.name, netns, err)
+	}
+	t.netns = netns
+
+	// The device is brought down when it's moved.
+	if err := t.ip(ctx, "link", "set", "lo", "up").Run(); err != nil {
+		return err
+	}
+	if err := t.ip(ctx, "link", "set", t.name, "up").Run(); err != nil {
+		return err
+	}
+	if err := t.ip(ctx, "route", "add", "default", "dev", t.name).Run(); err != nil {
+		return fmt.Errorf("failed to add default route to %s: %w", t.name, err)
+	}
+	if err := t.enableIPv6(ctx); err != nil {
+		// The kernel might not support IPv6 at all.
+		dlog.Warn(ctx, err)
+		return nil
+	}
+	if err := t.ip(ctx, "-6", "route", "add", "default", "dev", t.name).Run(); err != nil {
+		dlog.Warnf(ctx, "failed to add IPv6 default route to %s: %v", t.name, err)
+	}
+	return nil
+}
+
+func deleteNamespace(ctx context.Context, netns string) error {
+	_ = os.RemoveAll(filepath.Dir(NamespaceResolvConf(netns)))
+	cmd := dexec.CommandContext(ctx, "ip", "netns", "del", netns)
+	cmd.DisableLogging = true
+	return cmd.Run()
+}
+
+// NamespaceResolvConf returns the path of the resolv.conf file that "ip netns exec" uses for the given
+// network namespace.
+func NamespaceResolvConf(netns string) string {
+	return filepath.Join("/etc/netns", netns, "resolv.conf")
+}
+
+// NetworkNamespace returns the name of the network namespace that this device was moved into, or an
+// empty string if the device is in the network namespace of the daemon.
+func (t *Device) NetworkNamespace() string {
+	return t.netns
+}
+
+// Close closes the device and deletes its network namespace, if any.
+func (t *Device) Close() error {
+	err := t.File.Close()
+	if t.netns != "" {
+		if nsErr := deleteNamespace(context.Background(), t.netns); err == nil {
+			err = nsErr
+		}
+	}
+	return err
+}
+
+// ip returns a command that runs "ip" with the given arguments in the network namespace of the device.
+func (t *Device) ip(ctx context.Context, args ...string) *dexec.Cmd {
+	if t.netns != "" {
+		args = append([]string{"-n", t.netns}, args...)
+	}
+	return dexec.CommandContext(ctx, "ip", args...)
+}
+
 func (t *Device) addSubnet(ctx context.Context, subnet *net.IPNet) error {
-	return dexec.CommandContext(ctx, "ip", "a", "add", subnet.String(), "dev", t.name).Run()
+	if subnet.IP.To4() == nil {
+		if err := t.enableIPv6(ctx); err != nil {
+			return err
+		}
+		// Duplicate address detection is pointless on a TUN device, and would leave the
+		// address in a tentative state for a while.
+		return t.ip(ctx, "-6", "a", "add", subnet.String(), "dev", t.name, "nodad").Run()
+	}
+	return t.ip(ctx, "a", "add", subnet.String(), "dev", t.name).Run()
+}
+
+// enableIPv6 ensures that IPv6 is enabled on the device. A new device inherits its
+// disable_ipv6 setting from net.ipv6.conf.default, which is set on some systems.
+func (t *Device) enableIPv6(ctx context.Context) error {
+	var err error
+	if t.netns != "" {
+		// The /proc/sys/net of this process belongs to the host's network namespace.
+		err = dexec.CommandContext(ctx, "ip", "netns", "exec", t.netns,
+			"sysctl", "-qw", fmt.Sprintf("net.ipv6.conf.%s.disable_ipv6=0", t.name)).Run()
+	} else {
+		err = os.WriteFile(fmt.Sprintf("/proc/sys/net/ipv6/conf/%s/disable_ipv6", t.name), []byte("0"), 0644)
+	}
+	if err != nil {
+		err = fmt.Errorf("unable to enable IPv6 on %s: %w", t.name, err)
+	}
+	return err
 }
 
 func (t *Device) removeSubnet(ctx context.Context, subnet *net.IPNet) error {
-	return dexec.CommandContext(ctx, "ip", "a", "del", subnet.String(), "dev", t.name).Run()
+	return t.ip(ctx, "a", "del", subnet.String(), "dev", t.name).Run()
 }
 
 func (t *Device) addStaticRoute(ctx context.Context, route routing.Route) error {
@@ -114,6 +239,10 @@ func (t *Device) Index() int32 {
 }
 
 func (t *Device) setMTU(mtu int) error {
+	if t.netns != "" {
+		// The ioctl would operate on the host's network namespace.
+		return t.ip(context.Background(), "link", "set", t.name, "mtu", strconv.Itoa(mtu)).Run()
+	}
 	return withSocket(unix.AF_INET, func(fd int) error {
 		var mtuRequest struct {
 			name [unix.IFNAMSIZ]byte
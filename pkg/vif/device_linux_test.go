@@ -0,0 +1,101 @@
+package vif
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+)
+
+func TestDevice_ip(t *testing.T) {
+	ctx := dlog.NewTestContext(t, false)
+	d := &Device{name: "tel0"}
+	assert.Equal(t, []string{"ip", "link", "set", "tel0", "up"}, d.ip(ctx, "link", "set", "tel0", "up").Args)
+	d.netns = "telepresence"
+	assert.Equal(t, []string{"ip", "-n", "telepresence", "link", "set", "tel0", "up"}, d.ip(ctx, "link", "set", "tel0", "up").Args)
+}
+
+func TestNamespaceResolvConf(t *testing.T) {
+	assert.Equal(t, "/etc/netns/telepresence/resolv.conf", NamespaceResolvConf("telepresence"))
+}
+
+// ipOutput returns the output of "ip" in the given network namespace.
+func ipOutput(t *testing.T, netns string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("ip", append([]string{"-n", netns}, args...)...).CombinedOutput()
+	require.NoError(t, err, string(out))
+	return string(out)
+}
+
+func namespaceExists(t *testing.T, netns string) bool {
+	t.Helper()
+	out, err := exec.Command("ip", "netns", "list").Output()
+	require.NoError(t, err)
+	for _, line := range strings.Split(string(out), "\n") {
+		if f := strings.Fields(line); len(f) > 0 && f[0] == netns {
+			return true
+		}
+	}
+	return false
+}
+
+func TestOpenTunInNamespace(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("creating a network namespace requires root")
+	}
+	if _, err := os.Stat(devicePath); err != nil {
+		t.Skipf("no TUN device: %v", err)
+	}
+	ctx := dlog.NewTestContext(t, false)
+	netns := fmt.Sprintf("teltest%d", os.Getpid())
+
+	// A namespace that was left behind is replaced
+	if out, err := exec.Command("ip", "netns", "add", netns).CombinedOutput(); err != nil {
+		t.Skipf("unable to create network namespace: %s", out)
+	}
+	defer func() {
+		if namespaceExists(t, netns) {
+			_ = exec.Command("ip", "netns", "del", netns).Run()
+		}
+	}()
+
+	d, err := openTunInNamespace(ctx, netns)
+	require.NoError(t, err)
+	closed := false
+	defer func() {
+		if !closed {
+			_ = d.Close()
+		}
+	}()
+	assert.Equal(t, netns, d.NetworkNamespace())
+
+	// The device is moved out of the host's network namespace
+	_, err = net.InterfaceByName(d.name)
+	assert.Error(t, err)
+
+	// The device is up and the default route of the namespace, and the namespace has a resolv.conf of its own
+	assert.Contains(t, ipOutput(t, netns, "link", "show", "dev", d.name), "UP")
+	assert.Contains(t, ipOutput(t, netns, "link", "show", "dev", "lo"), "UP")
+	assert.Contains(t, ipOutput(t, netns, "route", "show", "default"), "dev "+d.name)
+	assert.FileExists(t, NamespaceResolvConf(netns))
+
+	// Subnets are added to the device in the namespace
+	_, subnet, err := net.ParseCIDR("10.1.0.0/16")
+	require.NoError(t, err)
+	require.NoError(t, d.addSubnet(ctx, subnet))
+	assert.Contains(t, ipOutput(t, netns, "addr", "show", "dev", d.name), "10.1.0.0/16")
+
+	// Closing the device deletes the namespace and its resolv.conf
+	closed = true
+	require.NoError(t, d.Close())
+	assert.False(t, namespaceExists(t, netns))
+	assert.NoDirExists(t, filepath.Dir(NamespaceResolvConf(netns)))
+}
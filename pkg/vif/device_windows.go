@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/netip"
 	"strings"
+	"sync/atomic"
 
 	"golang.org/x/sys/windows"
 	"golang.zx2c4.com/wireguard/tun"
@@ -27,6 +28,11 @@ type Device struct {
 	name           string
 	dns            net.IP
 	interfaceIndex uint32
+	capture        atomic.Value // PacketCapture
+}
+
+func openTunInNamespace(_ context.Context, _ string) (*Device, error) {
+	return nil, errors.New("network namespaces are only supported on Linux")
 }
 
 func openTun(ctx context.Context) (td *Device, err error) {
@@ -0,0 +1,107 @@
+package icmp
+
+import (
+	"context"
+	"time"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/ip"
+)
+
+// EchoHandler dispatches ICMP echo requests to a tunnel.Stream, and the echo replies from that stream
+// to the TUN device. All requests with the same source, destination, and identifier use the same handler.
+type EchoHandler interface {
+	tunnel.Handler
+	HandleEcho(ctx context.Context, pkt Packet)
+}
+
+type echoHandler struct {
+	tunnel.TimedHandler
+	stream  tunnel.Stream
+	toTun   ip.Writer
+	fromTun chan Packet
+}
+
+const ioChannelSize = 0x40
+const idleDuration = 10 * time.Second
+
+func NewEchoHandler(stream tunnel.Stream, toTun ip.Writer, id tunnel.ConnID, remove func()) EchoHandler {
+	return &echoHandler{
+		TimedHandler: tunnel.NewTimedHandler(id, idleDuration, remove),
+		stream:       stream,
+		toTun:        toTun,
+		fromTun:      make(chan Packet, ioChannelSize),
+	}
+}
+
+func (h *echoHandler) HandleEcho(ctx context.Context, pkt Packet) {
+	select {
+	case <-ctx.Done():
+		pkt.Release()
+	case h.fromTun <- pkt:
+	}
+}
+
+func (h *echoHandler) Start(ctx context.Context) {
+	h.TimedHandler.Start(ctx)
+	go h.readLoop(ctx)
+	go h.writeLoop(ctx)
+}
+
+func (h *echoHandler) readLoop(ctx context.Context) {
+	defer h.Stop(ctx)
+	for ctx.Err() == nil {
+		m, err := h.stream.Receive(ctx)
+		if err != nil {
+			return
+		}
+		switch m.Code() {
+		case tunnel.DialOK:
+		case tunnel.DialReject, tunnel.Disconnect:
+			return
+		case tunnel.EchoReply:
+			seq, data, err := tunnel.GetEcho(m)
+			if err != nil {
+				dlog.Errorf(ctx, "!! TUN %s: %v", h.ID, err)
+				continue
+			}
+			pkt := EchoReplyPacket(h.ID, seq, data)
+			dlog.Tracef(ctx, "-> TUN %s", pkt)
+			if err = h.toTun.Write(ctx, pkt); err != nil {
+				dlog.Errorf(ctx, "!! TUN %s: %v", h.ID, err)
+			}
+			pkt.Release()
+		}
+	}
+}
+
+func (h *echoHandler) writeLoop(ctx context.Context) {
+	defer func() {
+		h.Stop(ctx)
+		_ = h.stream.CloseSend(ctx)
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.Idle():
+			return
+		case pkt := <-h.fromTun:
+			if !h.ResetIdle() {
+				pkt.Release()
+				return
+			}
+			dlog.Tracef(ctx, "<- TUN %s", pkt)
+			icmpHdr := pkt.Header()
+			err := h.stream.Send(ctx, tunnel.EchoMessage(tunnel.EchoRequest, icmpHdr.Sequence(), icmpHdr.Payload()))
+			pkt.Release()
+			if err != nil {
+				if ctx.Err() == nil {
+					dlog.Errorf(ctx, "failed to send EchoRequest: %v", err)
+				}
+				return
+			}
+		}
+	}
+}
@@ -38,6 +38,24 @@ func (h Header) RestOfHeader() []byte {
 	return h[4:8]
 }
 
+// Identifier returns the identifier of an echo request or echo reply.
+func (h Header) Identifier() uint16 {
+	return binary.BigEndian.Uint16(h[4:])
+}
+
+func (h Header) SetIdentifier(id uint16) {
+	binary.BigEndian.PutUint16(h[4:], id)
+}
+
+// Sequence returns the sequence number of an echo request or echo reply.
+func (h Header) Sequence() uint16 {
+	return binary.BigEndian.Uint16(h[6:])
+}
+
+func (h Header) SetSequence(seq uint16) {
+	binary.BigEndian.PutUint16(h[6:], seq)
+}
+
 func (h Header) Payload() []byte {
 	return h[8:]
 }
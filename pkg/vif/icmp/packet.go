@@ -1,6 +1,7 @@
 package icmp
 
 import (
+	"encoding/binary"
 	"fmt"
 	"net"
 
@@ -9,6 +10,7 @@ import (
 	"golang.org/x/net/ipv6"
 
 	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/buffer"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/ip"
 )
@@ -102,29 +104,74 @@ const (
 const IPv6MinMTU = 1280 // From RFC 2460, section 5
 
 func DestinationUnreachablePacket(origHdr ip.Header, code UnreachableCode) Packet {
+	return errorPacket(origHdr, ipv4.ICMPTypeDestinationUnreachable, ipv6.ICMPTypeDestinationUnreachable, int(code), 0)
+}
+
+// PacketTooBigPacket creates a "fragmentation needed" (IPv4) or "packet too big" (IPv6) message that tells
+// the sender of the given packet that it must not send packets larger than the given MTU.
+func PacketTooBigPacket(origHdr ip.Header, mtu int) Packet {
+	return errorPacket(origHdr, ipv4.ICMPTypeDestinationUnreachable, ipv6.ICMPTypePacketTooBig, int(MustFragment), uint32(mtu))
+}
+
+// errorPacket creates an ICMP error message that is a reply to the given packet. The rest of the ICMP header
+// is set to the given value, which for both the IPv4 "fragmentation needed" and the IPv6 "packet too big"
+// messages is the MTU.
+func errorPacket(origHdr ip.Header, v4Type ipv4.ICMPType, v6Type ipv6.ICMPType, code int, rest uint32) Packet {
 	var msgType int
 	var origSz int
 	if origHdr.Version() == ipv4.Version {
-		msgType = int(ipv4.ICMPTypeDestinationUnreachable)
+		msgType = int(v4Type)
 
 		// include header + 64 bits of original payload
 		origSz = origHdr.HeaderLen() + 8
 	} else {
-		msgType = int(ipv6.ICMPTypeDestinationUnreachable)
+		msgType = int(v6Type)
+		if v6Type == ipv6.ICMPTypePacketTooBig {
+			code = 0
+		}
 
 		// include as much of invoking packet as possible without the ICMPv6 packet
 		// exceeding the minimum IPv6 MTU
 		origSz = origHdr.HeaderLen() + origHdr.PayloadLen()
-		if HeaderLen+origSz > IPv6MinMTU {
-			origSz = IPv6MinMTU - HeaderLen
+		if ipv6.HeaderLen+HeaderLen+origSz > IPv6MinMTU {
+			origSz = IPv6MinMTU - ipv6.HeaderLen - HeaderLen
 		}
 	}
-	pkt := NewPacket(HeaderLen+origSz, origHdr.Source(), origHdr.Destination())
+	pkt := NewPacket(HeaderLen+origSz, origHdr.Destination(), origHdr.Source())
 	iph := pkt.IPHeader()
 	icmpHdr := Header(iph.Payload())
 	icmpHdr.SetMessageType(msgType)
-	icmpHdr.SetCode(int(code))
+	icmpHdr.SetCode(code)
+	binary.BigEndian.PutUint32(icmpHdr.RestOfHeader(), rest)
 	copy(icmpHdr.Payload(), origHdr.Packet()[:origSz])
 	icmpHdr.SetChecksum(iph)
 	return pkt
 }
+
+// IsEchoRequest returns true if the given packet is an ICMP echo request.
+func IsEchoRequest(pkt Packet) bool {
+	t := pkt.Header().MessageType()
+	if pkt.IPHeader().Version() == ipv4.Version {
+		return t == int(ipv4.ICMPTypeEcho)
+	}
+	return t == int(ipv6.ICMPTypeEchoRequest)
+}
+
+// EchoReplyPacket creates an echo reply to the echo request identified by the given id. The ID of the
+// request is the source port of the id.
+func EchoReplyPacket(id tunnel.ConnID, seq uint16, data []byte) Packet {
+	pkt := NewPacket(HeaderLen+len(data), id.Destination(), id.Source())
+	iph := pkt.IPHeader()
+	icmpHdr := Header(iph.Payload())
+	if iph.Version() == ipv4.Version {
+		icmpHdr.SetMessageType(int(ipv4.ICMPTypeEchoReply))
+	} else {
+		icmpHdr.SetMessageType(int(ipv6.ICMPTypeEchoReply))
+	}
+	icmpHdr.SetCode(0)
+	icmpHdr.SetIdentifier(id.SourcePort())
+	icmpHdr.SetSequence(seq)
+	copy(icmpHdr.Payload(), data)
+	icmpHdr.SetChecksum(iph)
+	return pkt
+}
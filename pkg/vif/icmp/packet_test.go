@@ -0,0 +1,131 @@
+package icmp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+)
+
+// checksumOK verifies the checksum of an ICMPv4 message, which doesn't include a pseudo header.
+func checksumOK(b []byte) bool {
+	s := 0
+	for i := 0; i+1 < len(b); i += 2 {
+		s += int(b[i])<<8 | int(b[i+1])
+	}
+	if len(b)%2 != 0 {
+		s += int(b[len(b)-1]) << 8
+	}
+	for s > 0xffff {
+		s = (s >> 16) + (s & 0xffff)
+	}
+	return s == 0xffff
+}
+
+func TestEchoReplyPacket(t *testing.T) {
+	tests := []struct {
+		name      string
+		proto     int
+		src       net.IP
+		dst       net.IP
+		replyType icmp.Type
+	}{
+		{"IPv4", ipproto.ICMP, iputil.Parse("192.168.1.10"), iputil.Parse("10.1.2.3"), ipv4.ICMPTypeEchoReply},
+		{"IPv6", ipproto.ICMPV6, iputil.Parse("fd00::10"), iputil.Parse("fd00:10:96::3"), ipv6.ICMPTypeEchoReply},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			id := tunnel.NewConnID(tt.proto, tt.src, tt.dst, 0x1234, 0)
+			pkt := EchoReplyPacket(id, 7, []byte("ping data"))
+			defer pkt.Release()
+
+			iph := pkt.IPHeader()
+			assert.True(t, tt.dst.Equal(iph.Source()))
+			assert.True(t, tt.src.Equal(iph.Destination()))
+			assert.Equal(t, tt.proto, iph.L4Protocol())
+			assert.False(t, IsEchoRequest(pkt))
+
+			m, err := icmp.ParseMessage(tt.proto, iph.Payload())
+			require.NoError(t, err)
+			assert.Equal(t, tt.replyType, m.Type)
+			assert.Equal(t, &icmp.Echo{ID: 0x1234, Seq: 7, Data: []byte("ping data")}, m.Body)
+			if tt.proto == ipproto.ICMP {
+				assert.True(t, checksumOK(iph.Payload()))
+			}
+		})
+	}
+}
+
+func TestIsEchoRequest(t *testing.T) {
+	for _, v6 := range []bool{false, true} {
+		src, dst := iputil.Parse("192.168.1.10"), iputil.Parse("10.1.2.3")
+		var echoType icmp.Type = ipv4.ICMPTypeEcho
+		if v6 {
+			src, dst = iputil.Parse("fd00::10"), iputil.Parse("fd00:10:96::3")
+			echoType = ipv6.ICMPTypeEchoRequest
+		}
+		b, err := (&icmp.Message{Type: echoType, Body: &icmp.Echo{ID: 1, Seq: 2, Data: []byte("x")}}).Marshal(nil)
+		require.NoError(t, err)
+		pkt := NewPacket(len(b), src, dst)
+		copy(pkt.IPHeader().Payload(), b)
+		assert.True(t, IsEchoRequest(pkt))
+		assert.Equal(t, uint16(1), pkt.Header().Identifier())
+		assert.Equal(t, uint16(2), pkt.Header().Sequence())
+
+		reply := DestinationUnreachablePacket(pkt.IPHeader(), HostUnreachable)
+		assert.True(t, dst.Equal(reply.IPHeader().Source()))
+		assert.True(t, src.Equal(reply.IPHeader().Destination()))
+		assert.False(t, IsEchoRequest(reply))
+		pkt.Release()
+		reply.Release()
+	}
+}
+
+func TestPacketTooBigPacket(t *testing.T) {
+	tests := []struct {
+		name  string
+		proto int
+		src   net.IP
+		dst   net.IP
+	}{
+		{"IPv4", ipproto.ICMP, iputil.Parse("192.168.1.10"), iputil.Parse("10.1.2.3")},
+		{"IPv6", ipproto.ICMPV6, iputil.Parse("fd00::10"), iputil.Parse("fd00:10:96::3")},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			orig := NewPacket(1460, tt.src, tt.dst)
+			defer orig.Release()
+			pkt := PacketTooBigPacket(orig.IPHeader(), 1400)
+			defer pkt.Release()
+
+			iph := pkt.IPHeader()
+			assert.True(t, tt.dst.Equal(iph.Source()))
+			assert.True(t, tt.src.Equal(iph.Destination()))
+			assert.LessOrEqual(t, len(iph.Packet()), IPv6MinMTU)
+
+			m, err := icmp.ParseMessage(tt.proto, iph.Payload())
+			require.NoError(t, err)
+			if tt.proto == ipproto.ICMP {
+				assert.Equal(t, ipv4.ICMPTypeDestinationUnreachable, m.Type)
+				assert.Equal(t, int(MustFragment), m.Code)
+				assert.Equal(t, []byte{0, 0, 0x05, 0x78}, pkt.Header().RestOfHeader())
+				assert.True(t, checksumOK(iph.Payload()))
+			} else {
+				assert.Equal(t, ipv6.ICMPTypePacketTooBig, m.Type)
+				assert.Equal(t, 0, m.Code)
+				require.IsType(t, &icmp.PacketTooBig{}, m.Body)
+				assert.Equal(t, 1400, m.Body.(*icmp.PacketTooBig).MTU)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Package netstack contains a TCP backend for the TUN device that uses gVisor's netstack to terminate the
+// TCP connections of the packets that are read from the device. It's an alternative to the TCP state
+// machine in the vif/tcp package.
+package netstack
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	gbuffer "gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv6"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/waiter"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/buffer"
+)
+
+const (
+	nicID = 1
+
+	// outboundQueueSize is the number of packets that the stack can queue for the TUN device
+	outboundQueueSize = 1024
+
+	// maxInFlight is the maximum number of connections that can be in the process of being established
+	maxInFlight = 1024
+)
+
+// PacketWriter writes IP packets, typically to the TUN device.
+type PacketWriter interface {
+	WritePacket(from *buffer.Data, offset int) (int, error)
+}
+
+// StreamCreator creates a tunnel.Stream for the connection with the given ID.
+type StreamCreator func(ctx context.Context, id tunnel.ConnID) (tunnel.Stream, error)
+
+// Stack terminates TCP connections using gVisor's netstack. Each accepted connection is handed to a
+// tunnel.Endpoint that dispatches its data over a tunnel.Stream, so the stack only replaces the TCP state
+// machine. Everything beyond the TUN device is the same as for the vif/tcp handler.
+type Stack struct {
+	stack         *stack.Stack
+	ep            *channel.Endpoint
+	toTun         PacketWriter
+	streamCreator StreamCreator
+	wg            sync.WaitGroup
+}
+
+// NewStack creates a Stack that writes the packets that it produces to the given PacketWriter, and creates
+// a tunnel.Stream using the given StreamCreator for each connection that it accepts. The stack and all its
+// connections are closed when the context is cancelled or when Close is called.
+func NewStack(ctx context.Context, toTun PacketWriter, mtu int, streamCreator StreamCreator) (*Stack, error) {
+	st := stack.New(stack.Options{
+		NetworkProtocols:   []stack.NetworkProtocolFactory{ipv4.NewProtocol, ipv6.NewProtocol},
+		TransportProtocols: []stack.TransportProtocolFactory{tcp.NewProtocol},
+	})
+	sackEnabled := tcpip.TCPSACKEnabled(true)
+	if err := st.SetTransportProtocolOption(tcp.ProtocolNumber, &sackEnabled); err != nil {
+		return nil, fmt.Errorf("failed to enable TCP SACK: %s", err)
+	}
+	ep := channel.New(outboundQueueSize, uint32(mtu), "")
+	if err := st.CreateNIC(nicID, ep); err != nil {
+		return nil, fmt.Errorf("failed to create NIC: %s", err)
+	}
+
+	// The stack answers on behalf of every address that is routed to the TUN device, so it must accept
+	// packets to, and send packets from, addresses that it hasn't been assigned.
+	if err := st.SetPromiscuousMode(nicID, true); err != nil {
+		return nil, fmt.Errorf("failed to enable promiscuous mode: %s", err)
+	}
+	if err := st.SetSpoofing(nicID, true); err != nil {
+		return nil, fmt.Errorf("failed to enable spoofing: %s", err)
+	}
+	st.SetRouteTable([]tcpip.Route{
+		{Destination: header.IPv4EmptySubnet, NIC: nicID},
+		{Destination: header.IPv6EmptySubnet, NIC: nicID},
+	})
+
+	s := &Stack{
+		stack:         st,
+		ep:            ep,
+		toTun:         toTun,
+		streamCreator: streamCreator,
+	}
+	fwd := tcp.NewForwarder(st, 0, maxInFlight, func(r *tcp.ForwarderRequest) {
+		// The forwarder calls this function from the goroutine that delivers the packet, so it must not block.
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handleRequest(ctx, r)
+		}()
+	})
+	st.SetTransportProtocolHandler(tcp.ProtocolNumber, fwd.HandlePacket)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.writeLoop(ctx)
+	}()
+	return s, nil
+}
+
+// HandlePacket delivers a packet that was read from the TUN device to the stack. The packet data is
+// copied, so the caller retains the ownership of the given data.
+func (s *Stack) HandlePacket(ctx context.Context, data *buffer.Data) {
+	b := data.Buf()
+	if len(b) == 0 {
+		return
+	}
+	var proto tcpip.NetworkProtocolNumber
+	switch b[0] >> 4 {
+	case 4:
+		proto = ipv4.ProtocolNumber
+	case 6:
+		proto = ipv6.ProtocolNumber
+	default:
+		dlog.Tracef(ctx, "netstack ignoring packet with IP version %d", b[0]>>4)
+		return
+	}
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: gbuffer.MakeWithData(b)})
+	s.ep.InjectInbound(proto, pkt)
+	pkt.DecRef()
+}
+
+// Close closes the stack and all its connections, and waits for them to terminate.
+func (s *Stack) Close() {
+	s.ep.Close()
+	s.stack.Close()
+	s.wg.Wait()
+}
+
+// writeLoop writes the packets that are produced by the stack to the TUN device.
+func (s *Stack) writeLoop(ctx context.Context) {
+	for {
+		pkt := s.ep.ReadContext(ctx)
+		if pkt == nil {
+			// The context is done
+			return
+		}
+		view := pkt.ToView()
+		pkt.DecRef()
+		data := buffer.DataPool.Get(view.Size())
+		copy(data.Buf(), view.AsSlice())
+		view.Release()
+		if err := s.writePacket(data); err != nil {
+			if ctx.Err() == nil {
+				dlog.Errorf(ctx, "TUN write failed: %v", err)
+			}
+		}
+		buffer.DataPool.Put(data)
+	}
+}
+
+func (s *Stack) writePacket(data *buffer.Data) error {
+	l := len(data.Buf())
+	o := 0
+	for {
+		n, err := s.toTun.WritePacket(data, o)
+		if err != nil {
+			return err
+		}
+		l -= n
+		if l == 0 {
+			return nil
+		}
+		o += n
+	}
+}
+
+// handleRequest handles the SYN of a new connection. The handshake isn't completed until the peer of the
+// stream has dialed the destination, so that a failed dial is reported to the client as a reset.
+func (s *Stack) handleRequest(ctx context.Context, r *tcp.ForwarderRequest) {
+	// The stream is created using this context, so cancelling it when the connection ends also ends a
+	// Receive that is blocked in a failed AwaitDial.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	id := r.ID()
+
+	// The ID is from the perspective of the stack, so the remote address is the source of the connection
+	connID := tunnel.NewConnID(ipproto.TCP,
+		net.IP(id.RemoteAddress.AsSlice()), net.IP(id.LocalAddress.AsSlice()), id.RemotePort, id.LocalPort)
+	dlog.Tracef(ctx, "<- TUN %s, SYN", connID)
+
+	stream, err := s.streamCreator(ctx, connID)
+	if err != nil {
+		dlog.Errorf(ctx, "!! CON %s, failed to create stream: %v", connID, err)
+		r.Complete(true)
+		return
+	}
+	if err = tunnel.AwaitDial(ctx, stream); err != nil {
+		dlog.Debugf(ctx, "!! CON %s, %v", connID, err)
+		_ = stream.CloseSend(ctx)
+		r.Complete(true)
+		return
+	}
+
+	var wq waiter.Queue
+	ep, tcpErr := r.CreateEndpoint(&wq)
+	r.Complete(false)
+	if tcpErr != nil {
+		dlog.Errorf(ctx, "!! CON %s, failed to create endpoint: %s", connID, tcpErr)
+		_ = stream.CloseSend(ctx)
+		return
+	}
+	ep.SocketOptions().SetKeepAlive(true)
+
+	d := tunnel.NewConnEndpoint(stream, gonet.NewTCPConn(&wq, ep))
+	d.Start(ctx)
+	<-d.Done()
+}
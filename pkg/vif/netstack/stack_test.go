@@ -0,0 +1,290 @@
+package netstack
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gbuffer "gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	gstack "gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/buffer"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/ip"
+	vtcp "github.com/telepresenceio/telepresence/v2/pkg/vif/tcp"
+)
+
+// echoStream is a tunnel.Stream whose peer responds to the dial with the given code, and then echoes
+// all data that it receives.
+type echoStream struct {
+	id        tunnel.ConnID
+	in        chan tunnel.Message
+	closeOnce sync.Once
+}
+
+func newEchoStream(id tunnel.ConnID, dialResponse tunnel.MessageCode) *echoStream {
+	s := &echoStream{id: id, in: make(chan tunnel.Message, 100)}
+	s.in <- tunnel.NewMessage(dialResponse, nil)
+	return s
+}
+
+func (s *echoStream) Tag() string                     { return "TEST" }
+func (s *echoStream) ID() tunnel.ConnID               { return s.id }
+func (s *echoStream) PeerVersion() uint16             { return tunnel.Version }
+func (s *echoStream) SessionID() string               { return "session" }
+func (s *echoStream) DialTimeout() time.Duration      { return time.Second }
+func (s *echoStream) RoundtripLatency() time.Duration { return time.Second }
+
+func (s *echoStream) Receive(context.Context) (tunnel.Message, error) {
+	m, ok := <-s.in
+	if !ok {
+		return nil, net.ErrClosed
+	}
+	return m, nil
+}
+
+func (s *echoStream) Send(_ context.Context, m tunnel.Message) error {
+	if m.Code() == tunnel.Normal {
+		s.in <- tunnel.NewMessage(tunnel.Normal, append([]byte(nil), m.Payload()...))
+	}
+	return nil
+}
+
+func (s *echoStream) CloseSend(context.Context) error {
+	s.closeOnce.Do(func() { close(s.in) })
+	return nil
+}
+
+// injectWriter injects the packets that are written to it into a gVisor link endpoint.
+type injectWriter struct {
+	ep *channel.Endpoint
+}
+
+func (w injectWriter) WritePacket(from *buffer.Data, offset int) (int, error) {
+	b := from.Buf()[offset:]
+	w.inject(b)
+	return len(b), nil
+}
+
+func (w injectWriter) Write(_ context.Context, pkt ip.Packet) error {
+	w.inject(pkt.Data().Buf())
+	return nil
+}
+
+func (w injectWriter) inject(b []byte) {
+	pkt := gstack.NewPacketBuffer(gstack.PacketBufferOptions{Payload: gbuffer.MakeWithData(b)})
+	w.ep.InjectInbound(ipv4.ProtocolNumber, pkt)
+	pkt.DecRef()
+}
+
+// packetHandler handles a packet that was read from the TUN device, and takes ownership of its data.
+type packetHandler func(ctx context.Context, data *buffer.Data)
+
+// newGVisorHandler creates a Stack and returns a packetHandler that delivers packets to it.
+func newGVisorHandler(ctx context.Context, tb testing.TB, toTun injectWriter, streamCreator StreamCreator) packetHandler {
+	s, err := NewStack(ctx, toTun, 1500, streamCreator)
+	require.NoError(tb, err)
+	tb.Cleanup(s.Close)
+	return func(ctx context.Context, data *buffer.Data) {
+		s.HandlePacket(ctx, data)
+		buffer.DataPool.Put(data)
+	}
+}
+
+// newLegacyHandler returns a packetHandler that dispatches packets to the TCP state machine in the vif/tcp
+// package, using one handler per connection just like the router does.
+func newLegacyHandler(ctx context.Context, tb testing.TB, toTun injectWriter, streamCreator StreamCreator) packetHandler {
+	pool := tunnel.NewPool()
+	closing := int32(0)
+	tb.Cleanup(func() { pool.CloseAll(ctx) })
+	return func(ctx context.Context, data *buffer.Data) {
+		ipHdr, err := ip.ParseHeader(data.Buf())
+		if err != nil || ipHdr.L4Protocol() != ipproto.TCP {
+			buffer.DataPool.Put(data)
+			return
+		}
+		pkt := vtcp.PacketFromData(ipHdr, data)
+		tcpHdr := pkt.Header()
+		id := tunnel.NewConnID(ipproto.TCP, ipHdr.Source(), ipHdr.Destination(), tcpHdr.SourcePort(), tcpHdr.DestinationPort())
+		if !tcpHdr.SYN() {
+			if h := pool.Get(id); h != nil {
+				h.(vtcp.PacketHandler).HandlePacket(ctx, pkt)
+			} else {
+				pkt.Release()
+			}
+			return
+		}
+		h, _, err := pool.GetOrCreate(ctx, id, func(ctx context.Context, remove func()) (tunnel.Handler, error) {
+			return vtcp.NewHandler(func(ctx context.Context) (tunnel.Stream, error) {
+				return streamCreator(ctx, id)
+			}, &closing, toTun, id, remove, rand.NewSource(1)), nil
+		})
+		if err != nil {
+			pkt.Release()
+			return
+		}
+		h.(vtcp.PacketHandler).HandlePacket(ctx, pkt)
+	}
+}
+
+// newClient creates a gVisor stack that acts as the workstation, and that routes everything to a TCP stack
+// created by the given function.
+func newClient(
+	ctx context.Context,
+	tb testing.TB,
+	dialResponse tunnel.MessageCode,
+	newHandler func(context.Context, testing.TB, injectWriter, StreamCreator) packetHandler,
+) (*gstack.Stack, *sync.Map) {
+	ctx, cancel := context.WithCancel(ctx)
+	cep := channel.New(outboundQueueSize, 1500, "")
+
+	streams := &sync.Map{}
+	handle := newHandler(ctx, tb, injectWriter{ep: cep}, func(_ context.Context, id tunnel.ConnID) (tunnel.Stream, error) {
+		es := newEchoStream(id, dialResponse)
+		streams.Store(id, es)
+		return es, nil
+	})
+
+	cs := gstack.New(gstack.Options{
+		NetworkProtocols:   []gstack.NetworkProtocolFactory{ipv4.NewProtocol},
+		TransportProtocols: []gstack.TransportProtocolFactory{tcp.NewProtocol},
+	})
+	require.Nil(tb, cs.CreateNIC(1, cep))
+	require.Nil(tb, cs.AddProtocolAddress(1, tcpip.ProtocolAddress{
+		Protocol:          ipv4.ProtocolNumber,
+		AddressWithPrefix: tcpip.AddrFromSlice(net.IPv4(192, 168, 0, 10).To4()).WithPrefix(),
+	}, gstack.AddressProperties{}))
+	cs.SetRouteTable([]tcpip.Route{{Destination: header.IPv4EmptySubnet, NIC: 1}})
+
+	go func() {
+		for {
+			pkt := cep.ReadContext(ctx)
+			if pkt == nil {
+				return
+			}
+			view := pkt.ToView()
+			pkt.DecRef()
+			data := buffer.DataPool.Get(view.Size())
+			copy(data.Buf(), view.AsSlice())
+			view.Release()
+			handle(ctx, data)
+		}
+	}()
+
+	// Cleanups run in reverse order, so this one runs before the handler's cleanup.
+	tb.Cleanup(func() {
+		cancel()
+		cs.Close()
+	})
+	return cs, streams
+}
+
+// dial connects the client to 10.1.2.3:8080.
+func dial(ctx context.Context, cs *gstack.Stack) (*gonet.TCPConn, error) {
+	return gonet.DialContextTCP(ctx, cs, tcpip.FullAddress{
+		NIC:  1,
+		Addr: tcpip.AddrFromSlice(net.IPv4(10, 1, 2, 3).To4()),
+		Port: 8080,
+	}, ipv4.ProtocolNumber)
+}
+
+func TestStack_Echo(t *testing.T) {
+	cs, streams := newClient(dlog.NewTestContext(t, false), t, tunnel.DialOK, newGVisorHandler)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conn, err := dial(ctx, cs)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// A large write makes the data span many segments and windows
+	data := make([]byte, 1<<20)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	go func() {
+		_, _ = conn.Write(data)
+	}()
+	echo := make([]byte, len(data))
+	_, err = io.ReadFull(conn, echo)
+	require.NoError(t, err)
+	assert.Equal(t, data, echo)
+
+	var ids []string
+	streams.Range(func(k, _ any) bool {
+		ids = append(ids, k.(tunnel.ConnID).String())
+		return true
+	})
+	require.Len(t, ids, 1)
+	assert.Contains(t, ids[0], "192.168.0.10")
+	assert.Contains(t, ids[0], "10.1.2.3:8080")
+}
+
+func TestStack_DialReject(t *testing.T) {
+	cs, _ := newClient(dlog.NewTestContext(t, false), t, tunnel.DialReject, newGVisorHandler)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := dial(ctx, cs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "refused")
+}
+
+// benchmarkEcho measures the throughput of a TCP stack by echoing 64 KiB per iteration over one connection.
+func benchmarkEcho(b *testing.B, newHandler func(context.Context, testing.TB, injectWriter, StreamCreator) packetHandler) {
+	// Logging at debug level would dominate the measurement
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	logger.SetOutput(io.Discard)
+	cs, _ := newClient(dlog.WithLogger(context.Background(), dlog.WrapLogrus(logger)), b, tunnel.DialOK, newHandler)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conn, err := dial(ctx, cs)
+	require.NoError(b, err)
+	defer conn.Close()
+
+	data := make([]byte, 64*1024)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	echo := make([]byte, len(data))
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		errs := make(chan error, 1)
+		go func() {
+			_, err := conn.Write(data)
+			errs <- err
+		}()
+		if _, err = io.ReadFull(conn, echo); err != nil {
+			b.Fatal(err)
+		}
+		if err = <-errs; err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStack_GVisor(b *testing.B) {
+	benchmarkEcho(b, newGVisorHandler)
+}
+
+func BenchmarkStack_Legacy(b *testing.B) {
+	benchmarkEcho(b, newLegacyHandler)
+}
@@ -0,0 +1,260 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/ip"
+)
+
+// Filter decides if a packet should be captured.
+type Filter func(ip.Header) bool
+
+// ParseFilter parses a filter expression. The syntax is a subset of the one used by tcpdump and
+// consists of the following primitives:
+//
+//	[src|dst] host <ip>
+//	[src|dst] net <cidr>
+//	[src|dst] port <number>
+//	ip, ip6, tcp, udp, icmp, icmp6
+//
+// Primitives can be combined using "and" (or "&&"), "or" (or "||"), "not" (or "!"), and parentheses.
+// Primitives that are just separated by whitespace are implicitly combined using "and", so "tcp port 80"
+// is the same as "tcp and port 80". An empty expression matches all packets.
+func ParseFilter(expr string) (Filter, error) {
+	p := &filterParser{tokens: tokenize(expr)}
+	if len(p.tokens) == 0 {
+		return func(ip.Header) bool { return true }, nil
+	}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t != "" {
+		return nil, fmt.Errorf("unexpected %q in filter expression", t)
+	}
+	return f, nil
+}
+
+// Match parses the given packet and returns true if it matches the filter.
+func (f Filter) Match(packet []byte) bool {
+	hdr, err := ip.ParseHeader(packet)
+	return err == nil && f(hdr)
+}
+
+func tokenize(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)
+	return strings.Fields(expr)
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) next() string {
+	t := p.peek()
+	if t != "" {
+		p.pos++
+	}
+	return t
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	f, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek() {
+		case "or", "||":
+			p.next()
+			lf := f
+			rf, err := p.parseAnd()
+			if err != nil {
+				return nil, err
+			}
+			f = func(h ip.Header) bool { return lf(h) || rf(h) }
+		default:
+			return f, nil
+		}
+	}
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	f, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek() {
+		case "", ")", "or", "||":
+			return f, nil
+		case "and", "&&":
+			p.next()
+		}
+		lf := f
+		rf, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		f = func(h ip.Header) bool { return lf(h) && rf(h) }
+	}
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	switch t := p.next(); t {
+	case "":
+		return nil, fmt.Errorf("unexpected end of filter expression")
+	case "not", "!":
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(h ip.Header) bool { return !f(h) }, nil
+	case "(":
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing ')' in filter expression")
+		}
+		return f, nil
+	default:
+		return p.parsePrimitive(t)
+	}
+}
+
+// direction determines what addresses or ports that a primitive is matched against.
+type direction int
+
+const (
+	srcOrDst = direction(iota)
+	src
+	dst
+)
+
+func (p *filterParser) parsePrimitive(t string) (Filter, error) {
+	switch t {
+	case "ip":
+		return func(h ip.Header) bool { return h.Version() == ipv4.Version }, nil
+	case "ip6":
+		return func(h ip.Header) bool { return h.Version() == ipv6.Version }, nil
+	case "tcp":
+		return protoFilter(ipproto.TCP), nil
+	case "udp":
+		return protoFilter(ipproto.UDP), nil
+	case "icmp":
+		return protoFilter(ipproto.ICMP), nil
+	case "icmp6":
+		return protoFilter(ipproto.ICMPV6), nil
+	}
+
+	dir := srcOrDst
+	switch t {
+	case "src":
+		dir = src
+		t = p.next()
+	case "dst":
+		dir = dst
+		t = p.next()
+	}
+
+	arg := p.next()
+	if arg == "" {
+		return nil, fmt.Errorf("missing argument for %q in filter expression", t)
+	}
+	switch t {
+	case "host":
+		hostIP := iputil.Parse(arg)
+		if hostIP == nil {
+			return nil, fmt.Errorf("invalid IP address %q in filter expression", arg)
+		}
+		return addrFilter(dir, hostIP.Equal), nil
+	case "net":
+		_, subnet, err := net.ParseCIDR(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subnet %q in filter expression", arg)
+		}
+		return addrFilter(dir, subnet.Contains), nil
+	case "port":
+		port, err := strconv.ParseUint(arg, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q in filter expression", arg)
+		}
+		return portFilter(dir, uint16(port)), nil
+	default:
+		return nil, fmt.Errorf("unknown primitive %q in filter expression", t)
+	}
+}
+
+func protoFilter(proto int) Filter {
+	return func(h ip.Header) bool { return h.L4Protocol() == proto }
+}
+
+func addrFilter(dir direction, match func(net.IP) bool) Filter {
+	return func(h ip.Header) bool {
+		switch dir {
+		case src:
+			return match(h.Source())
+		case dst:
+			return match(h.Destination())
+		default:
+			return match(h.Source()) || match(h.Destination())
+		}
+	}
+}
+
+func portFilter(dir direction, port uint16) Filter {
+	return func(h ip.Header) bool {
+		switch h.L4Protocol() {
+		case ipproto.TCP, ipproto.UDP:
+		default:
+			return false
+		}
+		// The ports are read from the raw bytes rather than from h.Payload(), because the length
+		// fields of a captured packet aren't guaranteed to be consistent with its actual length.
+		var pl []byte
+		switch hdr := h.(type) {
+		case ip.V4Header:
+			if hdr.FragmentOffset() != 0 {
+				// Only the first fragment contains the layer-4 header
+				return false
+			}
+			pl = hdr
+		case ip.V6Header:
+			pl = hdr
+		}
+		hl := h.HeaderLen()
+		if len(pl) < hl+4 {
+			return false
+		}
+		pl = pl[hl:]
+		srcPort := binary.BigEndian.Uint16(pl)
+		dstPort := binary.BigEndian.Uint16(pl[2:])
+		switch dir {
+		case src:
+			return srcPort == port
+		case dst:
+			return dstPort == port
+		default:
+			return srcPort == port || dstPort == port
+		}
+	}
+}
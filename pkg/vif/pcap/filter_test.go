@@ -0,0 +1,116 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/iputil"
+)
+
+// testPacket creates an IPv4 or IPv6 packet with a payload that starts with the given ports.
+func testPacket(src, dst string, proto int, srcPort, dstPort uint16) []byte {
+	srcIP, dstIP := iputil.Parse(src), iputil.Parse(dst)
+	var b, pl []byte
+	if srcIP.To4() != nil {
+		b = make([]byte, 20+8)
+		b[0] = 0x45
+		binary.BigEndian.PutUint16(b[2:], uint16(len(b)))
+		b[9] = byte(proto)
+		copy(b[12:], srcIP)
+		copy(b[16:], dstIP)
+		pl = b[20:]
+	} else {
+		b = make([]byte, 40+8)
+		b[0] = 0x60
+		binary.BigEndian.PutUint16(b[4:], 8)
+		b[6] = byte(proto)
+		copy(b[8:], srcIP)
+		copy(b[24:], dstIP)
+		pl = b[40:]
+	}
+	binary.BigEndian.PutUint16(pl, srcPort)
+	binary.BigEndian.PutUint16(pl[2:], dstPort)
+	return b
+}
+
+func TestParseFilter(t *testing.T) {
+	tcp4 := testPacket("10.0.0.1", "10.42.0.12", ipproto.TCP, 43210, 8080)
+	udp4 := testPacket("10.0.0.1", "10.96.0.10", ipproto.UDP, 43210, 53)
+	icmp4 := testPacket("10.0.0.1", "10.42.0.12", ipproto.ICMP, 0, 0)
+	tcp6 := testPacket("fd00::1", "fd00:10:244::12", ipproto.TCP, 43210, 8080)
+	all := [][]byte{tcp4, udp4, icmp4, tcp6}
+
+	tests := []struct {
+		expr string
+		want [][]byte
+	}{
+		{"", all},
+		{"tcp", [][]byte{tcp4, tcp6}},
+		{"ip", [][]byte{tcp4, udp4, icmp4}},
+		{"ip6", [][]byte{tcp6}},
+		{"icmp", [][]byte{icmp4}},
+		{"not icmp", [][]byte{tcp4, udp4, tcp6}},
+		{"! tcp", [][]byte{udp4, icmp4}},
+		{"host 10.42.0.12", [][]byte{tcp4, icmp4}},
+		{"src host 10.42.0.12", nil},
+		{"dst host 10.42.0.12", [][]byte{tcp4, icmp4}},
+		{"net 10.96.0.0/12", [][]byte{udp4}},
+		{"net fd00:10:244::/56", [][]byte{tcp6}},
+		{"port 8080", [][]byte{tcp4, tcp6}},
+		{"src port 8080", nil},
+		{"udp port 53", [][]byte{udp4}},
+		{"tcp and port 53", nil},
+		{"udp || icmp", [][]byte{udp4, icmp4}},
+		{"ip and (udp or icmp)", [][]byte{udp4, icmp4}},
+		{"(udp or icmp) and ip6", nil},
+		{"tcp && dst port 8080 and not ip6", [][]byte{tcp4}},
+		{"ip6 or udp and port 53", [][]byte{udp4, tcp6}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := ParseFilter(tt.expr)
+			require.NoError(t, err)
+			var got [][]byte
+			for _, pkt := range all {
+				if f.Match(pkt) {
+					got = append(got, pkt)
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseFilter_invalid(t *testing.T) {
+	for _, expr := range []string{
+		"and",
+		"tcp and",
+		"host",
+		"host example.com",
+		"net 10.0.0.0",
+		"port http",
+		"port 70000",
+		"(tcp or udp",
+		"tcp)",
+		"sctp",
+	} {
+		_, err := ParseFilter(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestFilter_Match_malformed(t *testing.T) {
+	f, err := ParseFilter("port 53")
+	require.NoError(t, err)
+	assert.False(t, f.Match(nil))
+	assert.False(t, f.Match([]byte{0x45, 0, 0}))
+
+	// A UDP packet that is truncated before the ports
+	pkt := testPacket("10.0.0.1", "10.96.0.10", ipproto.UDP, 43210, 53)
+	assert.False(t, f.Match(pkt[:21]))
+}
@@ -0,0 +1,76 @@
+// Package pcap writes the packets of a TUN device in the libpcap file format, so that they can be
+// analyzed with tools like Wireshark or tcpdump.
+//
+// See https://datatracker.ietf.org/doc/draft-ietf-opsawg-pcap/
+package pcap
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	// FileHeaderLen is the length of the header that starts a pcap stream.
+	FileHeaderLen = 24
+
+	// RecordHeaderLen is the length of the header that precedes each packet in a pcap stream.
+	RecordHeaderLen = 16
+
+	magicMicroseconds = 0xa1b2c3d4
+	versionMajor      = 2
+	versionMinor      = 4
+
+	// linkTypeRaw means that each packet begins with an IPv4 or IPv6 header, which is
+	// exactly what is read from and written to a TUN device.
+	linkTypeRaw = 101
+)
+
+// Writer writes packets to an io.Writer in the libpcap file format.
+type Writer struct {
+	w       io.Writer
+	snapLen int
+	hdr     [RecordHeaderLen]byte
+}
+
+// NewWriter writes the pcap file header to the given io.Writer and returns a Writer that
+// will write packets to it. Packets longer than snapLen are truncated.
+func NewWriter(w io.Writer, snapLen int) (*Writer, error) {
+	var hdr [FileHeaderLen]byte
+	binary.LittleEndian.PutUint32(hdr[0:], magicMicroseconds)
+	binary.LittleEndian.PutUint16(hdr[4:], versionMajor)
+	binary.LittleEndian.PutUint16(hdr[6:], versionMinor)
+	// bytes 8 - 15 are the zone and accuracy of the timestamps, both zero
+	binary.LittleEndian.PutUint32(hdr[16:], uint32(snapLen))
+	binary.LittleEndian.PutUint32(hdr[20:], linkTypeRaw)
+	if _, err := w.Write(hdr[:]); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, snapLen: snapLen}, nil
+}
+
+// RecordLen returns the number of bytes that WritePacket will write for a packet of the given length.
+func (w *Writer) RecordLen(packetLen int) int {
+	if packetLen > w.snapLen {
+		packetLen = w.snapLen
+	}
+	return RecordHeaderLen + packetLen
+}
+
+// WritePacket writes the given packet, captured at the given time, to the stream.
+func (w *Writer) WritePacket(ts time.Time, data []byte) error {
+	origLen := len(data)
+	if origLen > w.snapLen {
+		data = data[:w.snapLen]
+	}
+	hdr := w.hdr[:]
+	binary.LittleEndian.PutUint32(hdr[0:], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(ts.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(hdr[12:], uint32(origLen))
+	if _, err := w.w.Write(hdr); err != nil {
+		return err
+	}
+	_, err := w.w.Write(data)
+	return err
+}
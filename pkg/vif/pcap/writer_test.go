@@ -0,0 +1,45 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	buf := bytes.Buffer{}
+	w, err := NewWriter(&buf, 8)
+	require.NoError(t, err)
+
+	hdr := buf.Bytes()
+	require.Len(t, hdr, FileHeaderLen)
+	assert.Equal(t, uint32(magicMicroseconds), binary.LittleEndian.Uint32(hdr))
+	assert.Equal(t, uint16(2), binary.LittleEndian.Uint16(hdr[4:]))
+	assert.Equal(t, uint16(4), binary.LittleEndian.Uint16(hdr[6:]))
+	assert.Equal(t, uint32(8), binary.LittleEndian.Uint32(hdr[16:]))
+	assert.Equal(t, uint32(linkTypeRaw), binary.LittleEndian.Uint32(hdr[20:]))
+
+	ts := time.Unix(1700000000, 123456789)
+	require.NoError(t, w.WritePacket(ts, []byte{1, 2, 3, 4}))
+	require.NoError(t, w.WritePacket(ts, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}))
+	assert.Equal(t, RecordHeaderLen+4, w.RecordLen(4))
+	assert.Equal(t, RecordHeaderLen+8, w.RecordLen(10))
+
+	rec := buf.Bytes()[FileHeaderLen:]
+	require.Len(t, rec, 2*RecordHeaderLen+4+8)
+	assert.Equal(t, uint32(1700000000), binary.LittleEndian.Uint32(rec))
+	assert.Equal(t, uint32(123456), binary.LittleEndian.Uint32(rec[4:]))
+	assert.Equal(t, uint32(4), binary.LittleEndian.Uint32(rec[8:]))
+	assert.Equal(t, uint32(4), binary.LittleEndian.Uint32(rec[12:]))
+	assert.Equal(t, []byte{1, 2, 3, 4}, rec[RecordHeaderLen:RecordHeaderLen+4])
+
+	// The second packet is truncated to the snap length
+	rec = rec[RecordHeaderLen+4:]
+	assert.Equal(t, uint32(8), binary.LittleEndian.Uint32(rec[8:]))
+	assert.Equal(t, uint32(10), binary.LittleEndian.Uint32(rec[12:]))
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, rec[RecordHeaderLen:])
+}
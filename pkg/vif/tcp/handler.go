@@ -3,6 +3,7 @@ package tcp
 import (
 	"context"
 	"encoding/binary"
+	"math"
 	"math/rand"
 	"sync"
 	"sync/atomic"
@@ -61,6 +62,7 @@ type queueElement struct {
 	retries  int32
 	cTime    time.Time
 	packet   Packet
+	sacked   bool
 	next     *queueElement
 }
 
@@ -165,6 +167,16 @@ type handler struct {
 	// determine the actual peerWindow
 	peerWindowScale uint8
 
+	// windowScaling is true when the peer sent a window scale option in its SYN. The window size of all
+	// packets except the SYN packets are then scaled in both directions.
+	windowScaling bool
+
+	// sackPermitted is true when the peer sent a selective acknowledgements permitted option in its SYN.
+	sackPermitted bool
+
+	// lastOutOfOrder is the sequence of the last out-of-order packet that was received from the peer.
+	lastOutOfOrder uint32
+
 	// peerMaxSegmentSize is the maximum size of a segment sent to the peer (not counting IP-header)
 	peerMaxSegmentSize uint16
 
@@ -308,12 +320,31 @@ func (h *handler) newResponse(ipPayloadLen int, withAck bool) Packet {
 	return pkt
 }
 
+// newAck creates an ACK response. The response carries a SACK option that describes the out-of-order
+// packets that have been received when the peer permits selective acknowledgements.
+func (h *handler) newAck() Packet {
+	var opts []option
+	if h.sackPermitted {
+		if blocks := h.outOfOrderBlocks(); len(blocks) > 0 {
+			opts = append(opts, selectiveAckOption(blocks))
+		}
+	}
+	hl := HeaderLen + optionsLen(opts)
+	pkt := h.newResponse(hl, false)
+	if len(opts) > 0 {
+		tcpHdr := pkt.Header()
+		tcpHdr.SetDataOffset(hl / 4)
+		tcpHdr.setOptions(opts)
+	}
+	return pkt
+}
+
 func (h *handler) sendAck(ctx context.Context) {
-	h.sendToTun(ctx, h.newResponse(HeaderLen, false), 0, false)
+	h.sendToTun(ctx, h.newAck(), 0, false)
 }
 
 func (h *handler) forceSendAck(ctx context.Context) {
-	h.sendToTun(ctx, h.newResponse(HeaderLen, false), 0, true)
+	h.sendToTun(ctx, h.newAck(), 0, true)
 }
 
 func (h *handler) sendFin(ctx context.Context, expectAck bool) {
@@ -334,30 +365,37 @@ func (h *handler) sendSynReply(ctx context.Context, syn Packet) {
 		return
 	}
 	h.setPeerSequenceToAck(synHdr.Sequence() + 1)
+	// The sequence comparisons use serial number arithmetic, so lastKnown must start out at the
+	// peer's sequence rather than zero.
+	h.lastKnown = synHdr.Sequence() + 1
 	h.sendSyn(ctx)
 }
 
 func (h *handler) sendSyn(ctx context.Context) {
-	hl := HeaderLen
-	hl += 8 // for the Maximum Segment Size option and for the Window Scale option
+	// The window scale and SACK permitted options may only be sent in reply to a SYN that contains them.
+	opts := []option{maximumSegmentSizeOption(uint16(maxSegmentSize))}
+	if h.windowScaling {
+		opts = append(opts, windowScaleOption(myWindowScale))
+	}
+	if h.sackPermitted {
+		opts = append(opts, selectiveAckPermittedOption())
+	}
+	hl := HeaderLen + optionsLen(opts)
 
 	pkt := h.newResponse(hl, true)
 	tcpHdr := pkt.Header()
 	tcpHdr.SetSYN(true)
-	tcpHdr.SetWindowSize(maxReceiveWindow >> myWindowScale) // The SYN packet itself is not subject to scaling
+
+	// The SYN packet itself is not subject to scaling
+	wz := h.receiveWindow()
+	if wz > math.MaxUint16 {
+		wz = math.MaxUint16
+	}
+	tcpHdr.SetWindowSize(uint16(wz))
 
 	// adjust data offset to account for options
 	tcpHdr.SetDataOffset(hl / 4)
-
-	opts := tcpHdr.OptionBytes()
-	opts[0] = byte(maximumSegmentSize)
-	opts[1] = 4
-	binary.BigEndian.PutUint16(opts[2:], uint16(maxSegmentSize))
-
-	opts[4] = byte(windowScale)
-	opts[5] = 3
-	opts[6] = myWindowScale
-	opts[7] = byte(noOp)
+	tcpHdr.setOptions(opts)
 	h.sendToTun(ctx, pkt, 1, true)
 }
 
@@ -410,7 +448,11 @@ func (h *handler) processPayload(ctx context.Context, data []byte) {
 
 		// Decrease the window size with the bytes that we just sent unless it's already updated
 		// from a received packet
-		atomic.CompareAndSwapInt64(&h.peerWindow, int64(window), int64(window-mxSend))
+		h.sendLock.Lock()
+		if h.peerWindow == int64(window) {
+			h.peerWindow = int64(window - mxSend)
+		}
+		h.sendLock.Unlock()
 		start = end
 	}
 }
@@ -446,8 +488,13 @@ func (h *handler) idle(ctx context.Context, syn Packet) quitReason {
 			dlog.Tracef(ctx, "   CON %s maximum segment size %d", h.id, h.peerMaxSegmentSize)
 		case windowScale:
 			h.peerWindowScale = synOpt.data()[0]
+			if h.peerWindowScale > maxWindowScale {
+				h.peerWindowScale = maxWindowScale
+			}
+			h.windowScaling = true
 			dlog.Tracef(ctx, "   CON %s window scale %d", h.id, h.peerWindowScale)
 		case selectiveAckPermitted:
+			h.sackPermitted = true
 			dlog.Tracef(ctx, "   CON %s selective acknowledgments permitted", h.id)
 		default:
 			dlog.Tracef(ctx, "   CON %s option %d with len %d", h.id, synOpt.kind(), synOpt.len())
@@ -526,6 +573,9 @@ func (h *handler) handleReceived(ctx context.Context, pkt Packet) quitReason {
 
 	ackNbr := tcpHdr.AckNumber()
 	h.onAckReceived(ctx, ackNbr)
+	if h.sackPermitted {
+		h.onSelectiveAckReceived(ctx, tcpHdr)
+	}
 
 	sq := tcpHdr.Sequence()
 	lastAck := h.peerSequenceAcked()
@@ -537,28 +587,29 @@ func (h *handler) handleReceived(ctx context.Context, pkt Packet) quitReason {
 			h.setState(ctx, stateTimedWait)
 			return quitByUs
 		}
-	case sq > lastAck:
+	case seqLT(lastAck, sq):
 		if payloadLen == 0 {
 			break
 		}
-		if sq <= h.lastKnown {
+		if seqLE(sq, h.lastKnown) {
 			// Previous packet lost by us. Don't ack this one, just treat it
 			// as the next lost packet.
 			if payloadLen > 0 {
 				lk := sq + uint32(payloadLen)
-				if lk > h.lastKnown {
+				if seqLT(h.lastKnown, lk) {
 					h.lastKnown = lk
 					h.packetsLost++
 				}
 			}
 			return pleaseContinue
 		}
-		// Oops. Packet loss! Let sender know by sending an ACK so that we ack the receipt
-		// and also tell the sender about our expected number
+		// Oops. Packet loss! Let sender know by sending a duplicate ACK that tells the sender
+		// about our expected number, and about the out-of-order packets that we have received.
 		dlog.Debugf(ctx, "   CON %s, ack-diff %d", pkt, sq-lastAck)
-		h.sendAck(ctx)
-		h.addOutOfOrderPacket(ctx, pkt)
-		release = false
+		if h.addOutOfOrderPacket(ctx, pkt) {
+			release = false
+		}
+		h.forceSendAck(ctx)
 		return pleaseContinue
 	case sq == lastAck-1 && payloadLen == 0:
 		// keep alive, force is needed because the ackNbr is unchanged
@@ -733,7 +784,9 @@ func (h *handler) processResends(ctx context.Context) {
 		for el := h.ackWaitQueue; el != nil; {
 			secs := initialResendDelay << el.retries // 2, 4, 8, 16, ...
 			deadLine := el.cTime.Add(time.Duration(secs) * time.Second)
-			if deadLine.Before(now) {
+			// Packets that the peer has acknowledged selectively are not resent. They remain in the
+			// queue until they are covered by a cumulative ACK.
+			if !el.sacked && deadLine.Before(now) {
 				el.retries++
 				if el.retries > maxResends {
 					el.packet.Release()
@@ -775,7 +828,7 @@ func (h *handler) onAckReceived(ctx context.Context, seq uint32) {
 
 	el := h.ackWaitQueue
 	var prev *queueElement
-	for el != nil && el.sequence > seq {
+	for el != nil && seqLT(seq, el.sequence) {
 		prev = el
 		el = el.next
 	}
@@ -819,27 +872,96 @@ func (h *handler) processNextOutOfOrderPacket(ctx context.Context, process func(
 	return true, false
 }
 
-func (h *handler) addOutOfOrderPacket(ctx context.Context, pkt Packet) {
+// addOutOfOrderPacket adds the given packet to the oooQueue, which is sorted ascending on sequence. It
+// returns false if the queue already contained a packet with the same sequence.
+func (h *handler) addOutOfOrderPacket(ctx context.Context, pkt Packet) bool {
 	hdr := pkt.Header()
 	sq := hdr.Sequence()
 
 	var prev *queueElement
-	for el := h.oooQueue; el != nil; el = el.next {
+	el := h.oooQueue
+	for ; el != nil && seqLE(el.sequence, sq); el = el.next {
 		if el.sequence == sq {
-			return
+			return false
 		}
 		prev = el
 	}
 	dlog.Debugf(ctx, "   CON %s, out-of-order", pkt)
-	el := &queueElement{
+	h.lastOutOfOrder = sq
+	nel := &queueElement{
 		sequence: sq,
 		cTime:    time.Now(),
 		packet:   pkt,
+		next:     el,
 	}
 	if prev == nil {
-		h.oooQueue = el
+		h.oooQueue = nel
 	} else {
-		prev.next = el
+		prev.next = nel
+	}
+	return true
+}
+
+// outOfOrderBlocks returns the SACK blocks that describe the contents of the oooQueue. The block that
+// contains the last received out-of-order packet comes first, as mandated by RFC 2018.
+func (h *handler) outOfOrderBlocks() []sackBlock {
+	ackNbr := h.peerSequenceToAck()
+	var blocks []sackBlock
+	first := -1
+	for el := h.oooQueue; el != nil; el = el.next {
+		left := el.sequence
+		right := left + uint32(len(el.packet.Header().Payload()))
+		if seqLE(left, ackNbr) {
+			// Already acknowledged, or about to be processed
+			continue
+		}
+		if n := len(blocks); n > 0 && seqLE(left, blocks[n-1].right) {
+			if seqLT(blocks[n-1].right, right) {
+				blocks[n-1].right = right
+			}
+		} else {
+			blocks = append(blocks, sackBlock{left: left, right: right})
+		}
+		if left == h.lastOutOfOrder {
+			first = len(blocks) - 1
+		}
+	}
+	if first > 0 {
+		fb := blocks[first]
+		copy(blocks[1:first+1], blocks[:first])
+		blocks[0] = fb
+	}
+	if len(blocks) > maxSackBlocks {
+		blocks = blocks[:maxSackBlocks]
+	}
+	return blocks
+}
+
+// onSelectiveAckReceived marks the packets in the ackWaitQueue that are covered by the blocks of the SACK
+// option in the given header, so that they aren't resent.
+func (h *handler) onSelectiveAckReceived(ctx context.Context, tcpHdr Header) {
+	opts, err := options(tcpHdr)
+	if err != nil {
+		dlog.Errorf(ctx, "   CON %s, %v", h.id, err)
+		return
+	}
+	for _, opt := range opts {
+		if opt.kind() != selectiveAck {
+			continue
+		}
+		blocks := opt.sackBlocks()
+		h.sendLock.Lock()
+		for el := h.ackWaitQueue; el != nil; el = el.next {
+			// The sequence of an element in the ackWaitQueue is the sequence that follows the packet.
+			start := el.sequence - uint32(len(el.packet.Header().Payload()))
+			for _, b := range blocks {
+				if seqLE(b.left, start) && seqLE(el.sequence, b.right) {
+					el.sacked = true
+					break
+				}
+			}
+		}
+		h.sendLock.Unlock()
 	}
 }
 
@@ -895,7 +1017,10 @@ func (h *handler) peerWindowFromHeader(ctx context.Context, tcpHeader Header) {
 	h.sendLock.Lock()
 	sq := h.sequence()
 	oldWindow := int(h.peerWindow) - int(sq-h.seqAcked)
-	h.peerWindow = int64(tcpHeader.WindowSize()) << h.peerWindowScale
+	h.peerWindow = int64(tcpHeader.WindowSize())
+	if h.windowScaling && !tcpHeader.SYN() {
+		h.peerWindow <<= h.peerWindowScale
+	}
 	newWindow := int(h.peerWindow) - int(sq-h.seqAcked)
 	h.sendLock.Unlock()
 	if oldWindow <= 0 && newWindow > 0 {
@@ -905,7 +1030,14 @@ func (h *handler) peerWindowFromHeader(ctx context.Context, tcpHeader Header) {
 }
 
 func (h *handler) myWindowToHeader(tcpHeader Header) {
-	tcpHeader.SetWindowSize(uint16(h.receiveWindow() >> myWindowScale))
+	wz := h.receiveWindow()
+	if h.windowScaling {
+		wz >>= myWindowScale
+	}
+	if wz > math.MaxUint16 {
+		wz = math.MaxUint16
+	}
+	tcpHeader.SetWindowSize(uint16(wz))
 }
 
 func (h *handler) receiveWindow() int {
@@ -0,0 +1,357 @@
+package tcp
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/datawire/dlib/dlog"
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+	"github.com/telepresenceio/telepresence/v2/pkg/tunnel"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/buffer"
+	"github.com/telepresenceio/telepresence/v2/pkg/vif/ip"
+)
+
+var (
+	clientIP   = net.IP{10, 0, 0, 1}
+	serviceIP  = net.IP{10, 1, 0, 1}
+	clientPort = uint16(43210)
+	svcPort    = uint16(8080)
+)
+
+// testStream is a tunnel.Stream that delivers the messages written to fromMgr to the handler, and that
+// makes the messages sent by the handler available in toMgr.
+type testStream struct {
+	id      tunnel.ConnID
+	fromMgr chan tunnel.Message
+	toMgr   chan tunnel.Message
+}
+
+func (s *testStream) Tag() string                     { return "TEST" }
+func (s *testStream) ID() tunnel.ConnID               { return s.id }
+func (s *testStream) PeerVersion() uint16             { return tunnel.Version }
+func (s *testStream) SessionID() string               { return "session" }
+func (s *testStream) DialTimeout() time.Duration      { return time.Second }
+func (s *testStream) RoundtripLatency() time.Duration { return time.Second }
+func (s *testStream) CloseSend(context.Context) error { return nil }
+
+func (s *testStream) Receive(ctx context.Context) (tunnel.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case m := <-s.fromMgr:
+		return m, nil
+	}
+}
+
+func (s *testStream) Send(ctx context.Context, m tunnel.Message) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case s.toMgr <- m:
+		return nil
+	}
+}
+
+// testWriter makes copies of the packets that the handler writes to the TUN device available in a
+// channel. Copies are needed because the handler releases some packets once they are written.
+type testWriter chan Packet
+
+func (w testWriter) Write(_ context.Context, pkt ip.Packet) error {
+	b := pkt.Data().Buf()
+	data := buffer.NewData(len(b))
+	copy(data.Buf(), b)
+	ipHdr, err := ip.ParseHeader(data.Buf())
+	if err != nil {
+		return err
+	}
+	w <- PacketFromData(ipHdr, data)
+	return nil
+}
+
+type testConn struct {
+	*testing.T
+	h      *handler
+	ctx    context.Context
+	stream *testStream
+	toTun  testWriter
+	seq    uint32 // next sequence to send from the client
+	ack    uint32 // next sequence expected from the handler
+}
+
+func newTestConn(t *testing.T) *testConn {
+	ctx, cancel := context.WithCancel(dlog.NewTestContext(t, false))
+	id := tunnel.NewConnID(ipproto.TCP, clientIP, serviceIP, clientPort, svcPort)
+	stream := &testStream{
+		id:      id,
+		fromMgr: make(chan tunnel.Message, 10),
+		toMgr:   make(chan tunnel.Message, 100),
+	}
+	toTun := make(testWriter, 100)
+	closing := int32(0)
+	h := NewHandler(func(context.Context) (tunnel.Stream, error) {
+		return stream, nil
+	}, &closing, toTun, id, func() {}, rand.NewSource(1)).(*handler)
+	h.Start(ctx)
+	t.Cleanup(func() {
+		// Wait for the handler to finish so that it doesn't log after the test has completed
+		cancel()
+		<-h.tunDone
+		h.wg.Wait()
+	})
+	return &testConn{T: t, h: h, ctx: ctx, stream: stream, toTun: toTun, seq: 1000}
+}
+
+// send sends a packet from the client to the handler.
+func (c *testConn) send(seq uint32, syn bool, window uint16, opts []option, payload []byte) {
+	hl := HeaderLen + optionsLen(opts)
+	pkt := NewPacket(hl+len(payload), clientIP, serviceIP, false)
+	ipHdr := pkt.IPHeader()
+	ipHdr.SetL4Protocol(ipproto.TCP)
+	ipHdr.SetChecksum()
+
+	tcpHdr := pkt.Header()
+	tcpHdr.SetDataOffset(hl / 4)
+	tcpHdr.SetSourcePort(clientPort)
+	tcpHdr.SetDestinationPort(svcPort)
+	tcpHdr.SetSequence(seq)
+	tcpHdr.SetSYN(syn)
+	if !syn {
+		tcpHdr.SetACK(true)
+		tcpHdr.SetAckNumber(c.ack)
+	}
+	tcpHdr.SetWindowSize(window)
+	tcpHdr.setOptions(opts)
+	tcpHdr.SetPSH(len(payload) > 0)
+	copy(tcpHdr.Payload(), payload)
+	tcpHdr.SetChecksum(ipHdr)
+	c.h.HandlePacket(c.ctx, pkt)
+}
+
+// sendData sends the given payload using the client's next sequence.
+func (c *testConn) sendData(payload []byte) {
+	c.send(c.seq, false, math.MaxUint16, nil, payload)
+	c.seq += uint32(len(payload))
+}
+
+// next returns the next packet that the handler writes to the TUN device.
+func (c *testConn) next() Packet {
+	select {
+	case pkt := <-c.toTun:
+		return pkt
+	case <-time.After(5 * time.Second):
+		c.Fatal("timeout waiting for packet from handler")
+		return nil
+	}
+}
+
+// handshake performs the three-way handshake and returns the options of the handler's SYN-ACK.
+func (c *testConn) handshake(synOpts []option, window uint16) []option {
+	c.send(c.seq, true, math.MaxUint16, synOpts, nil)
+	c.seq++
+	synAck := c.next().Header()
+	require.True(c, synAck.SYN())
+	require.True(c, synAck.ACK())
+	require.Equal(c, c.seq, synAck.AckNumber())
+	c.ack = synAck.Sequence() + 1
+	opts, err := options(synAck)
+	require.NoError(c, err)
+
+	// The window size of the SYN-ACK is never scaled
+	assert.Equal(c, uint16(math.MaxUint16), synAck.WindowSize())
+
+	c.send(c.seq, false, window, nil, nil)
+	return opts
+}
+
+func (c *testConn) peerWindow() int64 {
+	c.h.sendLock.Lock()
+	defer c.h.sendLock.Unlock()
+	return c.h.peerWindow
+}
+
+// received returns the data that the handler has sent to the manager, waiting until n bytes are available.
+func (c *testConn) received(n int) []byte {
+	var data []byte
+	for len(data) < n {
+		select {
+		case m := <-c.stream.toMgr:
+			if m.Code() == tunnel.Normal {
+				data = append(data, m.Payload()...)
+			}
+		case <-time.After(5 * time.Second):
+			c.Fatalf("timeout waiting for data, got %d of %d bytes", len(data), n)
+		}
+	}
+	return data
+}
+
+func findOption(opts []option, kind optionKind) option {
+	for _, o := range opts {
+		if o.kind() == kind {
+			return o
+		}
+	}
+	return nil
+}
+
+func TestHandler_windowScale(t *testing.T) {
+	t.Run("negotiated", func(t *testing.T) {
+		c := newTestConn(t)
+		opts := c.handshake([]option{maximumSegmentSizeOption(1460), windowScaleOption(7)}, 100)
+
+		ws := findOption(opts, windowScale)
+		require.NotNil(t, ws)
+		assert.Equal(t, []byte{myWindowScale}, ws.data())
+		assert.Nil(t, findOption(opts, selectiveAckPermitted))
+		assert.Eventually(t, func() bool { return c.peerWindow() == 100<<7 }, 5*time.Second, 10*time.Millisecond)
+
+		c.sendData([]byte("hello"))
+		ack := c.next().Header()
+		assert.Equal(t, c.seq, ack.AckNumber())
+		wz := int(ack.WindowSize()) << myWindowScale
+		assert.Greater(t, wz, maxReceiveWindow/2)
+		assert.LessOrEqual(t, wz, maxReceiveWindow)
+		assert.Equal(t, []byte("hello"), c.received(5))
+	})
+
+	t.Run("excessive shift count", func(t *testing.T) {
+		c := newTestConn(t)
+		c.handshake([]option{maximumSegmentSizeOption(1460), windowScaleOption(20)}, 100)
+		assert.Eventually(t, func() bool { return c.peerWindow() == 100<<maxWindowScale }, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("not offered", func(t *testing.T) {
+		c := newTestConn(t)
+		opts := c.handshake([]option{maximumSegmentSizeOption(1460)}, 100)
+
+		assert.Nil(t, findOption(opts, windowScale))
+		assert.Eventually(t, func() bool { return c.peerWindow() == 100 }, 5*time.Second, 10*time.Millisecond)
+
+		c.sendData([]byte("hello"))
+		ack := c.next().Header()
+		assert.Equal(t, c.seq, ack.AckNumber())
+		assert.Equal(t, uint16(math.MaxUint16), ack.WindowSize())
+		assert.Equal(t, []byte("hello"), c.received(5))
+	})
+}
+
+func TestHandler_sendSelectiveAck(t *testing.T) {
+	t.Run("no wrap", func(t *testing.T) {
+		testSendSelectiveAck(t, 1000)
+	})
+	t.Run("wrap", func(t *testing.T) {
+		// The segments cross the point where the sequence numbers wrap around
+		testSendSelectiveAck(t, math.MaxUint32-150)
+	})
+}
+
+func testSendSelectiveAck(t *testing.T, isn uint32) {
+	c := newTestConn(t)
+	c.seq = isn
+	opts := c.handshake([]option{maximumSegmentSizeOption(1460), selectiveAckPermittedOption()}, math.MaxUint16)
+	require.NotNil(t, findOption(opts, selectiveAckPermitted))
+
+	data := make([]byte, 400)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	base := c.seq
+	segment := func(i int) []byte {
+		return data[i*100 : (i+1)*100]
+	}
+	expectAck := func(ack uint32, blocks ...sackBlock) {
+		t.Helper()
+		hdr := c.next().Header()
+		assert.Equal(t, ack, hdr.AckNumber())
+		opts, err := options(hdr)
+		require.NoError(t, err)
+		sack := findOption(opts, selectiveAck)
+		if len(blocks) == 0 {
+			assert.Nil(t, sack)
+		} else if assert.NotNil(t, sack) {
+			assert.Equal(t, blocks, sack.sackBlocks())
+		}
+	}
+
+	// Segments 1 and 3 arrive before segment 0. Each results in a duplicate ACK, and the block that
+	// contains the most recently received segment is reported first.
+	c.send(base+100, false, math.MaxUint16, nil, segment(1))
+	expectAck(base, sackBlock{base + 100, base + 200})
+	c.send(base+300, false, math.MaxUint16, nil, segment(3))
+	expectAck(base, sackBlock{base + 300, base + 400}, sackBlock{base + 100, base + 200})
+
+	// Segment 0 fills the first hole, and the queued segment 1 is processed
+	c.send(base, false, math.MaxUint16, nil, segment(0))
+	expectAck(base+100, sackBlock{base + 300, base + 400})
+	expectAck(base+200, sackBlock{base + 300, base + 400})
+
+	// Segment 2 fills the last hole, and the queued segment 3 is processed
+	c.send(base+200, false, math.MaxUint16, nil, segment(2))
+	expectAck(base + 300)
+	expectAck(base + 400)
+
+	assert.Equal(t, data, c.received(len(data)))
+}
+
+func TestHandler_receiveSelectiveAck(t *testing.T) {
+	c := newTestConn(t)
+	c.handshake([]option{maximumSegmentSizeOption(100), selectiveAckPermittedOption()}, math.MaxUint16)
+
+	// Data from the manager is sent to the client in three segments
+	c.stream.fromMgr <- tunnel.NewMessage(tunnel.Normal, make([]byte, 300))
+	var seqs []uint32
+	for i := 0; i < 3; i++ {
+		pkt := c.next()
+		require.Equal(t, 100, len(pkt.Header().Payload()))
+		seqs = append(seqs, pkt.Header().Sequence())
+	}
+
+	// Ack the first segment, and selectively ack the third
+	c.ack = seqs[1]
+	c.send(c.seq, false, math.MaxUint16, []option{selectiveAckOption([]sackBlock{{seqs[2], seqs[2] + 100}})}, nil)
+
+	assert.Eventually(t, func() bool {
+		c.h.sendLock.Lock()
+		defer c.h.sendLock.Unlock()
+		var sacked []bool
+		for el := c.h.ackWaitQueue; el != nil; el = el.next {
+			sacked = append(sacked, el.sacked)
+		}
+		// The ackWaitQueue is sorted descending on sequence
+		return assert.ObjectsAreEqual([]bool{true, false}, sacked)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestHandler_receiveSelectiveAckWrap(t *testing.T) {
+	h := newTestConn(t).h
+
+	// Three 100 byte segments that cross the point where the sequence numbers wrap around. The sequence
+	// of an element in the ackWaitQueue is the sequence that follows the packet, and the queue is sorted
+	// descending on sequence.
+	start := uint32(math.MaxUint32 - 149)
+	h.sendLock.Lock()
+	for i := 0; i < 3; i++ {
+		pkt := NewPacket(HeaderLen+100, serviceIP, clientIP, true)
+		pkt.Header().SetDataOffset(HeaderLen / 4)
+		h.ackWaitQueue = &queueElement{sequence: start + uint32(i+1)*100, packet: pkt, next: h.ackWaitQueue}
+	}
+	h.sendLock.Unlock()
+
+	// Selectively ack the second and third segment
+	h.onSelectiveAckReceived(context.Background(), headerWithOptions([]option{selectiveAckOption([]sackBlock{{start + 100, start + 300}})}))
+
+	h.sendLock.Lock()
+	var sacked []bool
+	for el := h.ackWaitQueue; el != nil; el = el.next {
+		sacked = append(sacked, el.sacked)
+	}
+	h.sendLock.Unlock()
+	assert.Equal(t, []bool{true, true, false}, sacked)
+}
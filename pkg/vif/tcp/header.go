@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 
 	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
 	"github.com/telepresenceio/telepresence/v2/pkg/vif/ip"
@@ -24,8 +25,15 @@ const (
 	maximumSegmentSize
 	windowScale
 	selectiveAckPermitted
+	selectiveAck
 )
 
+// maxWindowScale is the maximum shift count allowed in a window scale option (RFC 7323, section 2.3)
+const maxWindowScale = 14
+
+// maxSackBlocks is the maximum number of blocks that fit in a SACK option when no other options are present
+const maxSackBlocks = 4
+
 type option []byte
 
 func (o option) kind() optionKind {
@@ -40,6 +48,96 @@ func (o option) data() []byte {
 	return o[2:o.len()]
 }
 
+// valid returns true if the length of the option is valid for its kind.
+func (o option) valid() bool {
+	switch o.kind() {
+	case maximumSegmentSize:
+		return o.len() == 4
+	case windowScale:
+		return o.len() == 3
+	case selectiveAckPermitted:
+		return o.len() == 2
+	case selectiveAck:
+		return o.len() >= 10 && (o.len()-2)%8 == 0
+	default:
+		return true
+	}
+}
+
+// seqLT returns true if the sequence number a precedes b. The comparison uses serial number arithmetic
+// (RFC 1982), so it remains correct when the sequence numbers wrap around.
+func seqLT(a, b uint32) bool {
+	return int32(a-b) < 0
+}
+
+// seqLE returns true if the sequence number a precedes or is equal to b, using serial number arithmetic.
+func seqLE(a, b uint32) bool {
+	return int32(a-b) <= 0
+}
+
+// sackBlock is a block of contiguous data that has been received, as reported by a SACK option. The
+// left edge is the first sequence number of the block and the right edge is the sequence number that
+// immediately follows it.
+type sackBlock struct {
+	left  uint32
+	right uint32
+}
+
+// sackBlocks returns the blocks of a selectiveAck option.
+func (o option) sackBlocks() []sackBlock {
+	d := o.data()
+	blocks := make([]sackBlock, len(d)/8)
+	for i := range blocks {
+		blocks[i].left = binary.BigEndian.Uint32(d[i*8:])
+		blocks[i].right = binary.BigEndian.Uint32(d[i*8+4:])
+	}
+	return blocks
+}
+
+func newOption(kind optionKind, dataLen int) option {
+	o := make(option, 2+dataLen)
+	o[0] = byte(kind)
+	o[1] = byte(2 + dataLen)
+	return o
+}
+
+func maximumSegmentSizeOption(mss uint16) option {
+	o := newOption(maximumSegmentSize, 2)
+	binary.BigEndian.PutUint16(o[2:], mss)
+	return o
+}
+
+func windowScaleOption(shift uint8) option {
+	o := newOption(windowScale, 1)
+	o[2] = shift
+	return o
+}
+
+func selectiveAckPermittedOption() option {
+	return newOption(selectiveAckPermitted, 0)
+}
+
+func selectiveAckOption(blocks []sackBlock) option {
+	if len(blocks) > maxSackBlocks {
+		blocks = blocks[:maxSackBlocks]
+	}
+	o := newOption(selectiveAck, len(blocks)*8)
+	for i, b := range blocks {
+		binary.BigEndian.PutUint32(o[2+i*8:], b.left)
+		binary.BigEndian.PutUint32(o[6+i*8:], b.right)
+	}
+	return o
+}
+
+// optionsLen returns the number of bytes needed to store the given options, padded to a 32-bit boundary.
+func optionsLen(opts []option) int {
+	l := 0
+	for _, o := range opts {
+		l += len(o)
+	}
+	return (l + 3) &^ 3
+}
+
 func options(h Header) ([]option, error) {
 	var opts []option
 	ob := h.OptionBytes()
@@ -57,8 +155,12 @@ func options(h Header) ([]option, error) {
 		default:
 			if i+1 < obl {
 				ol := int(ob[i+1])
-				if i+ol <= obl {
-					opts = append(opts, option(ob[i:i+ol]))
+				if ol >= 2 && i+ol <= obl {
+					o := option(ob[i : i+ol])
+					if !o.valid() {
+						return nil, fmt.Errorf("invalid length %d of option %d", ol, o.kind())
+					}
+					opts = append(opts, o)
 					i += ol
 					continue
 				}
@@ -245,6 +347,19 @@ func (h Header) OptionBytes() []byte {
 	return h[20 : h.DataOffset()*4]
 }
 
+// setOptions writes the given options to the header and pads them with noOp options so that they end on a
+// 32-bit boundary. The header's data offset must already account for the length of the options.
+func (h Header) setOptions(opts []option) {
+	ob := h.OptionBytes()
+	i := 0
+	for _, o := range opts {
+		i += copy(ob[i:], o)
+	}
+	for ; i < len(ob); i++ {
+		ob[i] = byte(noOp)
+	}
+}
+
 func (h Header) Payload() []byte {
 	return h[h.DataOffset()*4:]
 }
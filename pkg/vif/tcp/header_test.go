@@ -0,0 +1,138 @@
+package tcp
+
+import (
+	"encoding/binary"
+	"math"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/telepresenceio/telepresence/v2/pkg/ipproto"
+)
+
+func headerWithOptions(opts []option) Header {
+	hl := HeaderLen + optionsLen(opts)
+	h := make(Header, hl)
+	h.SetDataOffset(hl / 4)
+	h.setOptions(opts)
+	return h
+}
+
+func TestOptions(t *testing.T) {
+	blocks := []sackBlock{{left: 3000, right: 4000}, {left: 1000, right: 2000}}
+	h := headerWithOptions([]option{
+		maximumSegmentSizeOption(1460),
+		windowScaleOption(7),
+		selectiveAckPermittedOption(),
+		selectiveAckOption(blocks),
+	})
+	assert.Equal(t, 0, len(h.OptionBytes())%4)
+
+	opts, err := options(h)
+	require.NoError(t, err)
+	require.Len(t, opts, 4)
+	assert.Equal(t, maximumSegmentSize, opts[0].kind())
+	assert.Equal(t, []byte{0x05, 0xb4}, opts[0].data())
+	assert.Equal(t, windowScale, opts[1].kind())
+	assert.Equal(t, []byte{7}, opts[1].data())
+	assert.Equal(t, selectiveAckPermitted, opts[2].kind())
+	assert.Empty(t, opts[2].data())
+	assert.Equal(t, selectiveAck, opts[3].kind())
+	assert.Equal(t, blocks, opts[3].sackBlocks())
+}
+
+func TestOptions_maxSackBlocks(t *testing.T) {
+	blocks := make([]sackBlock, maxSackBlocks+2)
+	for i := range blocks {
+		blocks[i] = sackBlock{left: uint32(i * 200), right: uint32(i*200 + 100)}
+	}
+	h := headerWithOptions([]option{selectiveAckOption(blocks)})
+	assert.LessOrEqual(t, len(h), HeaderMaxLen)
+
+	opts, err := options(h)
+	require.NoError(t, err)
+	require.Len(t, opts, 1)
+	assert.Equal(t, blocks[:maxSackBlocks], opts[0].sackBlocks())
+}
+
+func TestOptions_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  option
+	}{
+		{"zero length", option{byte(maximumSegmentSize), 0}},
+		{"short MSS", option{byte(maximumSegmentSize), 3, 1}},
+		{"long window scale", option{byte(windowScale), 4, 1, 1}},
+		{"odd SACK", option{byte(selectiveAck), 6, 0, 0, 0, 1}},
+		{"beyond header", option{byte(windowScale), 8, 1}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := options(headerWithOptions([]option{tt.opt}))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func synPacket(src, dst net.IP, syn bool, mss uint16) Packet {
+	opts := []option{maximumSegmentSizeOption(mss), windowScaleOption(7)}
+	hl := HeaderLen + optionsLen(opts)
+	pkt := NewPacket(hl, src, dst, false)
+	ipHdr := pkt.IPHeader()
+	ipHdr.SetL4Protocol(ipproto.TCP)
+	ipHdr.SetChecksum()
+	tcpHdr := pkt.Header()
+	tcpHdr.SetDataOffset(hl / 4)
+	tcpHdr.SetSYN(syn)
+	tcpHdr.setOptions(opts)
+	tcpHdr.SetChecksum(ipHdr)
+	return pkt
+}
+
+func TestClampMSS(t *testing.T) {
+	v4, v6 := net.IP{10, 0, 0, 1}, net.ParseIP("fd00::1")
+	tests := []struct {
+		name    string
+		pkt     Packet
+		clamped bool
+		mss     uint16
+	}{
+		{"IPv4", synPacket(v4, v4, true, 1460), true, 1400 - 20 - HeaderLen},
+		{"IPv6", synPacket(v6, v6, true, 1440), true, 1400 - 40 - HeaderLen},
+		{"small MSS", synPacket(v4, v4, true, 1200), false, 1200},
+		{"not SYN", synPacket(v4, v4, false, 1460), false, 1460},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.pkt.Release()
+			assert.Equal(t, tt.clamped, ClampMSS(tt.pkt, 1400))
+			tcpHdr := tt.pkt.Header()
+			opts, err := options(tcpHdr)
+			require.NoError(t, err)
+			require.Len(t, opts, 2)
+			assert.Equal(t, tt.mss, binary.BigEndian.Uint16(opts[0].data()))
+			assert.Equal(t, windowScale, opts[1].kind())
+
+			// The checksum must be valid for the modified header
+			cs := tcpHdr.Checksum()
+			tcpHdr.SetChecksum(tt.pkt.IPHeader())
+			assert.Equal(t, cs, tcpHdr.Checksum())
+		})
+	}
+}
+
+func TestSeqCompare(t *testing.T) {
+	assert.True(t, seqLT(1, 2))
+	assert.False(t, seqLT(2, 2))
+	assert.True(t, seqLE(2, 2))
+	assert.False(t, seqLE(3, 2))
+
+	// The comparisons remain correct across the wrap
+	assert.True(t, seqLT(math.MaxUint32, 0))
+	assert.True(t, seqLT(math.MaxUint32-10, 10))
+	assert.False(t, seqLE(10, math.MaxUint32-10))
+}
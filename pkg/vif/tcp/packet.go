@@ -2,6 +2,7 @@ package tcp
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"net"
 
@@ -100,3 +101,34 @@ func (p *packet) Reset() Packet {
 	tcpHdr.SetChecksum(iph)
 	return pkt
 }
+
+// ClampMSS lowers the maximum segment size option of a SYN packet so that the segments that the receiver of
+// the packet sends will fit in packets of the given MTU. It returns true if the option was changed.
+func ClampMSS(pkt Packet, mtu int) bool {
+	tcpHdr := pkt.Header()
+	if !tcpHdr.SYN() {
+		return false
+	}
+	ipHdr := pkt.IPHeader()
+	mss := mtu - ipHdr.HeaderLen() - HeaderLen
+	if mss <= 0 {
+		return false
+	}
+	opts, err := options(tcpHdr)
+	if err != nil {
+		return false
+	}
+	for _, o := range opts {
+		if o.kind() != maximumSegmentSize {
+			continue
+		}
+		// The option is a slice of the header, so it's updated in place.
+		if d := o.data(); int(binary.BigEndian.Uint16(d)) > mss {
+			binary.BigEndian.PutUint16(d, uint16(mss))
+			tcpHdr.SetChecksum(ipHdr)
+			return true
+		}
+		break
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+// Package watchstate contains functions that maintain the state that a client of the traffic-manager
+// builds from the complete and delta snapshots of the manager's watch streams.
+package watchstate
+
+import (
+	"sort"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+)
+
+// ApplyIntercepts applies the given snapshot to the given intercepts, keyed by ID, and returns
+// the result. A snapshot that isn't a delta replaces all intercepts.
+func ApplyIntercepts(intercepts map[string]*manager.InterceptInfo, snapshot *manager.InterceptInfoSnapshot) map[string]*manager.InterceptInfo {
+	if !snapshot.Delta || intercepts == nil {
+		intercepts = make(map[string]*manager.InterceptInfo, len(snapshot.Intercepts))
+	}
+	for _, ii := range snapshot.Intercepts {
+		intercepts[ii.Id] = ii
+	}
+	for _, id := range snapshot.RemovedIds {
+		delete(intercepts, id)
+	}
+	return intercepts
+}
+
+// SortedIntercepts returns the intercepts of the given map, sorted by ID.
+func SortedIntercepts(im map[string]*manager.InterceptInfo) []*manager.InterceptInfo {
+	intercepts := make([]*manager.InterceptInfo, 0, len(im))
+	for _, ii := range im {
+		intercepts = append(intercepts, ii)
+	}
+	sort.Slice(intercepts, func(i, j int) bool {
+		return intercepts[i].Id < intercepts[j].Id
+	})
+	return intercepts
+}
+
+// ApplyAgents applies the given snapshot to the given agents, keyed by session ID, and returns
+// the result. A snapshot that isn't a delta replaces all agents.
+func ApplyAgents(agents map[string]*manager.AgentInfo, snapshot *manager.AgentInfoSnapshot) map[string]*manager.AgentInfo {
+	if !snapshot.Delta || agents == nil {
+		agents = make(map[string]*manager.AgentInfo, len(snapshot.SessionAgents))
+	}
+	for id, ai := range snapshot.SessionAgents {
+		agents[id] = ai
+	}
+	for _, id := range snapshot.RemovedSessionIds {
+		delete(agents, id)
+	}
+	return agents
+}
+
+// SortedAgents returns the agents of the given map, sorted by session ID.
+func SortedAgents(am map[string]*manager.AgentInfo) []*manager.AgentInfo {
+	ids := make([]string, 0, len(am))
+	for id := range am {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	agents := make([]*manager.AgentInfo, len(ids))
+	for i, id := range ids {
+		agents[i] = am[id]
+	}
+	return agents
+}
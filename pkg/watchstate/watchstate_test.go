@@ -0,0 +1,67 @@
+package watchstate_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/telepresenceio/telepresence/rpc/v2/manager"
+	"github.com/telepresenceio/telepresence/v2/pkg/watchstate"
+)
+
+func TestApplyIntercepts(t *testing.T) {
+	a := &manager.InterceptInfo{Id: "s1:a"}
+	b := &manager.InterceptInfo{Id: "s1:b"}
+	b2 := &manager.InterceptInfo{Id: "s1:b", Message: "updated"}
+	c := &manager.InterceptInfo{Id: "s2:c"}
+
+	intercepts := watchstate.ApplyIntercepts(nil, &manager.InterceptInfoSnapshot{
+		Intercepts: []*manager.InterceptInfo{b, a},
+		Revision:   3,
+	})
+	assert.Equal(t, []*manager.InterceptInfo{a, b}, watchstate.SortedIntercepts(intercepts))
+
+	// A delta adds, updates, and removes intercepts
+	intercepts = watchstate.ApplyIntercepts(intercepts, &manager.InterceptInfoSnapshot{
+		Intercepts: []*manager.InterceptInfo{c, b2},
+		RemovedIds: []string{"s1:a"},
+		Delta:      true,
+		Revision:   5,
+	})
+	assert.Equal(t, []*manager.InterceptInfo{b2, c}, watchstate.SortedIntercepts(intercepts))
+
+	// A complete snapshot replaces all intercepts
+	intercepts = watchstate.ApplyIntercepts(intercepts, &manager.InterceptInfoSnapshot{
+		Intercepts: []*manager.InterceptInfo{a},
+		Revision:   7,
+	})
+	assert.Equal(t, []*manager.InterceptInfo{a}, watchstate.SortedIntercepts(intercepts))
+}
+
+func TestApplyAgents(t *testing.T) {
+	a := &manager.AgentInfo{Name: "a", Namespace: "default"}
+	b := &manager.AgentInfo{Name: "b", Namespace: "default"}
+	c := &manager.AgentInfo{Name: "c", Namespace: "default"}
+
+	agents := watchstate.ApplyAgents(nil, &manager.AgentInfoSnapshot{
+		SessionAgents: map[string]*manager.AgentInfo{"s1": a, "s2": b},
+		Revision:      3,
+	})
+	assert.Equal(t, []*manager.AgentInfo{a, b}, watchstate.SortedAgents(agents))
+
+	// A delta adds and removes agents
+	agents = watchstate.ApplyAgents(agents, &manager.AgentInfoSnapshot{
+		SessionAgents:     map[string]*manager.AgentInfo{"s3": c},
+		RemovedSessionIds: []string{"s1"},
+		Delta:             true,
+		Revision:          5,
+	})
+	assert.Equal(t, []*manager.AgentInfo{b, c}, watchstate.SortedAgents(agents))
+
+	// A complete snapshot replaces all agents
+	agents = watchstate.ApplyAgents(agents, &manager.AgentInfoSnapshot{
+		SessionAgents: map[string]*manager.AgentInfo{"s1": a},
+		Revision:      7,
+	})
+	assert.Equal(t, []*manager.AgentInfo{a}, watchstate.SortedAgents(agents))
+}
@@ -41,6 +41,9 @@ type DaemonClient interface {
 	SetDnsSearchPath(ctx context.Context, in *Paths, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// SetLogLevel will temporarily set the log-level for the daemon for a duration that is determined b the request.
 	SetLogLevel(ctx context.Context, in *manager.LogLevelRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// Capture streams the packets that are read from, and written to, the TUN device in
+	// pcap format until the duration or the size limit of the request is reached.
+	Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (Daemon_CaptureClient, error)
 }
 
 type daemonClient struct {
@@ -123,6 +126,38 @@ func (c *daemonClient) SetLogLevel(ctx context.Context, in *manager.LogLevelRequ
 	return out, nil
 }
 
+func (c *daemonClient) Capture(ctx context.Context, in *CaptureRequest, opts ...grpc.CallOption) (Daemon_CaptureClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Daemon_ServiceDesc.Streams[0], "/telepresence.daemon.Daemon/Capture", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &daemonCaptureClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Daemon_CaptureClient interface {
+	Recv() (*CaptureData, error)
+	grpc.ClientStream
+}
+
+type daemonCaptureClient struct {
+	grpc.ClientStream
+}
+
+func (x *daemonCaptureClient) Recv() (*CaptureData, error) {
+	m := new(CaptureData)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // DaemonServer is the server API for Daemon service.
 // All implementations must embed UnimplementedDaemonServer
 // for forward compatibility
@@ -143,6 +178,9 @@ type DaemonServer interface {
 	SetDnsSearchPath(context.Context, *Paths) (*emptypb.Empty, error)
 	// SetLogLevel will temporarily set the log-level for the daemon for a duration that is determined b the request.
 	SetLogLevel(context.Context, *manager.LogLevelRequest) (*emptypb.Empty, error)
+	// Capture streams the packets that are read from, and written to, the TUN device in
+	// pcap format until the duration or the size limit of the request is reached.
+	Capture(*CaptureRequest, Daemon_CaptureServer) error
 	mustEmbedUnimplementedDaemonServer()
 }
 
@@ -174,6 +212,9 @@ func (UnimplementedDaemonServer) SetDnsSearchPath(context.Context, *Paths) (*emp
 func (UnimplementedDaemonServer) SetLogLevel(context.Context, *manager.LogLevelRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetLogLevel not implemented")
 }
+func (UnimplementedDaemonServer) Capture(*CaptureRequest, Daemon_CaptureServer) error {
+	return status.Errorf(codes.Unimplemented, "method Capture not implemented")
+}
 func (UnimplementedDaemonServer) mustEmbedUnimplementedDaemonServer() {}
 
 // UnsafeDaemonServer may be embedded to opt out of forward compatibility for this service.
@@ -331,6 +372,27 @@ func _Daemon_SetLogLevel_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Daemon_Capture_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CaptureRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DaemonServer).Capture(m, &daemonCaptureServer{stream})
+}
+
+type Daemon_CaptureServer interface {
+	Send(*CaptureData) error
+	grpc.ServerStream
+}
+
+type daemonCaptureServer struct {
+	grpc.ServerStream
+}
+
+func (x *daemonCaptureServer) Send(m *CaptureData) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Daemon_ServiceDesc is the grpc.ServiceDesc for Daemon service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -371,6 +433,12 @@ var Daemon_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _Daemon_SetLogLevel_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Capture",
+			Handler:       _Daemon_Capture_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "rpc/daemon/daemon.proto",
 }
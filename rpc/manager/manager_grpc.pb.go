@@ -69,6 +69,20 @@ type ManagerClient interface {
 	// WatchClusterInfo returns information needed when establishing
 	// connectivity to the cluster.
 	WatchClusterInfo(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchClusterInfoClient, error)
+	// WatchAgentsSince is like WatchAgentsNS, but only the initial snapshot
+	// can be complete. All other snapshots are deltas. The initial snapshot
+	// is also a delta when the traffic-manager remembers the requested
+	// since_revision.
+	WatchAgentsSince(ctx context.Context, in *WatchSinceRequest, opts ...grpc.CallOption) (Manager_WatchAgentsSinceClient, error)
+	// WatchInterceptsSince is like WatchIntercepts, but only the initial
+	// snapshot can be complete. All other snapshots are deltas. The initial
+	// snapshot is also a delta when the traffic-manager remembers the
+	// requested since_revision.
+	WatchInterceptsSince(ctx context.Context, in *WatchSinceRequest, opts ...grpc.CallOption) (Manager_WatchInterceptsSinceClient, error)
+	// WatchClusterInfoSince is like WatchClusterInfo, but the initial
+	// cluster info is not sent when its revision is equal to the requested
+	// since_revision.
+	WatchClusterInfoSince(ctx context.Context, in *WatchSinceRequest, opts ...grpc.CallOption) (Manager_WatchClusterInfoSinceClient, error)
 	// Request that the traffic-manager makes the preparations necessary to
 	// create the given intercept.
 	PrepareIntercept(ctx context.Context, in *CreateInterceptRequest, opts ...grpc.CallOption) (*PreparedIntercept, error)
@@ -83,10 +97,25 @@ type ManagerClient interface {
 	UpdateIntercept(ctx context.Context, in *UpdateInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error)
 	// GetIntercept gets info from intercept name
 	GetIntercept(ctx context.Context, in *GetInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error)
+	// AttachIntercept attaches the calling client to an active intercept
+	// that is owned by another client. The attached client will see the
+	// intercept in WatchIntercepts and can use its environment and mounts.
+	AttachIntercept(ctx context.Context, in *AttachInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error)
+	// DetachIntercept detaches the calling client from an intercept that
+	// it has attached to.
+	DetachIntercept(ctx context.Context, in *DetachInterceptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// HandoverIntercept routes the traffic of an intercept to one of its
+	// attached clients, or back to the owner. Only the owner of the
+	// intercept can call this.
+	HandoverIntercept(ctx context.Context, in *HandoverInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error)
 	// ReviewIntercept lets an agent approve or reject an intercept by
 	// changing the disposition from "WATING" to "ACTIVE" or to an
 	// error, and setting a human-readable status message.
 	ReviewIntercept(ctx context.Context, in *ReviewInterceptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// DryRunInject generates the agent config for a workload and computes
+	// the patch that the agent injector would apply to its pods, without
+	// storing the config or changing anything in the cluster.
+	DryRunInject(ctx context.Context, in *DryRunInjectRequest, opts ...grpc.CallOption) (*DryRunInjectResponse, error)
 	// ClientTunnel receives messages from the client and dispatches them to tracked
 	// net.Conn instances in the traffic-manager. Responses from tracked instances
 	// are sent back on the returned message stream
@@ -120,6 +149,15 @@ type ManagerClient interface {
 	// connection and responds with a Tunnel. The manager then connects the
 	// two tunnels.
 	WatchDial(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchDialClient, error)
+	// ListClientSessions returns all client sessions together with the
+	// intercepts that they own.
+	ListClientSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ClientSessionSnapshot, error)
+	// AdminRemoveIntercept removes an intercept regardless of what client
+	// session that owns it.
+	AdminRemoveIntercept(ctx context.Context, in *AdminRemoveInterceptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// EvictSession terminates a client session and removes all intercepts
+	// that it owns.
+	EvictSession(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type managerClient struct {
@@ -357,6 +395,102 @@ func (x *managerWatchClusterInfoClient) Recv() (*ClusterInfo, error) {
 	return m, nil
 }
 
+func (c *managerClient) WatchAgentsSince(ctx context.Context, in *WatchSinceRequest, opts ...grpc.CallOption) (Manager_WatchAgentsSinceClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[4], "/telepresence.manager.Manager/WatchAgentsSince", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerWatchAgentsSinceClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Manager_WatchAgentsSinceClient interface {
+	Recv() (*AgentInfoSnapshot, error)
+	grpc.ClientStream
+}
+
+type managerWatchAgentsSinceClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerWatchAgentsSinceClient) Recv() (*AgentInfoSnapshot, error) {
+	m := new(AgentInfoSnapshot)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *managerClient) WatchInterceptsSince(ctx context.Context, in *WatchSinceRequest, opts ...grpc.CallOption) (Manager_WatchInterceptsSinceClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[5], "/telepresence.manager.Manager/WatchInterceptsSince", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerWatchInterceptsSinceClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Manager_WatchInterceptsSinceClient interface {
+	Recv() (*InterceptInfoSnapshot, error)
+	grpc.ClientStream
+}
+
+type managerWatchInterceptsSinceClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerWatchInterceptsSinceClient) Recv() (*InterceptInfoSnapshot, error) {
+	m := new(InterceptInfoSnapshot)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *managerClient) WatchClusterInfoSince(ctx context.Context, in *WatchSinceRequest, opts ...grpc.CallOption) (Manager_WatchClusterInfoSinceClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[6], "/telepresence.manager.Manager/WatchClusterInfoSince", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &managerWatchClusterInfoSinceClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Manager_WatchClusterInfoSinceClient interface {
+	Recv() (*ClusterInfo, error)
+	grpc.ClientStream
+}
+
+type managerWatchClusterInfoSinceClient struct {
+	grpc.ClientStream
+}
+
+func (x *managerWatchClusterInfoSinceClient) Recv() (*ClusterInfo, error) {
+	m := new(ClusterInfo)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *managerClient) PrepareIntercept(ctx context.Context, in *CreateInterceptRequest, opts ...grpc.CallOption) (*PreparedIntercept, error) {
 	out := new(PreparedIntercept)
 	err := c.cc.Invoke(ctx, "/telepresence.manager.Manager/PrepareIntercept", in, out, opts...)
@@ -402,6 +536,33 @@ func (c *managerClient) GetIntercept(ctx context.Context, in *GetInterceptReques
 	return out, nil
 }
 
+func (c *managerClient) AttachIntercept(ctx context.Context, in *AttachInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error) {
+	out := new(InterceptInfo)
+	err := c.cc.Invoke(ctx, "/telepresence.manager.Manager/AttachIntercept", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) DetachIntercept(ctx context.Context, in *DetachInterceptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/telepresence.manager.Manager/DetachIntercept", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) HandoverIntercept(ctx context.Context, in *HandoverInterceptRequest, opts ...grpc.CallOption) (*InterceptInfo, error) {
+	out := new(InterceptInfo)
+	err := c.cc.Invoke(ctx, "/telepresence.manager.Manager/HandoverIntercept", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) ReviewIntercept(ctx context.Context, in *ReviewInterceptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, "/telepresence.manager.Manager/ReviewIntercept", in, out, opts...)
@@ -411,8 +572,17 @@ func (c *managerClient) ReviewIntercept(ctx context.Context, in *ReviewIntercept
 	return out, nil
 }
 
+func (c *managerClient) DryRunInject(ctx context.Context, in *DryRunInjectRequest, opts ...grpc.CallOption) (*DryRunInjectResponse, error) {
+	out := new(DryRunInjectResponse)
+	err := c.cc.Invoke(ctx, "/telepresence.manager.Manager/DryRunInject", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *managerClient) ClientTunnel(ctx context.Context, opts ...grpc.CallOption) (Manager_ClientTunnelClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[4], "/telepresence.manager.Manager/ClientTunnel", opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[7], "/telepresence.manager.Manager/ClientTunnel", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -443,7 +613,7 @@ func (x *managerClientTunnelClient) Recv() (*ConnMessage, error) {
 }
 
 func (c *managerClient) AgentTunnel(ctx context.Context, opts ...grpc.CallOption) (Manager_AgentTunnelClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[5], "/telepresence.manager.Manager/AgentTunnel", opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[8], "/telepresence.manager.Manager/AgentTunnel", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -492,7 +662,7 @@ func (c *managerClient) AgentLookupHostResponse(ctx context.Context, in *LookupH
 }
 
 func (c *managerClient) WatchLookupHost(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchLookupHostClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[6], "/telepresence.manager.Manager/WatchLookupHost", opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[9], "/telepresence.manager.Manager/WatchLookupHost", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -524,7 +694,7 @@ func (x *managerWatchLookupHostClient) Recv() (*LookupHostRequest, error) {
 }
 
 func (c *managerClient) WatchLogLevel(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Manager_WatchLogLevelClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[7], "/telepresence.manager.Manager/WatchLogLevel", opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[10], "/telepresence.manager.Manager/WatchLogLevel", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -556,7 +726,7 @@ func (x *managerWatchLogLevelClient) Recv() (*LogLevelRequest, error) {
 }
 
 func (c *managerClient) Tunnel(ctx context.Context, opts ...grpc.CallOption) (Manager_TunnelClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[8], "/telepresence.manager.Manager/Tunnel", opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[11], "/telepresence.manager.Manager/Tunnel", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -587,7 +757,7 @@ func (x *managerTunnelClient) Recv() (*TunnelMessage, error) {
 }
 
 func (c *managerClient) WatchDial(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (Manager_WatchDialClient, error) {
-	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[9], "/telepresence.manager.Manager/WatchDial", opts...)
+	stream, err := c.cc.NewStream(ctx, &Manager_ServiceDesc.Streams[12], "/telepresence.manager.Manager/WatchDial", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -618,6 +788,33 @@ func (x *managerWatchDialClient) Recv() (*DialRequest, error) {
 	return m, nil
 }
 
+func (c *managerClient) ListClientSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ClientSessionSnapshot, error) {
+	out := new(ClientSessionSnapshot)
+	err := c.cc.Invoke(ctx, "/telepresence.manager.Manager/ListClientSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) AdminRemoveIntercept(ctx context.Context, in *AdminRemoveInterceptRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/telepresence.manager.Manager/AdminRemoveIntercept", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *managerClient) EvictSession(ctx context.Context, in *SessionInfo, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/telepresence.manager.Manager/EvictSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ManagerServer is the server API for Manager service.
 // All implementations must embed UnimplementedManagerServer
 // for forward compatibility
@@ -668,6 +865,20 @@ type ManagerServer interface {
 	// WatchClusterInfo returns information needed when establishing
 	// connectivity to the cluster.
 	WatchClusterInfo(*SessionInfo, Manager_WatchClusterInfoServer) error
+	// WatchAgentsSince is like WatchAgentsNS, but only the initial snapshot
+	// can be complete. All other snapshots are deltas. The initial snapshot
+	// is also a delta when the traffic-manager remembers the requested
+	// since_revision.
+	WatchAgentsSince(*WatchSinceRequest, Manager_WatchAgentsSinceServer) error
+	// WatchInterceptsSince is like WatchIntercepts, but only the initial
+	// snapshot can be complete. All other snapshots are deltas. The initial
+	// snapshot is also a delta when the traffic-manager remembers the
+	// requested since_revision.
+	WatchInterceptsSince(*WatchSinceRequest, Manager_WatchInterceptsSinceServer) error
+	// WatchClusterInfoSince is like WatchClusterInfo, but the initial
+	// cluster info is not sent when its revision is equal to the requested
+	// since_revision.
+	WatchClusterInfoSince(*WatchSinceRequest, Manager_WatchClusterInfoSinceServer) error
 	// Request that the traffic-manager makes the preparations necessary to
 	// create the given intercept.
 	PrepareIntercept(context.Context, *CreateInterceptRequest) (*PreparedIntercept, error)
@@ -682,10 +893,25 @@ type ManagerServer interface {
 	UpdateIntercept(context.Context, *UpdateInterceptRequest) (*InterceptInfo, error)
 	// GetIntercept gets info from intercept name
 	GetIntercept(context.Context, *GetInterceptRequest) (*InterceptInfo, error)
+	// AttachIntercept attaches the calling client to an active intercept
+	// that is owned by another client. The attached client will see the
+	// intercept in WatchIntercepts and can use its environment and mounts.
+	AttachIntercept(context.Context, *AttachInterceptRequest) (*InterceptInfo, error)
+	// DetachIntercept detaches the calling client from an intercept that
+	// it has attached to.
+	DetachIntercept(context.Context, *DetachInterceptRequest) (*emptypb.Empty, error)
+	// HandoverIntercept routes the traffic of an intercept to one of its
+	// attached clients, or back to the owner. Only the owner of the
+	// intercept can call this.
+	HandoverIntercept(context.Context, *HandoverInterceptRequest) (*InterceptInfo, error)
 	// ReviewIntercept lets an agent approve or reject an intercept by
 	// changing the disposition from "WATING" to "ACTIVE" or to an
 	// error, and setting a human-readable status message.
 	ReviewIntercept(context.Context, *ReviewInterceptRequest) (*emptypb.Empty, error)
+	// DryRunInject generates the agent config for a workload and computes
+	// the patch that the agent injector would apply to its pods, without
+	// storing the config or changing anything in the cluster.
+	DryRunInject(context.Context, *DryRunInjectRequest) (*DryRunInjectResponse, error)
 	// ClientTunnel receives messages from the client and dispatches them to tracked
 	// net.Conn instances in the traffic-manager. Responses from tracked instances
 	// are sent back on the returned message stream
@@ -719,6 +945,15 @@ type ManagerServer interface {
 	// connection and responds with a Tunnel. The manager then connects the
 	// two tunnels.
 	WatchDial(*SessionInfo, Manager_WatchDialServer) error
+	// ListClientSessions returns all client sessions together with the
+	// intercepts that they own.
+	ListClientSessions(context.Context, *emptypb.Empty) (*ClientSessionSnapshot, error)
+	// AdminRemoveIntercept removes an intercept regardless of what client
+	// session that owns it.
+	AdminRemoveIntercept(context.Context, *AdminRemoveInterceptRequest) (*emptypb.Empty, error)
+	// EvictSession terminates a client session and removes all intercepts
+	// that it owns.
+	EvictSession(context.Context, *SessionInfo) (*emptypb.Empty, error)
 	mustEmbedUnimplementedManagerServer()
 }
 
@@ -771,6 +1006,15 @@ func (UnimplementedManagerServer) WatchIntercepts(*SessionInfo, Manager_WatchInt
 func (UnimplementedManagerServer) WatchClusterInfo(*SessionInfo, Manager_WatchClusterInfoServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchClusterInfo not implemented")
 }
+func (UnimplementedManagerServer) WatchAgentsSince(*WatchSinceRequest, Manager_WatchAgentsSinceServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAgentsSince not implemented")
+}
+func (UnimplementedManagerServer) WatchInterceptsSince(*WatchSinceRequest, Manager_WatchInterceptsSinceServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchInterceptsSince not implemented")
+}
+func (UnimplementedManagerServer) WatchClusterInfoSince(*WatchSinceRequest, Manager_WatchClusterInfoSinceServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchClusterInfoSince not implemented")
+}
 func (UnimplementedManagerServer) PrepareIntercept(context.Context, *CreateInterceptRequest) (*PreparedIntercept, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PrepareIntercept not implemented")
 }
@@ -786,9 +1030,21 @@ func (UnimplementedManagerServer) UpdateIntercept(context.Context, *UpdateInterc
 func (UnimplementedManagerServer) GetIntercept(context.Context, *GetInterceptRequest) (*InterceptInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetIntercept not implemented")
 }
+func (UnimplementedManagerServer) AttachIntercept(context.Context, *AttachInterceptRequest) (*InterceptInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AttachIntercept not implemented")
+}
+func (UnimplementedManagerServer) DetachIntercept(context.Context, *DetachInterceptRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DetachIntercept not implemented")
+}
+func (UnimplementedManagerServer) HandoverIntercept(context.Context, *HandoverInterceptRequest) (*InterceptInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HandoverIntercept not implemented")
+}
 func (UnimplementedManagerServer) ReviewIntercept(context.Context, *ReviewInterceptRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ReviewIntercept not implemented")
 }
+func (UnimplementedManagerServer) DryRunInject(context.Context, *DryRunInjectRequest) (*DryRunInjectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DryRunInject not implemented")
+}
 func (UnimplementedManagerServer) ClientTunnel(Manager_ClientTunnelServer) error {
 	return status.Errorf(codes.Unimplemented, "method ClientTunnel not implemented")
 }
@@ -813,6 +1069,15 @@ func (UnimplementedManagerServer) Tunnel(Manager_TunnelServer) error {
 func (UnimplementedManagerServer) WatchDial(*SessionInfo, Manager_WatchDialServer) error {
 	return status.Errorf(codes.Unimplemented, "method WatchDial not implemented")
 }
+func (UnimplementedManagerServer) ListClientSessions(context.Context, *emptypb.Empty) (*ClientSessionSnapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListClientSessions not implemented")
+}
+func (UnimplementedManagerServer) AdminRemoveIntercept(context.Context, *AdminRemoveInterceptRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminRemoveIntercept not implemented")
+}
+func (UnimplementedManagerServer) EvictSession(context.Context, *SessionInfo) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EvictSession not implemented")
+}
 func (UnimplementedManagerServer) mustEmbedUnimplementedManagerServer() {}
 
 // UnsafeManagerServer may be embedded to opt out of forward compatibility for this service.
@@ -1108,6 +1373,69 @@ func (x *managerWatchClusterInfoServer) Send(m *ClusterInfo) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Manager_WatchAgentsSince_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchSinceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManagerServer).WatchAgentsSince(m, &managerWatchAgentsSinceServer{stream})
+}
+
+type Manager_WatchAgentsSinceServer interface {
+	Send(*AgentInfoSnapshot) error
+	grpc.ServerStream
+}
+
+type managerWatchAgentsSinceServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerWatchAgentsSinceServer) Send(m *AgentInfoSnapshot) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Manager_WatchInterceptsSince_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchSinceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManagerServer).WatchInterceptsSince(m, &managerWatchInterceptsSinceServer{stream})
+}
+
+type Manager_WatchInterceptsSinceServer interface {
+	Send(*InterceptInfoSnapshot) error
+	grpc.ServerStream
+}
+
+type managerWatchInterceptsSinceServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerWatchInterceptsSinceServer) Send(m *InterceptInfoSnapshot) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Manager_WatchClusterInfoSince_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchSinceRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ManagerServer).WatchClusterInfoSince(m, &managerWatchClusterInfoSinceServer{stream})
+}
+
+type Manager_WatchClusterInfoSinceServer interface {
+	Send(*ClusterInfo) error
+	grpc.ServerStream
+}
+
+type managerWatchClusterInfoSinceServer struct {
+	grpc.ServerStream
+}
+
+func (x *managerWatchClusterInfoSinceServer) Send(m *ClusterInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Manager_PrepareIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateInterceptRequest)
 	if err := dec(in); err != nil {
@@ -1198,6 +1526,60 @@ func _Manager_GetIntercept_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_AttachIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AttachInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).AttachIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/telepresence.manager.Manager/AttachIntercept",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).AttachIntercept(ctx, req.(*AttachInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_DetachIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DetachInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).DetachIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/telepresence.manager.Manager/DetachIntercept",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).DetachIntercept(ctx, req.(*DetachInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_HandoverIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HandoverInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).HandoverIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/telepresence.manager.Manager/HandoverIntercept",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).HandoverIntercept(ctx, req.(*HandoverInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_ReviewIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ReviewInterceptRequest)
 	if err := dec(in); err != nil {
@@ -1216,6 +1598,24 @@ func _Manager_ReviewIntercept_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Manager_DryRunInject_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DryRunInjectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).DryRunInject(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/telepresence.manager.Manager/DryRunInject",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).DryRunInject(ctx, req.(*DryRunInjectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Manager_ClientTunnel_Handler(srv interface{}, stream grpc.ServerStream) error {
 	return srv.(ManagerServer).ClientTunnel(&managerClientTunnelServer{stream})
 }
@@ -1393,6 +1793,60 @@ func (x *managerWatchDialServer) Send(m *DialRequest) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Manager_ListClientSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).ListClientSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/telepresence.manager.Manager/ListClientSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).ListClientSessions(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_AdminRemoveIntercept_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminRemoveInterceptRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).AdminRemoveIntercept(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/telepresence.manager.Manager/AdminRemoveIntercept",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).AdminRemoveIntercept(ctx, req.(*AdminRemoveInterceptRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Manager_EvictSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ManagerServer).EvictSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/telepresence.manager.Manager/EvictSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ManagerServer).EvictSession(ctx, req.(*SessionInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Manager_ServiceDesc is the grpc.ServiceDesc for Manager service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -1464,10 +1918,26 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetIntercept",
 			Handler:    _Manager_GetIntercept_Handler,
 		},
+		{
+			MethodName: "AttachIntercept",
+			Handler:    _Manager_AttachIntercept_Handler,
+		},
+		{
+			MethodName: "DetachIntercept",
+			Handler:    _Manager_DetachIntercept_Handler,
+		},
+		{
+			MethodName: "HandoverIntercept",
+			Handler:    _Manager_HandoverIntercept_Handler,
+		},
 		{
 			MethodName: "ReviewIntercept",
 			Handler:    _Manager_ReviewIntercept_Handler,
 		},
+		{
+			MethodName: "DryRunInject",
+			Handler:    _Manager_DryRunInject_Handler,
+		},
 		{
 			MethodName: "LookupHost",
 			Handler:    _Manager_LookupHost_Handler,
@@ -1476,6 +1946,18 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AgentLookupHostResponse",
 			Handler:    _Manager_AgentLookupHostResponse_Handler,
 		},
+		{
+			MethodName: "ListClientSessions",
+			Handler:    _Manager_ListClientSessions_Handler,
+		},
+		{
+			MethodName: "AdminRemoveIntercept",
+			Handler:    _Manager_AdminRemoveIntercept_Handler,
+		},
+		{
+			MethodName: "EvictSession",
+			Handler:    _Manager_EvictSession_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1498,6 +1980,21 @@ var Manager_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Manager_WatchClusterInfo_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "WatchAgentsSince",
+			Handler:       _Manager_WatchAgentsSince_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchInterceptsSince",
+			Handler:       _Manager_WatchInterceptsSince_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchClusterInfoSince",
+			Handler:       _Manager_WatchClusterInfoSince_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "ClientTunnel",
 			Handler:       _Manager_ClientTunnel_Handler,